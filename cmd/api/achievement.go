@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// evaluateAchievementsInBackground awards any achievement the user has newly reached
+// and publishes "achievement.awarded" for each. Failures are logged only.
+func (app *application) evaluateAchievementsInBackground(userID uuid.UUID) {
+	app.background(func() {
+		awarded, err := app.models.Achievement.Evaluate(userID)
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{
+				"action":  "evaluate_achievements",
+				"user_id": userID.String(),
+			})
+		}
+
+		for _, a := range awarded {
+			app.publishEvent("achievement.awarded", a.AwardedPayload(userID))
+		}
+	})
+}
+
+// listAchievementsHandler lists every badge with the user's progress and award time
+// GET /v1/achievements
+func (app *application) listAchievementsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	achievements, err := app.models.Achievement.GetAllForUser(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	locale := app.getLocale(r)
+	earned := 0
+	for _, a := range achievements {
+		a.ApplyLocale(locale)
+		if a.Earned {
+			earned++
+		}
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"achievements": achievements,
+		"earned":       earned,
+		"total":        len(achievements),
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
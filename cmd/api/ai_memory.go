@@ -83,12 +83,7 @@ func (app *application) deleteAIMemoryHandler(w http.ResponseWriter, r *http.Req
 
 // deleteMemoryFromQdrant calls the AI service to remove a memory vector.
 func (app *application) deleteMemoryFromQdrant(memoryID string) {
-	aiServiceURL := os.Getenv("AI_SERVICE_URL")
-	if aiServiceURL == "" {
-		aiServiceURL = "http://ai-service:8000"
-	}
-
-	url := fmt.Sprintf("%s/api/internal/memory/%s", aiServiceURL, memoryID)
+	url := fmt.Sprintf("%s/api/internal/memory/%s", app.config.ai.url, memoryID)
 
 	req, err := http.NewRequest(http.MethodDelete, url, nil)
 	if err != nil {
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// createChatThreadHandler starts a conversation, optionally about a journal. A journal
+// has one thread per user; creating a second one is a 409.
+// POST /v1/chat_threads
+func (app *application) createChatThreadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Title     string     `json:"title"`
+		JournalID *uuid.UUID `json:"journal_id"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	thread := &data.ChatThread{
+		UserID:    userID,
+		Title:     strings.TrimSpace(input.Title),
+		JournalID: input.JournalID,
+	}
+	if thread.Title == "" {
+		thread.Title = data.DefaultChatThreadTitle
+	}
+
+	v := validator.New()
+	data.ValidateChatThread(v, thread)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if thread.JournalID != nil {
+		_, err = app.models.UserJournal.Get(*thread.JournalID, userID)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				v.AddError("journal_id", "must reference an existing journal")
+				app.failedValidationResponse(w, r, v.Errors)
+				return
+			}
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	thread, err = app.models.ChatThread.Insert(thread)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateChatThread) {
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Location", fmt.Sprintf("v1/chat_threads/%s", thread.ID))
+
+	err = app.writeJson(w, http.StatusCreated, envolope{"thread": thread}, header)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listChatThreadsHandler lists the user's threads, most recently active first
+// GET /v1/chat_threads?journal_id=...&page=1&page_size=20
+func (app *application) listChatThreadsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	var journalID *uuid.UUID
+	if param := app.readString(qs, "journal_id", ""); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			app.badRequestResponse(w, r, errors.New("journal_id must be a valid UUID"))
+			return
+		}
+		journalID = &id
+	}
+
+	filter := app.readQueryFilter(qs, v, DefaultFilterOptions(
+		"-last_message_at",
+		[]string{"last_message_at", "-last_message_at", "created_at", "-created_at", "title", "-title"},
+	))
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	threads, metadata, err := app.models.ChatThread.GetList(userID, journalID, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"metadata": metadata, "threads": threads}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showChatThreadHandler returns a thread
+// GET /v1/chat_threads/:id
+func (app *application) showChatThreadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, threadID, ok := app.readChatThreadIDs(w, r)
+	if !ok {
+		return
+	}
+
+	thread, err := app.models.ChatThread.Get(threadID, userID)
+	if err != nil {
+		app.chatThreadErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"thread": thread}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// renameChatThreadHandler changes a thread's title
+// PATCH /v1/chat_threads/:id
+func (app *application) renameChatThreadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, threadID, ok := app.readChatThreadIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Title string `json:"title"`
+	}
+
+	err := app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	thread := &data.ChatThread{
+		ID:     threadID,
+		UserID: userID,
+		Title:  strings.TrimSpace(input.Title),
+	}
+
+	v := validator.New()
+	data.ValidateChatThread(v, thread)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	thread, err = app.models.ChatThread.Update(thread)
+	if err != nil {
+		app.chatThreadErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"thread": thread}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteChatThreadHandler deletes a thread and its messages
+// DELETE /v1/chat_threads/:id
+func (app *application) deleteChatThreadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, threadID, ok := app.readChatThreadIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.ChatThread.Delete(threadID, userID)
+	if err != nil {
+		app.chatThreadErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "chat thread deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listChatThreadMessagesHandler returns a page of a thread's messages, oldest first
+// GET /v1/chat_threads/:id/messages?page=1&page_size=50&sort=-created_at
+func (app *application) listChatThreadMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, threadID, ok := app.readChatThreadIDs(w, r)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+	filter := app.readQueryFilter(r.URL.Query(), v, DefaultFilterOptions(
+		"created_at",
+		[]string{"created_at", "-created_at"},
+	))
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err := app.models.ChatThread.Get(threadID, userID)
+	if err != nil {
+		app.chatThreadErrorResponse(w, r, err)
+		return
+	}
+
+	messages, metadata, err := app.models.GuiderChatlog.GetThreadMessages(threadID, userID, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"metadata": metadata, "messages": messages}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) chatThreadErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		http.Error(w, "Chat thread not found", http.StatusNotFound)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readChatThreadIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	threadID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, threadID, true
+}
@@ -1,60 +1,62 @@
 package main
 
 import (
+	"errors"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
 	"tranquara.net/internal/data"
 	"tranquara.net/internal/validator"
 )
 
+// GetEmotionLogs lists the user's emotion logs
+// GET /v1/emotion_log?page=&page_size=&sort=-created_at&start_time=&end_time=&emotion=&source=&search=
+// "start" and "end" are still accepted as aliases of start_time and end_time.
 func (app *application) GetEmotionLogs(w http.ResponseWriter, r *http.Request) {
 	v := validator.New()
 	qs := r.URL.Query()
 
-	// Parse query parameters
 	id, err := app.GetUserUUIDFromContext(r.Context())
-
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	startStr := app.readString(qs, "start", "")
-	endStr := app.readString(qs, "end", "")
-
-	if startStr == "" || endStr == "" {
-		app.badRequestResponse(w, r, nil)
-		return
+	for alias, key := range map[string]string{"start": "start_time", "end": "end_time"} {
+		if qs.Get(key) == "" && qs.Get(alias) != "" {
+			qs.Set(key, qs.Get(alias))
+		}
 	}
 
-	startTime, err := time.Parse(time.RFC3339, startStr)
-	if err != nil {
-		v.AddError("start", "must be a valid RFC3339 timestamp")
-	}
+	filter := app.readQueryFilter(qs, v, FilterOptions{
+		DefaultPage:     1,
+		DefaultPageSize: 20,
+		DefaultSort:     "-created_at",
+		SortSafelist:    []string{"created_at", "-created_at"},
+		SearchFields:    []string{"context"},
+		TimeField:       "created_at",
+	})
 
-	endTime, err := time.Parse(time.RFC3339, endStr)
-	if err != nil {
-		v.AddError("end", "must be a valid RFC3339 timestamp")
+	// Emotion filter accepts taxonomy codes as well as legacy labels
+	emotion := app.readString(qs, "emotion", "")
+	if emotion != "" {
+		if code, ok := data.NormalizeEmotionCode(emotion); ok {
+			emotion = code
+		} else {
+			emotion = strings.ToLower(strings.TrimSpace(emotion))
+		}
 	}
+	source := app.readString(qs, "source", "")
 
-	filter := app.readQueryFilter(qs, v, TimeRangeFilterOptions(
-		"created_at",
-		[]string{"created_at", "-created_at"},
-		"created_at",
-	))
-
-	// Apply time range to filter
-	filter.WithTimeRange(&startTime, &endTime, "created_at")
-
+	filter.Validate(v)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	// Query from database
-	logs, metadata, err := app.models.EmotionLog.GetList(id, filter)
+	logs, metadata, err := app.models.EmotionLog.GetList(id, filter, emotion, source)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -85,19 +87,178 @@ func (app *application) CreateEmotionLog(w http.ResponseWriter, r *http.Request)
 
 	input.UserID = id
 
-	if input.UserID == uuid.Nil || input.Emotion == "" {
+	if input.UserID == uuid.Nil || (input.Emotion == "" && len(input.Emotions) == 0) {
 		http.Error(w, "Missing required fields", http.StatusBadRequest)
 		return
 	}
 
+	input.NormalizeEmotions()
+
+	v := validator.New()
+	data.ValidateEmotionLog(v, &input)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	newLog, err := app.models.EmotionLog.Insert(&input)
 	if err != nil {
 		http.Error(w, "Failed to insert emotion log: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Logging an emotion counts as activity for the streak
+	app.recordActivity(id, data.StreakActivityEmotionLog)
+
+	// Re-check for a sustained mood decline (non-blocking)
+	app.analyseWellbeingInBackground(id)
+
 	err = app.writeJson(w, http.StatusCreated, newLog, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// UpdateEmotionLog replaces the emotions, source and context of an emotion log
+// PUT /v1/emotion_log/:id
+func (app *application) UpdateEmotionLog(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	logID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Emotion  string              `json:"emotion"`
+		Emotions []data.EmotionEntry `json:"emotions"`
+		Source   *string             `json:"source"`
+		Context  *string             `json:"context"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	emotionLog, err := app.models.EmotionLog.Get(logID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Emotions are replaced as a whole when given; a bare "emotion" replaces them with one entry
+	emotionsChanged := true
+	switch {
+	case len(input.Emotions) > 0:
+		emotionLog.Emotions = input.Emotions
+	case input.Emotion != "":
+		emotionLog.Emotion = input.Emotion
+		emotionLog.Emotions = nil
+	default:
+		emotionsChanged = false
+	}
+	if input.Source != nil {
+		emotionLog.Source = *input.Source
+	}
+	if input.Context != nil {
+		emotionLog.Context = *input.Context
+	}
+
+	// Untouched emotions are kept as stored, so legacy free-text logs stay editable
+	v := validator.New()
+	if emotionsChanged {
+		emotionLog.NormalizeEmotions()
+		data.ValidateEmotionLog(v, emotionLog)
+	} else {
+		data.ValidateEmotionLogNotes(v, emotionLog)
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.EmotionLog.Update(emotionLog)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.activityChanged(userID, data.StreakActivityEmotionLog)
+	app.analyseWellbeingInBackground(userID)
+
+	err = app.writeJson(w, http.StatusOK, envolope{"emotion_log": emotionLog}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// DeleteEmotionLog deletes an emotion log
+// DELETE /v1/emotion_log/:id
+func (app *application) DeleteEmotionLog(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	logID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return
+	}
+
+	err = app.models.EmotionLog.Delete(logID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.activityChanged(userID, data.StreakActivityEmotionLog)
+	app.analyseWellbeingInBackground(userID)
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "emotion log deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// GetEmotionTaxonomy returns the emotion wheel used to validate emotion log codes
+// GET /v1/emotion_taxonomy
+func (app *application) GetEmotionTaxonomy(w http.ResponseWriter, r *http.Request) {
+	err := app.writeJson(w, http.StatusOK, envolope{
+		"version":  data.EmotionTaxonomyVersion,
+		"emotions": data.EmotionTaxonomy(app.getLocale(r)),
+		"intensity": envolope{
+			"min":     data.MinEmotionIntensity,
+			"max":     data.MaxEmotionIntensity,
+			"default": data.DefaultEmotionIntensity,
+		},
+		"max_emotions_per_log": data.MaxEmotionsPerLog,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
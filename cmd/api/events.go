@@ -0,0 +1,29 @@
+package main
+
+import (
+	"tranquara.net/internal/pubsub"
+)
+
+// publishEvent publishes a domain event to the app_events queue.
+// This is non-blocking — failures are logged but don't affect the HTTP response.
+func (app *application) publishEvent(event string, payload any) {
+	if app.rabbitchannel == nil {
+		app.logger.PrintInfo("RabbitMQ not connected, skipping event publish", map[string]string{
+			"event": event,
+		})
+		return
+	}
+
+	err := pubsub.PublishEvent(app.rabbitchannel, event, payload)
+	if err != nil {
+		app.logger.PrintError(err, map[string]string{
+			"action": "publish_event",
+			"event":  event,
+		})
+		return
+	}
+
+	app.logger.PrintInfo("published event", map[string]string{
+		"event": event,
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/google/uuid"
 	"tranquara.net/internal/data"
 	"tranquara.net/internal/validator"
 )
@@ -13,6 +15,7 @@ func (app *application) showExerciseHanlder(w http.ResponseWriter, r *http.Reque
 	id, err := app.readIDParam(r)
 	if err != nil {
 		app.notFoundRespond(w, r)
+		return
 	}
 
 	exercise, err := app.models.Exercise.Get(id)
@@ -26,6 +29,8 @@ func (app *application) showExerciseHanlder(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	exercise.ApplyLocale(app.getLocale(r))
+
 	err = app.writeJson(w, http.StatusOK, envolope{"exercise": exercise}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -35,10 +40,19 @@ func (app *application) showExerciseHanlder(w http.ResponseWriter, r *http.Reque
 
 func (app *application) createExerciseHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Title        string `json:"title"`
-		Description  string `json:"description"`
-		MediaLink    string `json:"media_link"`
-		ExerciseType string `json:"exercise_type"`
+		Title           string     `json:"title"`
+		TitleVi         *string    `json:"title_vi"`
+		Description     string     `json:"description"`
+		DescriptionVi   *string    `json:"description_vi"`
+		MediaLink       string     `json:"media_link"`
+		MediaAssetID    *uuid.UUID `json:"media_asset_id"`
+		ExerciseType    string     `json:"exercise_type"`
+		Category        string     `json:"category"`
+		Tags            []string   `json:"tags"`
+		Difficulty      string     `json:"difficulty"`
+		DurationMinutes *int       `json:"duration_minutes"`
+		Steps           []string   `json:"steps"`
+		StepsVi         []string   `json:"steps_vi"`
 	}
 
 	err := app.readJson(w, r, &input)
@@ -48,20 +62,45 @@ func (app *application) createExerciseHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	exercise := &data.Exercise{
-		Title:        input.Title,
-		Description:  input.Description,
-		MediaLink:    input.MediaLink,
-		ExerciseType: input.ExerciseType,
+		Title:           strings.TrimSpace(input.Title),
+		TitleVi:         input.TitleVi,
+		Description:     input.Description,
+		DescriptionVi:   input.DescriptionVi,
+		MediaLink:       input.MediaLink,
+		MediaAssetID:    input.MediaAssetID,
+		ExerciseType:    input.ExerciseType,
+		Category:        strings.TrimSpace(input.Category),
+		Tags:            normalizeTags(input.Tags),
+		Difficulty:      input.Difficulty,
+		DurationMinutes: input.DurationMinutes,
+		Steps:           input.Steps,
+		StepsVi:         input.StepsVi,
+	}
+	if exercise.Difficulty == "" {
+		exercise.Difficulty = data.ExerciseDifficultyBeginner
+	}
+
+	v := validator.New()
+	data.ValidateExercise(v, exercise)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
 
-	err = app.models.Exercise.Insert(exercise)
+	exercise, err = app.models.Exercise.Insert(exercise)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrUnknownMediaAsset):
+			v.AddError("media_asset_id", "must reference an existing media asset")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
 	header := make(http.Header)
-	header.Set("Content-Location", fmt.Sprintf("v1/exercises/%d", exercise.ExerciseID))
+	header.Set("Content-Location", fmt.Sprintf("v1/exercise/%s", exercise.ExerciseID))
 
 	err = app.writeJson(w, http.StatusCreated, exercise, header)
 	if err != nil {
@@ -71,24 +110,34 @@ func (app *application) createExerciseHandler(w http.ResponseWriter, r *http.Req
 
 func (app *application) updateExerciseHandler(w http.ResponseWriter, r *http.Request) {
 	exerciseID, err := app.readIDParam(r)
-	if err != nil || exerciseID < 0 {
+	if err != nil {
 		app.notFoundRespond(w, r)
+		return
 	}
 
 	var input struct {
-		Title        *string `json:"title"`
-		Description  *string `json:"description"`
-		MediaLink    *string `json:"media_link"`
-		ExerciseType *string `json:"exercise_type"`
+		Title           *string    `json:"title"`
+		TitleVi         *string    `json:"title_vi"`
+		Description     *string    `json:"description"`
+		DescriptionVi   *string    `json:"description_vi"`
+		MediaLink       *string    `json:"media_link"`
+		MediaAssetID    *uuid.UUID `json:"media_asset_id"`
+		ExerciseType    *string    `json:"exercise_type"`
+		Category        *string    `json:"category"`
+		Tags            *[]string  `json:"tags"`
+		Difficulty      *string    `json:"difficulty"`
+		DurationMinutes *int       `json:"duration_minutes"`
+		Steps           *[]string  `json:"steps"`
+		StepsVi         *[]string  `json:"steps_vi"`
 	}
 
 	err = app.readJson(w, r, &input)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.badRequestResponse(w, r, err)
+		return
 	}
 
 	exercise, err := app.models.Exercise.Get(exerciseID)
-
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -96,28 +145,68 @@ func (app *application) updateExerciseHandler(w http.ResponseWriter, r *http.Req
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
+		return
 	}
 
 	if input.Title != nil {
-
-		exercise.Title = *input.Title
+		exercise.Title = strings.TrimSpace(*input.Title)
+	}
+	if input.TitleVi != nil {
+		exercise.TitleVi = input.TitleVi
 	}
-
 	if input.Description != nil {
 		exercise.Description = *input.Description
 	}
-
+	if input.DescriptionVi != nil {
+		exercise.DescriptionVi = input.DescriptionVi
+	}
 	if input.MediaLink != nil {
 		exercise.MediaLink = *input.MediaLink
 	}
-
+	if input.MediaAssetID != nil {
+		exercise.MediaAssetID = input.MediaAssetID
+	}
 	if input.ExerciseType != nil {
 		exercise.ExerciseType = *input.ExerciseType
 	}
+	if input.Category != nil {
+		exercise.Category = strings.TrimSpace(*input.Category)
+	}
+	if input.Tags != nil {
+		exercise.Tags = normalizeTags(*input.Tags)
+	}
+	if input.Difficulty != nil {
+		exercise.Difficulty = *input.Difficulty
+	}
+	if input.DurationMinutes != nil {
+		exercise.DurationMinutes = input.DurationMinutes
+	}
+	if input.Steps != nil {
+		exercise.Steps = *input.Steps
+	}
+	if input.StepsVi != nil {
+		exercise.StepsVi = *input.StepsVi
+	}
+
+	v := validator.New()
+	data.ValidateExercise(v, exercise)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
 
-	err = app.models.Exercise.Update(exercise)
+	exercise, err = app.models.Exercise.Update(exercise)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundRespond(w, r)
+		case errors.Is(err, data.ErrUnknownMediaAsset):
+			v.AddError("media_asset_id", "must reference an existing media asset")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
 	}
 
 	err = app.writeJson(w, http.StatusOK, exercise, nil)
@@ -136,24 +225,47 @@ func (app *application) deleteExerciseHandler(w http.ResponseWriter, r *http.Req
 
 	err = app.models.Exercise.Delete(exerciseID)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundRespond(w, r)
+		case errors.Is(err, data.ErrExerciseInUse):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
-	fmt.Fprintf(w, "Exercise id: %d deleted", exerciseID)
+	fmt.Fprintf(w, "Exercise id: %s deleted", exerciseID)
 }
 
+// listExerciseHandler lists the exercise catalog
+// GET /v1/exercise?search=breathing&category=sleep&difficulty=beginner&tags=calm,focus&max_duration=10
+// "title" is still accepted as a search term for older clients.
 func (app *application) listExerciseHandler(w http.ResponseWriter, r *http.Request) {
 
 	v := validator.New()
 	qs := r.URL.Query()
 
-	title := app.readString(qs, "title", "")
-	exerciseType := app.readString(qs, "exercise_type", "")
+	criteria := data.ExerciseCriteria{
+		Search:       app.readString(qs, "search", app.readString(qs, "title", "")),
+		ExerciseType: app.readString(qs, "exercise_type", ""),
+		Category:     app.readString(qs, "category", ""),
+		Difficulty:   app.readString(qs, "difficulty", ""),
+		Tags:         normalizeTags(app.readCSV(qs, "tags", nil)),
+		MaxDuration:  app.readInt(qs, "max_duration", 0, v),
+	}
+
+	if criteria.Difficulty != "" {
+		v.Check(validator.In(criteria.Difficulty, data.ExerciseDifficultyBeginner, data.ExerciseDifficultyIntermediate, data.ExerciseDifficultyAdvanced),
+			"difficulty", "must be one of beginner, intermediate, advanced")
+	}
+	v.Check(criteria.MaxDuration >= 0, "max_duration", "must not be negative")
 
 	filter := app.readQueryFilter(qs, v, DefaultFilterOptions(
-		"exercise_id",
-		[]string{"exercise_id", "title", "exercise_type", "-exercise_id", "-title", "-exercise_type"},
+		"title",
+		[]string{"exercise_id", "title", "exercise_type", "category", "duration_minutes", "created_at",
+			"-exercise_id", "-title", "-exercise_type", "-category", "-duration_minutes", "-created_at"},
 	))
 
 	if !v.Valid() {
@@ -161,12 +273,17 @@ func (app *application) listExerciseHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	exercises, metadata, err := app.models.Exercise.GetList(title, exerciseType, filter)
+	exercises, metadata, err := app.models.Exercise.GetList(criteria, filter)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
+	locale := app.getLocale(r)
+	for _, exercise := range exercises {
+		exercise.ApplyLocale(locale)
+	}
+
 	err = app.writeJson(w, http.StatusOK, envolope{"metadata": metadata, "exercises": exercises}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -174,3 +291,15 @@ func (app *application) listExerciseHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 }
+
+// normalizeTags lower-cases and trims tags, dropping empty ones.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+)
+
+// listExerciseFavouritesHandler returns the user's favourite exercises
+// GET /v1/exercise_favourites
+func (app *application) listExerciseFavouritesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	exercises, err := app.models.ExerciseFavourite.GetAll(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	locale := app.getLocale(r)
+	for _, e := range exercises {
+		e.ApplyLocale(locale)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"exercises": exercises}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// addExerciseFavouriteHandler favourites an exercise
+// PUT /v1/exercise_favourites/:id
+func (app *application) addExerciseFavouriteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, exerciseID, ok := app.readExerciseFavouriteIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.ExerciseFavourite.Add(userID, exerciseID)
+	if err != nil {
+		if errors.Is(err, data.ErrUnknownExercise) {
+			http.Error(w, "Exercise not found", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "exercise favourited"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// removeExerciseFavouriteHandler unfavourites an exercise
+// DELETE /v1/exercise_favourites/:id
+func (app *application) removeExerciseFavouriteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, exerciseID, ok := app.readExerciseFavouriteIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.ExerciseFavourite.Remove(userID, exerciseID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Favourite not found", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "exercise unfavourited"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readExerciseFavouriteIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	exerciseID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, exerciseID, true
+}
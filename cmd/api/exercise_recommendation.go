@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// getRecommendedExercisesHandler ranks the exercise catalog for the authenticated user
+// GET /v1/exercise/recommended?available_minutes=10&limit=5&tz=Asia/Ho_Chi_Minh
+func (app *application) getRecommendedExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	limit := app.readInt(qs, "limit", 5, v)
+	v.Check(limit > 0 && limit <= data.MaxPageSize, "limit", "must be between 1 and 100")
+
+	available := app.readInt(qs, "available_minutes", 0, v)
+	v.Check(available >= 0 && available <= data.MaxExerciseDuration, "available_minutes", "must be between 0 and 240")
+
+	loc, err := app.userLocation(r, userID, v)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	exercises, err := app.models.Exercise.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	signals, err := app.exerciseSignals(userID, time.Now().In(loc), available)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	recommendations := data.RankExercises(exercises, signals)
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	locale := app.getLocale(r)
+	for _, rec := range recommendations {
+		rec.Exercise.ApplyLocale(locale)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"recommendations": recommendations,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// exerciseSignals gathers the user's latest mood and emotions, completion history,
+// local time and available time for the exercise ranking. The emotions come from
+// whichever is newer: the latest emotion log or the latest journal's mood label.
+func (app *application) exerciseSignals(userID uuid.UUID, now time.Time, availableMinutes int) (data.ExerciseSignals, error) {
+	since := now.Add(-recommendationLookback)
+
+	signals := data.ExerciseSignals{
+		LocalHour:        now.Hour(),
+		AvailableMinutes: availableMinutes,
+	}
+
+	var journalAt time.Time
+	points, err := app.models.UserJournal.GetMoodPoints(userID, since)
+	if err != nil {
+		return signals, err
+	}
+	if len(points) > 0 {
+		latest := points[len(points)-1]
+		signals.LatestMood = &latest.Score
+		journalAt = latest.At
+		if latest.Label != "" {
+			signals.LatestEmotions = []string{strings.ToLower(latest.Label)}
+		}
+	}
+
+	logs, err := app.models.EmotionLog.GetSince(userID, since)
+	if err != nil {
+		return signals, err
+	}
+	if len(logs) > 0 && logs[len(logs)-1].CreatedAt.After(journalAt) {
+		signals.LatestEmotions = latestEmotions(logs[len(logs)-1])
+	}
+
+	signals.History, err = app.models.UserCompletedExercise.HistoryByExercise(userID)
+	if err != nil {
+		return signals, err
+	}
+
+	return signals, nil
+}
+
+// latestEmotions returns the lower-cased emotions of a log, most intense first.
+func latestEmotions(log *data.EmotionLog) []string {
+	entries := append([]data.EmotionEntry(nil), log.Emotions...)
+	if len(entries) == 0 && log.Emotion != "" {
+		entries = []data.EmotionEntry{{Code: log.Emotion}}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Intensity > entries[j].Intensity
+	})
+
+	emotions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		emotions = append(emotions, strings.ToLower(e.Code))
+	}
+	return emotions
+}
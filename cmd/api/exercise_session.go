@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// startExerciseSessionHandler starts timing an exercise
+// POST /v1/exercise_sessions
+func (app *application) startExerciseSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		ExerciseID uuid.UUID `json:"exercise_id"`
+		MoodBefore *int      `json:"mood_before"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.ExerciseID != uuid.Nil, "exercise_id", "must be provided")
+	data.ValidateMoodRating(v, "mood_before", input.MoodBefore)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Exercise.Get(input.ExerciseID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			v.AddError("exercise_id", "must reference an existing exercise")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	session, err := app.models.ExerciseSession.Start(&data.ExerciseSession{
+		UserID:     userID,
+		ExerciseID: input.ExerciseID,
+		MoodBefore: input.MoodBefore,
+	})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Location", fmt.Sprintf("v1/exercise_sessions/%s", session.ID))
+
+	err = app.writeJson(w, http.StatusCreated, envolope{"session": session}, header)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listExerciseSessionsHandler lists the user's sessions, newest first
+// GET /v1/exercise_sessions?status=active
+func (app *application) listExerciseSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	status := app.readString(qs, "status", "")
+	if status != "" {
+		v.Check(validator.In(status, data.ExerciseSessionActive, data.ExerciseSessionPaused, data.ExerciseSessionCompleted, data.ExerciseSessionAbandoned),
+			"status", "must be one of active, paused, completed, abandoned")
+	}
+
+	filter := app.readQueryFilter(qs, v, TimeRangeFilterOptions(
+		"-started_at",
+		[]string{"started_at", "-started_at", "active_seconds", "-active_seconds"},
+		"started_at",
+	))
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	sessions, metadata, err := app.models.ExerciseSession.GetList(userID, status, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	for _, s := range sessions {
+		s.ActiveSeconds = s.Elapsed(now)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"metadata": metadata, "sessions": sessions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showExerciseSessionHandler returns a session with its active time so far
+// GET /v1/exercise_sessions/:id
+func (app *application) showExerciseSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID, ok := app.readExerciseSessionIDs(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := app.models.ExerciseSession.Get(sessionID, userID)
+	if err != nil {
+		app.exerciseSessionErrorResponse(w, r, err)
+		return
+	}
+	session.ActiveSeconds = session.Elapsed(time.Now())
+
+	err = app.writeJson(w, http.StatusOK, envolope{"session": session}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// exerciseSessionActionHandler pauses, resumes, completes or abandons a session.
+// Completing and abandoning accept an optional {"mood_after": 1-10}; completing
+// records the exercise as completed with the measured duration.
+// POST /v1/exercise_sessions/:id/pause|resume|complete|abandon
+func (app *application) exerciseSessionActionHandler(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, sessionID, ok := app.readExerciseSessionIDs(w, r)
+		if !ok {
+			return
+		}
+
+		var input struct {
+			MoodAfter *int `json:"mood_after"`
+		}
+
+		if action == data.ExerciseSessionComplete || action == data.ExerciseSessionAbandon {
+			if r.ContentLength != 0 {
+				err := app.readJson(w, r, &input)
+				if err != nil {
+					app.badRequestResponse(w, r, err)
+					return
+				}
+			}
+
+			v := validator.New()
+			data.ValidateMoodRating(v, "mood_after", input.MoodAfter)
+			if !v.Valid() {
+				app.failedValidationResponse(w, r, v.Errors)
+				return
+			}
+		}
+
+		session, err := app.models.ExerciseSession.Transition(sessionID, userID, action, input.MoodAfter)
+		if err != nil {
+			app.exerciseSessionErrorResponse(w, r, err)
+			return
+		}
+
+		if session.Status == data.ExerciseSessionCompleted {
+			app.recordActivity(userID, data.StreakActivityExercise)
+		}
+
+		err = app.writeJson(w, http.StatusOK, envolope{"session": session}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+	}
+}
+
+// completeExerciseSessionStepHandler marks a step (zero-based) of a multi-step exercise as done
+// POST /v1/exercise_sessions/:id/steps
+func (app *application) completeExerciseSessionStepHandler(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID, ok := app.readExerciseSessionIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Step *int `json:"step"`
+	}
+
+	err := app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	session, err := app.models.ExerciseSession.Get(sessionID, userID)
+	if err != nil {
+		app.exerciseSessionErrorResponse(w, r, err)
+		return
+	}
+
+	exercise, err := app.models.Exercise.Get(session.ExerciseID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.Step != nil, "step", "must be provided")
+	if input.Step != nil {
+		v.Check(*input.Step >= 0 && *input.Step < len(exercise.Steps), "step", fmt.Sprintf("must be between 0 and %d", len(exercise.Steps)-1))
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	session, err = app.models.ExerciseSession.CompleteStep(sessionID, userID, *input.Step)
+	if err != nil {
+		app.exerciseSessionErrorResponse(w, r, err)
+		return
+	}
+	session.ActiveSeconds = session.Elapsed(time.Now())
+
+	err = app.writeJson(w, http.StatusOK, envolope{"session": session}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) exerciseSessionErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		http.Error(w, "Exercise session not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrInvalidSessionTransition):
+		app.errorResponse(w, r, http.StatusConflict, err.Error())
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readExerciseSessionIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	sessionID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, sessionID, true
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// goalsProgress computes the current-period progress of the user's active goals and
+// publishes "goal.met" the first time a goal reaches its target in a period.
+func (app *application) goalsProgress(userID uuid.UUID) ([]*data.GoalProgress, error) {
+	loc, err := app.userTimezone(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress, met, err := app.models.Goal.CheckProgress(userID, time.Now(), loc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, payload := range met {
+		app.publishEvent("goal.met", payload)
+	}
+
+	return progress, nil
+}
+
+// checkGoalsInBackground re-computes goal progress after an activity so "goal.met"
+// is emitted as soon as a target is reached. Failures are logged only.
+func (app *application) checkGoalsInBackground(userID uuid.UUID) {
+	app.background(func() {
+		_, err := app.goalsProgress(userID)
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{
+				"action":  "check_goals",
+				"user_id": userID.String(),
+			})
+		}
+	})
+}
+
+// createGoalHandler creates a weekly or monthly goal
+// POST /v1/goals
+func (app *application) createGoalHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Title     string `json:"title"`
+		Metric    string `json:"metric"`
+		Qualifier string `json:"qualifier"`
+		Target    int    `json:"target"`
+		Period    string `json:"period"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	goal := &data.Goal{
+		UserID:    userID,
+		Title:     strings.TrimSpace(input.Title),
+		Metric:    input.Metric,
+		Qualifier: strings.TrimSpace(input.Qualifier),
+		Target:    input.Target,
+		Period:    input.Period,
+		IsActive:  true,
+	}
+	if goal.Period == "" {
+		goal.Period = data.GoalPeriodWeek
+	}
+
+	v := validator.New()
+	data.ValidateGoal(v, goal)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	created, err := app.models.Goal.Insert(goal)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusCreated, envolope{"goal": created}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listGoalsHandler lists the user's goals
+// GET /v1/goals               → all goals
+// GET /v1/goals?active=true   → active goals only
+func (app *application) listGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	activeOnly := r.URL.Query().Get("active") == "true"
+
+	goals, err := app.models.Goal.GetAllByUser(userID, activeOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"goals": goals}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateGoalHandler updates the provided fields of a goal
+// PATCH /v1/goals?id=<uuid>
+func (app *application) updateGoalHandler(w http.ResponseWriter, r *http.Request) {
+	userID, goalID, ok := app.readGoalIDs(w, r)
+	if !ok {
+		return
+	}
+
+	goal, err := app.models.Goal.Get(goalID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Goal not found", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Title     *string `json:"title"`
+		Metric    *string `json:"metric"`
+		Qualifier *string `json:"qualifier"`
+		Target    *int    `json:"target"`
+		Period    *string `json:"period"`
+		IsActive  *bool   `json:"is_active"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Title != nil {
+		goal.Title = strings.TrimSpace(*input.Title)
+	}
+	if input.Metric != nil {
+		goal.Metric = *input.Metric
+	}
+	if input.Qualifier != nil {
+		goal.Qualifier = strings.TrimSpace(*input.Qualifier)
+	}
+	if input.Target != nil {
+		goal.Target = *input.Target
+	}
+	if input.Period != nil {
+		goal.Period = *input.Period
+	}
+	if input.IsActive != nil {
+		goal.IsActive = *input.IsActive
+	}
+
+	v := validator.New()
+	data.ValidateGoal(v, goal)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	updated, err := app.models.Goal.Update(goal)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Goal not found", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"goal": updated}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteGoalHandler deletes a goal
+// DELETE /v1/goals?id=<uuid>
+func (app *application) deleteGoalHandler(w http.ResponseWriter, r *http.Request) {
+	userID, goalID, ok := app.readGoalIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Goal.Delete(goalID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Goal not found", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "Goal deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getGoalsProgressHandler returns the current-period progress of every active goal,
+// with periods in the user's timezone
+// GET /v1/goals/progress
+func (app *application) getGoalsProgressHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	progress, err := app.goalsProgress(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"progress": progress}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readGoalIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		http.Error(w, "id query parameter is required", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	goalID, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, goalID, true
+}
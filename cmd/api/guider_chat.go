@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+const (
+	// guiderChatTimeout bounds a whole reply, replacing the server's write timeout
+	// which would otherwise cut long streams short.
+	guiderChatTimeout = 2 * time.Minute
+	// guiderChatHistory is how many earlier messages are sent to the AI service as context.
+	guiderChatHistory = 20
+)
+
+// guiderChatlogStore stores the messages of a guider chat. It is the
+// GuiderChatlog model outside of tests.
+type guiderChatlogStore interface {
+	Insert(chatLog *data.GuiderChatlog) (*data.GuiderChatlog, error)
+	RecentThreadMessages(threadID uuid.UUID, limit int) ([]*data.GuiderChatlog, error)
+}
+
+// GuiderChatRequest is what the core sends to the AI service's streaming chat endpoint.
+type GuiderChatRequest struct {
+	UserID  uuid.UUID             `json:"user_id"`
+	Message string                `json:"message"`
+	History []GuiderChatTurn      `json:"history"`
+	Journal *GuiderJournalContext `json:"journal,omitempty"`
+}
+
+// GuiderChatTurn is an earlier message of the conversation, oldest first.
+type GuiderChatTurn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// GuiderJournalContext is the journal the conversation is about.
+type GuiderJournalContext struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	MoodScore *int      `json:"mood_score,omitempty"`
+	MoodLabel *string   `json:"mood_label,omitempty"`
+}
+
+// guiderChatHandler stores the user's message, asks the AI service for a reply and
+// streams it back as Server-Sent Events. The message goes to thread_id when given,
+// otherwise to the thread about journal_id, otherwise to a new general thread.
+//
+//	event: thread        the thread the conversation belongs to
+//	event: user_message  the stored user message
+//	event: delta         {"content": "..."} for each chunk of the reply
+//	event: done          the stored assistant message
+//	event: error         {"error": "..."} when the reply fails; nothing more is stored
+//
+// POST /v1/guider/chat
+func (app *application) guiderChatHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		ThreadID  *uuid.UUID `json:"thread_id"`
+		JournalID *uuid.UUID `json:"journal_id"`
+		Message   string     `json:"message"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.ThreadID == nil || input.JournalID == nil, "thread_id", "must not be combined with journal_id")
+	data.ValidateChatMessage(v, input.Message)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	thread, ok := app.guiderChatThread(w, r, userID, input.ThreadID, input.JournalID, input.Message)
+	if !ok {
+		return
+	}
+
+	chatRequest := GuiderChatRequest{
+		UserID:  userID,
+		Message: input.Message,
+	}
+
+	if thread.JournalID != nil {
+		journal, err := app.models.UserJournal.Get(*thread.JournalID, userID)
+		switch {
+		case err == nil:
+			chatRequest.Journal = &GuiderJournalContext{
+				ID:        journal.ID,
+				Title:     journal.Title,
+				Content:   journal.Content,
+				MoodScore: journal.MoodScore,
+				MoodLabel: journal.MoodLabel,
+			}
+		case !errors.Is(err, data.ErrRecordNotFound):
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	app.relayGuiderChat(w, r, thread, chatRequest)
+}
+
+// relayGuiderChat stores the user's message in thread, streams the AI service's reply
+// to the client as Server-Sent Events and stores the reply once it is complete.
+func (app *application) relayGuiderChat(w http.ResponseWriter, r *http.Request, thread *data.ChatThread, chatRequest GuiderChatRequest) {
+	history, err := app.guiderChatlogs.RecentThreadMessages(thread.ID, guiderChatHistory)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	chatRequest.History = guiderChatTurns(history)
+
+	userMessage, err := app.guiderChatlogs.Insert(&data.GuiderChatlog{
+		UserId:     thread.UserID,
+		JournalId:  thread.JournalID,
+		ThreadId:   &thread.ID,
+		SenderType: data.ChatSenderUser,
+		Message:    chatRequest.Message,
+	})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(guiderChatTimeout))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	err = app.writeSSE(w, "thread", thread)
+	if err == nil {
+		err = app.writeSSE(w, "user_message", userMessage)
+	}
+	if err != nil {
+		return // the client has gone away
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), guiderChatTimeout)
+	defer cancel()
+
+	reply, err := app.streamGuiderReply(ctx, chatRequest, func(delta string) error {
+		return app.writeSSE(w, "delta", envolope{"content": delta})
+	})
+	if err != nil {
+		app.logger.PrintError(err, map[string]string{"action": "guider_chat", "thread_id": thread.ID.String()})
+		_ = app.writeSSE(w, "error", envolope{"error": "the guider could not reply, please try again"})
+		return
+	}
+
+	assistantMessage, err := app.guiderChatlogs.Insert(&data.GuiderChatlog{
+		UserId:     thread.UserID,
+		JournalId:  thread.JournalID,
+		ThreadId:   &thread.ID,
+		SenderType: data.ChatSenderAI,
+		Message:    reply,
+	})
+	if err != nil {
+		app.logger.PrintError(err, map[string]string{"action": "guider_chat", "thread_id": thread.ID.String()})
+		_ = app.writeSSE(w, "error", envolope{"error": "the reply could not be saved"})
+		return
+	}
+
+	_ = app.writeSSE(w, "done", assistantMessage)
+}
+
+// guiderChatThread resolves the thread a chat message belongs to, writing an error
+// response and returning false when the thread or journal does not exist.
+func (app *application) guiderChatThread(w http.ResponseWriter, r *http.Request, userID uuid.UUID, threadID, journalID *uuid.UUID, message string) (*data.ChatThread, bool) {
+	var thread *data.ChatThread
+	var err error
+
+	switch {
+	case threadID != nil:
+		thread, err = app.models.ChatThread.Get(*threadID, userID)
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Chat thread not found", http.StatusNotFound)
+			return nil, false
+		}
+	case journalID != nil:
+		_, err = app.models.UserJournal.Get(*journalID, userID)
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Journal not found", http.StatusNotFound)
+			return nil, false
+		}
+		if err == nil {
+			thread, err = app.models.ChatThread.GetForJournal(userID, *journalID)
+		}
+	default:
+		thread, err = app.models.ChatThread.Insert(&data.ChatThread{
+			UserID: userID,
+			Title:  data.ChatThreadTitle(message),
+		})
+	}
+
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+	return thread, true
+}
+
+// streamGuiderReply posts the request to the AI service and calls onDelta for every
+// chunk of its Server-Sent Events reply. Each event carries {"delta": "..."}; the
+// stream ends with "data: [DONE]". It returns the whole reply.
+func (app *application) streamGuiderReply(ctx context.Context, chatRequest GuiderChatRequest, onDelta func(string) error) (string, error) {
+	body, err := json.Marshal(chatRequest)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, app.config.ai.url+"/api/guider/chat/stream", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if key := os.Getenv("INTERNAL_API_KEY"); key != "" {
+		req.Header.Set("X-Internal-Key", key)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("ai service returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var reply strings.Builder
+	var event string
+	var payload []string
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			continue
+		case strings.HasPrefix(line, "data:"):
+			payload = append(payload, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		case line != "":
+			continue // comments and unknown fields
+		}
+
+		// A blank line dispatches the event
+		eventData := strings.Join(payload, "\n")
+		eventName := event
+		event, payload = "", nil
+
+		if eventData == "" {
+			continue
+		}
+		if eventData == "[DONE]" {
+			if reply.Len() == 0 {
+				return "", errors.New("ai service sent an empty reply")
+			}
+			return reply.String(), nil
+		}
+
+		var chunk struct {
+			Delta string `json:"delta"`
+			Error string `json:"error"`
+		}
+		err = json.Unmarshal([]byte(eventData), &chunk)
+		if err != nil {
+			return "", fmt.Errorf("ai service sent an invalid event: %w", err)
+		}
+		if eventName == "error" || chunk.Error != "" {
+			return "", fmt.Errorf("ai service error: %s", chunk.Error)
+		}
+		if chunk.Delta == "" {
+			continue
+		}
+
+		reply.WriteString(chunk.Delta)
+		err = onDelta(chunk.Delta)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return "", err
+	}
+	return "", errors.New("ai service closed the stream before it was done")
+}
+
+// writeSSE writes one Server-Sent Event with a JSON payload and flushes it.
+func (app *application) writeSSE(w http.ResponseWriter, event string, payload any) error {
+	js, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, js)
+	if err != nil {
+		return err
+	}
+
+	return http.NewResponseController(w).Flush()
+}
+
+// guiderChatTurns converts the latest chat logs to the AI service's history format.
+func guiderChatTurns(chatlogs []*data.GuiderChatlog) []GuiderChatTurn {
+	if len(chatlogs) > guiderChatHistory {
+		chatlogs = chatlogs[len(chatlogs)-guiderChatHistory:]
+	}
+
+	turns := make([]GuiderChatTurn, 0, len(chatlogs))
+	for _, c := range chatlogs {
+		role := "assistant"
+		if c.SenderType == data.ChatSenderUser {
+			role = "user"
+		}
+		turns = append(turns, GuiderChatTurn{Role: role, Content: c.Message})
+	}
+	return turns
+}
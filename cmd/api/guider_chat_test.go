@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+)
+
+// memoryChatlogs is an in-memory guiderChatlogStore. Inserts fail once failAfter
+// messages are stored, when it is set.
+type memoryChatlogs struct {
+	stored    []*data.GuiderChatlog
+	failAfter *int
+}
+
+func (m *memoryChatlogs) Insert(chatLog *data.GuiderChatlog) (*data.GuiderChatlog, error) {
+	if m.failAfter != nil && len(m.stored) >= *m.failAfter {
+		return nil, errors.New("insert failed")
+	}
+	stored := *chatLog
+	stored.Id = uuid.New()
+	m.stored = append(m.stored, &stored)
+	return &stored, nil
+}
+
+func (m *memoryChatlogs) RecentThreadMessages(threadID uuid.UUID, limit int) ([]*data.GuiderChatlog, error) {
+	var messages []*data.GuiderChatlog
+	for _, c := range m.stored {
+		if c.ThreadId != nil && *c.ThreadId == threadID {
+			messages = append(messages, c)
+		}
+	}
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
+}
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+func parseSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		if block == "" {
+			continue
+		}
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			default:
+				t.Fatalf("unexpected line %q", line)
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRelayGuiderChat(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		upstream   string
+		failAfter  *int
+		wantEvents []string
+		wantDeltas string
+		wantStored []string
+	}{
+		{
+			name:       "relays deltas until done",
+			status:     http.StatusOK,
+			upstream:   "data: {\"delta\": \"Hel\"}\n\n: keep-alive\n\ndata: {\"delta\": \"lo\"}\n\ndata: {\"delta\": \"\"}\n\ndata: [DONE]\n\n",
+			wantEvents: []string{"thread", "user_message", "delta", "delta", "done"},
+			wantDeltas: "Hello",
+			wantStored: []string{"user: How do I calm down?", "ai: Hello"},
+		},
+		{
+			name:       "multi-line data",
+			status:     http.StatusOK,
+			upstream:   "event: message\ndata: {\"delta\":\ndata: \"Hi\"}\n\ndata: [DONE]\n\n",
+			wantEvents: []string{"thread", "user_message", "delta", "done"},
+			wantDeltas: "Hi",
+			wantStored: []string{"user: How do I calm down?", "ai: Hi"},
+		},
+		{
+			name:       "error event",
+			status:     http.StatusOK,
+			upstream:   "data: {\"delta\": \"Hel\"}\n\nevent: error\ndata: {\"error\": \"model overloaded\"}\n\n",
+			wantEvents: []string{"thread", "user_message", "delta", "error"},
+			wantDeltas: "Hel",
+			wantStored: []string{"user: How do I calm down?"},
+		},
+		{
+			name:       "error payload without event name",
+			status:     http.StatusOK,
+			upstream:   "data: {\"error\": \"model overloaded\"}\n\n",
+			wantEvents: []string{"thread", "user_message", "error"},
+			wantStored: []string{"user: How do I calm down?"},
+		},
+		{
+			name:       "upstream status",
+			status:     http.StatusServiceUnavailable,
+			upstream:   "unavailable",
+			wantEvents: []string{"thread", "user_message", "error"},
+			wantStored: []string{"user: How do I calm down?"},
+		},
+		{
+			name:       "stream closed early",
+			status:     http.StatusOK,
+			upstream:   "data: {\"delta\": \"Hel\"}\n\n",
+			wantEvents: []string{"thread", "user_message", "delta", "error"},
+			wantDeltas: "Hel",
+			wantStored: []string{"user: How do I calm down?"},
+		},
+		{
+			name:       "empty reply",
+			status:     http.StatusOK,
+			upstream:   "data: [DONE]\n\n",
+			wantEvents: []string{"thread", "user_message", "error"},
+			wantStored: []string{"user: How do I calm down?"},
+		},
+		{
+			name:       "invalid event",
+			status:     http.StatusOK,
+			upstream:   "data: not json\n\n",
+			wantEvents: []string{"thread", "user_message", "error"},
+			wantStored: []string{"user: How do I calm down?"},
+		},
+		{
+			name:       "reply cannot be stored",
+			status:     http.StatusOK,
+			upstream:   "data: {\"delta\": \"Hello\"}\n\ndata: [DONE]\n\n",
+			failAfter:  intPtr(2), // the earlier message and the user's
+			wantEvents: []string{"thread", "user_message", "delta", "error"},
+			wantDeltas: "Hello",
+			wantStored: []string{"user: How do I calm down?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent GuiderChatRequest
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/guider/chat/stream" {
+					t.Errorf("got path %q", r.URL.Path)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+					t.Errorf("decode request: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.upstream)
+			}))
+			defer upstream.Close()
+
+			thread := &data.ChatThread{ID: uuid.New(), UserID: uuid.New()}
+			previous := &data.GuiderChatlog{UserId: thread.UserID, ThreadId: &thread.ID, SenderType: data.ChatSenderAI, Message: "How are you?"}
+			chatlogs := &memoryChatlogs{stored: []*data.GuiderChatlog{previous}, failAfter: tt.failAfter}
+
+			app := newTestApplication()
+			app.config.ai.url = upstream.URL
+			app.guiderChatlogs = chatlogs
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/guider/chat", nil)
+			app.relayGuiderChat(w, r, thread, GuiderChatRequest{UserID: thread.UserID, Message: "How do I calm down?"})
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+				t.Errorf("got Content-Type %q", ct)
+			}
+
+			events := parseSSE(t, w.Body.String())
+			var names []string
+			var deltas strings.Builder
+			for _, e := range events {
+				names = append(names, e.name)
+				if e.name == "delta" {
+					var delta struct{ Content string }
+					if err := json.Unmarshal([]byte(e.data), &delta); err != nil {
+						t.Fatal(err)
+					}
+					deltas.WriteString(delta.Content)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Errorf("got events %v, want %v", names, tt.wantEvents)
+			}
+			if deltas.String() != tt.wantDeltas {
+				t.Errorf("got deltas %q, want %q", deltas.String(), tt.wantDeltas)
+			}
+
+			if len(sent.History) != 1 || sent.History[0] != (GuiderChatTurn{Role: "assistant", Content: "How are you?"}) {
+				t.Errorf("got history %v", sent.History)
+			}
+			if sent.Message != "How do I calm down?" {
+				t.Errorf("got message %q", sent.Message)
+			}
+
+			var stored []string
+			for _, c := range chatlogs.stored[1:] {
+				if c.ThreadId == nil || *c.ThreadId != thread.ID || c.UserId != thread.UserID {
+					t.Errorf("message %q stored outside the thread", c.Message)
+				}
+				stored = append(stored, c.SenderType+": "+c.Message)
+			}
+			if strings.Join(stored, "|") != strings.Join(tt.wantStored, "|") {
+				t.Errorf("got stored %v, want %v", stored, tt.wantStored)
+			}
+
+			last := events[len(events)-1]
+			if last.name == "done" {
+				var done data.GuiderChatlog
+				if err := json.Unmarshal([]byte(last.data), &done); err != nil {
+					t.Fatal(err)
+				}
+				if done.Id != chatlogs.stored[len(chatlogs.stored)-1].Id {
+					t.Errorf("done carries %v, not the stored reply", done.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestRelayGuiderChatUserMessageNotStored(t *testing.T) {
+	called := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer upstream.Close()
+
+	app := newTestApplication()
+	app.config.ai.url = upstream.URL
+	app.guiderChatlogs = &memoryChatlogs{failAfter: intPtr(0)}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/guider/chat", nil)
+	app.relayGuiderChat(w, r, &data.ChatThread{ID: uuid.New(), UserID: uuid.New()}, GuiderChatRequest{Message: "Hi"})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", w.Code)
+	}
+	if called {
+		t.Error("the AI service was called although the message was not stored")
+	}
+}
+
+func intPtr(n int) *int { return &n }
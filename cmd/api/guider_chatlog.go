@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -18,14 +19,21 @@ func (app *application) getChatLogHandler(w http.ResponseWriter, r *http.Request
 	id, err := app.GetUserUUIDFromContext(r.Context())
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+		return
 	}
 	var input struct {
 		journalId uuid.UUID
 	}
 
-	input.journalId, err = uuid.Parse(app.readString(qs, "journal_id", ""))
+	journalParam := app.readString(qs, "journal_id", "")
+	if journalParam == "" {
+		app.badRequestResponse(w, r, errors.New("journal_id must be provided"))
+		return
+	}
+
+	input.journalId, err = uuid.Parse(journalParam)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.badRequestResponse(w, r, errors.New("journal_id must be a valid UUID"))
 		return
 	}
 
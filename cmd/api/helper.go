@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 
 	"github.com/julienschmidt/httprouter"
 	"tranquara.net/internal/validator"
@@ -85,12 +86,12 @@ func (app *application) readJson(w http.ResponseWriter, r *http.Request, data an
 	return nil
 }
 
-func (app *application) readIDParam(r *http.Request) (int64, error) {
+func (app *application) readIDParam(r *http.Request) (uuid.UUID, error) {
 	params := httprouter.ParamsFromContext(r.Context())
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	id, err := uuid.Parse(params.ByName("id"))
 
-	if err != nil || id < 1 {
-		return 0, errors.New("invalid Id parameter")
+	if err != nil {
+		return uuid.Nil, errors.New("invalid Id parameter")
 	}
 
 	return id, nil
@@ -180,3 +181,18 @@ func (app *application) getLocale(r *http.Request) string {
 	}
 	return "en"
 }
+
+// matchParam routes to matched when the named route parameter equals value and to
+// fallback otherwise. httprouter v1 cannot register a static segment (e.g.
+// "/v1/exercise/recommended") next to a wildcard at the same position, so such
+// routes share the wildcard and are told apart here.
+func (app *application) matchParam(param, value string, matched, fallback http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := httprouter.ParamsFromContext(r.Context())
+		if params.ByName(param) == value {
+			matched(w, r)
+			return
+		}
+		fallback(w, r)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// userLocation resolves the timezone insights are bucketed in: the "tz" query
+// parameter when given, otherwise the user's timezone setting, otherwise UTC.
+func (app *application) userLocation(r *http.Request, userID uuid.UUID, v *validator.Validator) (*time.Location, error) {
+	if name := app.readString(r.URL.Query(), "tz", ""); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			v.AddError("tz", "must be a valid IANA timezone")
+			return time.UTC, nil
+		}
+		return loc, nil
+	}
+
+	return app.userTimezone(userID)
+}
+
+// userTimezone returns the user's timezone setting, or UTC when none is set.
+func (app *application) userTimezone(userID uuid.UUID) (*time.Location, error) {
+	info, err := app.models.UserInformation.Get(userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return time.UTC, nil
+		}
+		return nil, err
+	}
+
+	return info.Location(), nil
+}
+
+// getMoodInsightsHandler returns mood and emotion analytics for charts
+// GET /v1/insights/mood?range=30d&bucket=day&tz=Asia/Ho_Chi_Minh
+func (app *application) getMoodInsightsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	qs := r.URL.Query()
+	v := validator.New()
+
+	opts := data.MoodInsightsOptions{
+		Range:  app.readString(qs, "range", "30d"),
+		Bucket: app.readString(qs, "bucket", data.BucketDay),
+		Locale: app.getLocale(r),
+		Now:    time.Now(),
+	}
+
+	_, validRange := data.InsightRanges[opts.Range]
+	v.Check(validRange, "range", "must be one of 7d, 30d, 90d, 180d, 365d")
+	v.Check(validator.In(opts.Bucket, data.BucketDay, data.BucketWeek, data.BucketMonth), "bucket", "must be one of day, week, month")
+
+	opts.Location, err = app.userLocation(r, userID, v)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	since := data.InsightsStart(opts)
+
+	points, err := app.models.UserJournal.GetMoodPoints(userID, since)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	logs, err := app.models.EmotionLog.GetSince(userID, since)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"insights": data.BuildMoodInsights(points, logs, opts),
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getSleepInsightsHandler returns sleep diary averages and their relation to next-day mood
+// GET /v1/insights/sleep?range=30d&tz=Asia/Ho_Chi_Minh
+func (app *application) getSleepInsightsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	opts := data.MoodInsightsOptions{
+		Range: app.readString(r.URL.Query(), "range", "30d"),
+		Now:   time.Now(),
+	}
+
+	_, validRange := data.InsightRanges[opts.Range]
+	v.Check(validRange, "range", "must be one of 7d, 30d, 90d, 180d, 365d")
+
+	opts.Location, err = app.userLocation(r, userID, v)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	since := data.RangeStart(opts)
+
+	entries, err := app.models.SleepEntry.GetSince(userID, since)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	points, err := app.models.UserJournal.GetMoodPoints(userID, since)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"insights": data.BuildSleepInsights(entries, points, opts),
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getCorrelationInsightsHandler relates completed activities to same-day and next-day mood.
+// Only differences that pass the minimum-sample and effect-size guards are returned.
+// GET /v1/insights/correlations?range=90d&tz=Asia/Ho_Chi_Minh
+func (app *application) getCorrelationInsightsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	opts := data.MoodInsightsOptions{
+		Range:  app.readString(r.URL.Query(), "range", "90d"),
+		Locale: app.getLocale(r),
+		Now:    time.Now(),
+	}
+
+	_, validRange := data.InsightRanges[opts.Range]
+	v.Check(validRange, "range", "must be one of 7d, 30d, 90d, 180d, 365d")
+
+	opts.Location, err = app.userLocation(r, userID, v)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Activities from the day before the range still affect its first day's mood
+	since := data.RangeStart(opts)
+	activitiesSince := since.AddDate(0, 0, -1)
+
+	points, err := app.models.UserJournal.GetMoodPoints(userID, since)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	events, err := app.models.ActivityEvent.GetSince(userID, activitiesSince)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	entries, err := app.models.SleepEntry.GetSince(userID, activitiesSince)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	events = append(events, data.SleepActivityEvents(entries, opts.Location)...)
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"insights": data.ComputeCorrelations(points, events, opts),
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// GetDueReviews lists learn slide groups whose spaced-repetition review is due
+// GET /v1/learned/reviews/due?limit=20
+func (app *application) GetDueReviews(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	limit := app.readInt(r.URL.Query(), "limit", 20, v)
+	v.Check(limit > 0 && limit <= data.MaxPageSize, "limit", "must be between 1 and 100")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	reviews, err := app.models.LearnReview.GetDue(userID, time.Now(), app.getLocale(r), limit)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"reviews": reviews,
+		"total":   len(reviews),
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// RecordReview records how well the user recalled a slide group and schedules the next review
+// POST /v1/learned/reviews/:id
+// Body: { "quality": 0-5 }
+func (app *application) RecordReview(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	reviewID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Quality *int `json:"quality"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.Quality != nil, "quality", "must be provided")
+	if input.Quality != nil {
+		v.Check(*input.Quality >= data.MinRecallQuality && *input.Quality <= data.MaxRecallQuality,
+			"quality", "must be between 0 and 5")
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	review, err := app.models.LearnReview.Get(reviewID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	review.ApplyRecall(*input.Quality, time.Now())
+
+	err = app.models.LearnReview.Update(review)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"review": review}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
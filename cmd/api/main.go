@@ -15,6 +15,7 @@ import (
 	"tranquara.net/internal/jsonlog"
 	"tranquara.net/internal/mailer"
 	"tranquara.net/internal/pubsub"
+	"tranquara.net/internal/storage"
 )
 
 type envolope map[string]any
@@ -42,15 +43,31 @@ type config struct {
 		password string
 		sender   string
 	}
+	streak struct {
+		policy data.StreakPolicy
+	}
+	auth struct {
+		adminRole  string
+		adminScope string
+	}
+	media struct {
+		dir            string
+		maxUploadBytes int64
+	}
+	ai struct {
+		url string
+	}
 }
 
 type application struct {
-	config        config
-	logger        *jsonlog.Logger
-	rabbitchannel *amqp.Channel
-	models        data.Models
-	mailer        mailer.Mailer
-	wg            sync.WaitGroup
+	config         config
+	logger         *jsonlog.Logger
+	rabbitchannel  *amqp.Channel
+	models         data.Models
+	guiderChatlogs guiderChatlogStore
+	mailer         mailer.Mailer
+	storage        storage.Storage
+	wg             sync.WaitGroup
 }
 
 func main() {
@@ -76,10 +93,32 @@ func main() {
 	flag.StringVar(&cfg.smtp.password, "smtp-password", "1cbbb17d7da071", "SMTP password")
 	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "Tranquara <no-reply@tranquara.nhattran.net>", "SMTP sender")
 
+	flag.StringVar(&cfg.auth.adminRole, "auth-admin-role", "admin", "Keycloak realm role required for content administration")
+	flag.StringVar(&cfg.auth.adminScope, "auth-admin-scope", "", "Token scope additionally required for content administration (empty = none)")
+
+	flag.StringVar(&cfg.media.dir, "media-dir", "./media", "Directory media asset files are stored in")
+	mediaMaxUploadMB := flag.Int64("media-max-upload-mb", 200, "Maximum size of a media upload in megabytes")
+
+	flag.StringVar(&cfg.ai.url, "ai-service-url", envOr("AI_SERVICE_URL", "http://ai-service:8000"), "AI service base URL")
+
+	streakActivities := flag.String("streak-activities", data.DefaultStreakActivities, "Comma-separated activities that advance streaks (journal,emotion_log,exercise,learn)")
+
 	flag.Parse()
 
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
 
+	streakPolicy, err := data.ParseStreakPolicy(*streakActivities)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+	cfg.streak.policy = streakPolicy
+	cfg.media.maxUploadBytes = *mediaMaxUploadMB << 20
+
+	mediaStorage, err := storage.NewLocal(cfg.media.dir)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
 	db, err := openDB(cfg)
 	if err != nil {
 		logger.PrintFatal(err, nil)
@@ -98,7 +137,7 @@ func main() {
 	retryDelay := 5 * time.Second
 
 	for i := 0; i < maxRetries; i++ {
-		channel, conn, err = pubsub.Serve(&models)
+		channel, conn, err = pubsub.Serve(&models, cfg.streak.policy)
 		if err == nil {
 			logger.PrintInfo("Successfully connected to RabbitMQ", nil)
 			break
@@ -129,17 +168,27 @@ func main() {
 	}
 
 	app := &application{
-		config:        cfg,
-		logger:        logger,
-		rabbitchannel: channel,
-		models:        models,
-		mailer:        mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		config:         cfg,
+		logger:         logger,
+		rabbitchannel:  channel,
+		models:         models,
+		guiderChatlogs: models.GuiderChatlog,
+		mailer:         mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		storage:        mediaStorage,
 	}
 
 	err = app.serve()
 	logger.PrintFatal(err, nil)
 }
 
+// envOr returns the environment variable, or fallback when it is unset or empty.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
 func openDB(cfg config) (*sql.DB, error) {
 	db, err := sql.Open("postgres", "host=db port=5432 user=postgres password=Nhatdien123 dbname=tranquara_core sslmode=disable")
 	if err != nil {
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/storage"
+	"tranquara.net/internal/validator"
+)
+
+// mediaTransferTimeout replaces the server's read and write timeouts for uploads and
+// streams, which are sized for JSON requests rather than audio and video files.
+const mediaTransferTimeout = 10 * time.Minute
+
+// createMediaAssetHandler registers an asset; its files are uploaded per locale afterwards
+// POST /v1/media_assets
+func (app *application) createMediaAssetHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Title string `json:"title"`
+		Kind  string `json:"kind"`
+	}
+
+	err := app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	asset := &data.MediaAsset{
+		Title: strings.TrimSpace(input.Title),
+		Kind:  input.Kind,
+	}
+
+	v := validator.New()
+	data.ValidateMediaAsset(v, asset)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	asset, err = app.models.MediaAsset.Insert(asset)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Location", fmt.Sprintf("v1/media_assets/%s", asset.ID))
+
+	err = app.writeJson(w, http.StatusCreated, envolope{"media_asset": asset}, header)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listMediaAssetsHandler lists assets with their variants
+// GET /v1/media_assets?kind=audio
+func (app *application) listMediaAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	kind := app.readString(qs, "kind", "")
+	if kind != "" {
+		v.Check(validator.In(kind, data.MediaKindAudio, data.MediaKindVideo), "kind", "must be one of audio, video")
+	}
+
+	filter := app.readQueryFilter(qs, v, DefaultFilterOptions(
+		"-created_at",
+		[]string{"created_at", "-created_at", "title", "-title"},
+	))
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	assets, metadata, err := app.models.MediaAsset.GetList(kind, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"metadata": metadata, "media_assets": assets}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showMediaAssetHandler returns an asset with its variants
+// GET /v1/media_assets/:id
+func (app *application) showMediaAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetID, ok := app.readMediaAssetID(w, r)
+	if !ok {
+		return
+	}
+
+	asset, err := app.models.MediaAsset.Get(assetID)
+	if err != nil {
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"media_asset": asset}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateMediaAssetHandler renames an asset
+// PATCH /v1/media_assets/:id
+func (app *application) updateMediaAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetID, ok := app.readMediaAssetID(w, r)
+	if !ok {
+		return
+	}
+
+	asset, err := app.models.MediaAsset.Get(assetID)
+	if err != nil {
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Title *string `json:"title"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Title != nil {
+		asset.Title = strings.TrimSpace(*input.Title)
+	}
+
+	v := validator.New()
+	data.ValidateMediaAsset(v, asset)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	asset, err = app.models.MediaAsset.Update(asset)
+	if err != nil {
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"media_asset": asset}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteMediaAssetHandler deletes an asset and its files; linked exercises are unlinked
+// DELETE /v1/media_assets/:id
+func (app *application) deleteMediaAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetID, ok := app.readMediaAssetID(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := app.models.MediaAsset.Delete(assetID)
+	if err != nil {
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+
+	app.deleteMediaFiles(keys...)
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "media asset deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// uploadMediaVariantHandler stores the request body as the asset's file for a locale,
+// replacing any previous upload. The body is the raw file with its MIME type in
+// Content-Type. An optional X-Checksum-Sha256 header is verified against the upload.
+// PUT /v1/media_assets/:id/variants/:locale?duration_seconds=300
+func (app *application) uploadMediaVariantHandler(w http.ResponseWriter, r *http.Request) {
+	assetID, ok := app.readMediaAssetID(w, r)
+	if !ok {
+		return
+	}
+
+	asset, err := app.models.MediaAsset.Get(assetID)
+	if err != nil {
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	variant := &data.MediaVariant{
+		AssetID: asset.ID,
+		Locale:  httprouter.ParamsFromContext(r.Context()).ByName("locale"),
+	}
+
+	variant.MimeType, _, err = mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		v.AddError("content_type", "must be a valid MIME type")
+	}
+
+	if r.URL.Query().Has("duration_seconds") {
+		duration := app.readInt(r.URL.Query(), "duration_seconds", 0, v)
+		variant.DurationSeconds = &duration
+	}
+
+	expectedChecksum := strings.ToLower(r.Header.Get("X-Checksum-Sha256"))
+
+	data.ValidateMediaVariant(v, asset.Kind, variant)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Now().Add(mediaTransferTimeout))
+	_ = rc.SetWriteDeadline(time.Now().Add(mediaTransferTimeout))
+
+	r.Body = http.MaxBytesReader(w, r.Body, app.config.media.maxUploadBytes)
+
+	variant.StorageKey = fmt.Sprintf("%s/%s", asset.ID, uuid.New())
+	hash := sha256.New()
+
+	variant.SizeBytes, err = app.storage.Put(r.Context(), variant.StorageKey, io.TeeReader(r.Body, hash))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			app.errorResponse(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("body must not be larger than %d bytes", maxBytesError.Limit))
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	variant.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	v.Check(variant.SizeBytes > 0, "body", "must not be empty")
+	if expectedChecksum != "" {
+		v.Check(expectedChecksum == variant.Checksum, "checksum", "does not match the uploaded file")
+	}
+	if !v.Valid() {
+		app.deleteMediaFiles(variant.StorageKey)
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	previousKey, err := app.models.MediaAsset.UpsertVariant(variant)
+	if err != nil {
+		app.deleteMediaFiles(variant.StorageKey)
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+	if previousKey != "" {
+		app.deleteMediaFiles(previousKey)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"variant": variant}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteMediaVariantHandler deletes the asset's file for a locale
+// DELETE /v1/media_assets/:id/variants/:locale
+func (app *application) deleteMediaVariantHandler(w http.ResponseWriter, r *http.Request) {
+	assetID, ok := app.readMediaAssetID(w, r)
+	if !ok {
+		return
+	}
+
+	locale := httprouter.ParamsFromContext(r.Context()).ByName("locale")
+
+	key, err := app.models.MediaAsset.DeleteVariant(assetID, locale)
+	if err != nil {
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+
+	app.deleteMediaFiles(key)
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "media variant deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// streamMediaAssetHandler serves the asset's file for the requested locale, falling
+// back to another variant when there is none. Range and conditional requests are
+// supported so clients can seek and resume.
+// GET /v1/media_assets/:id/stream?locale=vi
+func (app *application) streamMediaAssetHandler(w http.ResponseWriter, r *http.Request) {
+	assetID, ok := app.readMediaAssetID(w, r)
+	if !ok {
+		return
+	}
+
+	asset, err := app.models.MediaAsset.Get(assetID)
+	if err != nil {
+		app.mediaAssetErrorResponse(w, r, err)
+		return
+	}
+
+	variant := asset.Variant(app.readString(r.URL.Query(), "locale", app.getLocale(r)))
+	if variant == nil {
+		http.Error(w, "Media asset has no uploaded files", http.StatusNotFound)
+		return
+	}
+
+	file, err := app.storage.Open(r.Context(), variant.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Media file not found", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(mediaTransferTimeout))
+
+	w.Header().Set("Content-Type", variant.MimeType)
+	w.Header().Set("Content-Language", variant.Locale)
+	w.Header().Set("ETag", `"`+variant.Checksum+`"`)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+
+	http.ServeContent(w, r, "", variant.CreatedAt, file)
+}
+
+// deleteMediaFiles removes files from storage in the background; a file left behind
+// by a failure is only wasted space, so errors are logged rather than returned.
+func (app *application) deleteMediaFiles(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, key := range keys {
+			err := app.storage.Delete(ctx, key)
+			if err != nil {
+				app.logger.PrintError(err, map[string]string{"storage_key": key})
+			}
+		}
+	})
+}
+
+func (app *application) mediaAssetErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		http.Error(w, "Media asset not found", http.StatusNotFound)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readMediaAssetID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	params := httprouter.ParamsFromContext(r.Context())
+	assetID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return assetID, true
+}
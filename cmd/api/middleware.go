@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -110,7 +111,7 @@ func (app *application) authMiddleWare(next http.HandlerFunc) http.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			app.errorResponse(w, r, http.StatusUnauthorized, "Invalid token")
+			app.invalidAuthenticationTokenResponse(w, r)
 			return
 		}
 
@@ -124,6 +125,77 @@ func (app *application) authMiddleWare(next http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
+// requireRole lets the request through only when the token carries role in its
+// Keycloak realm roles (realm_access.roles). It must run after authMiddleWare.
+func (app *application) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := app.GetUserFromContext(r.Context())
+		if claims == nil {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		if !slices.Contains(realmRoles(claims), role) {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireScope lets the request through only when the token's space-separated
+// scope claim contains scope. An empty scope requires nothing. It must run after authMiddleWare.
+func (app *application) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scope == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims := app.GetUserFromContext(r.Context())
+		if claims == nil {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		granted, _ := claims["scope"].(string)
+		if !slices.Contains(strings.Fields(granted), scope) {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin authenticates the request and requires the configured admin role
+// and scope. Used for exercise and template administration.
+func (app *application) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return app.authMiddleWare(app.requireRole(app.config.auth.adminRole, app.requireScope(app.config.auth.adminScope, next)))
+}
+
+// realmRoles returns the Keycloak realm roles of the token ({"realm_access": {"roles": [...]}}).
+func realmRoles(claims jwt.MapClaims) []string {
+	realmAccess, ok := claims["realm_access"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	rawRoles, ok := realmAccess["roles"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	roles := make([]string, 0, len(rawRoles))
+	for _, raw := range rawRoles {
+		if role, ok := raw.(string); ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
 func (app *application) GetUserFromContext(ctx context.Context) jwt.MapClaims {
 	claims, ok := ctx.Value(userCtxKey).(jwt.MapClaims)
 	if !ok {
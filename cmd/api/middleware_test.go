@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"tranquara.net/internal/jsonlog"
+)
+
+var hmacTestKey = []byte("middleware-test-secret")
+
+// signedClaims signs claims with method and parses the token back, so the claims
+// have the shapes produced by decoding a real token.
+func signedClaims(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) jwt.MapClaims {
+	t.Helper()
+
+	var signKey, verifyKey interface{}
+	switch method.(type) {
+	case *jwt.SigningMethodRSA:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signKey, verifyKey = key, &key.PublicKey
+	default:
+		signKey, verifyKey = hmacTestKey, hmacTestKey
+	}
+
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(signKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return verifyKey, nil })
+	if err != nil || !token.Valid {
+		t.Fatalf("parse token: %v", err)
+	}
+
+	return token.Claims.(jwt.MapClaims)
+}
+
+func newTestApplication() *application {
+	return &application{logger: jsonlog.New(io.Discard, jsonlog.LevelInfo)}
+}
+
+// serve runs handler with claims in the request context (none when nil) and returns
+// the status code and whether the wrapped handler was reached.
+func serve(t *testing.T, wrap func(http.HandlerFunc) http.HandlerFunc, claims jwt.MapClaims) (int, bool) {
+	t.Helper()
+
+	reached := false
+	handler := wrap(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/admin/exercises", nil)
+	if claims != nil {
+		r = r.WithContext(context.WithValue(r.Context(), userCtxKey, claims))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w.Code, reached
+}
+
+func TestRealmRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   []string
+	}{
+		{"missing realm_access", jwt.MapClaims{"sub": "user"}, nil},
+		{"realm_access without roles", jwt.MapClaims{"realm_access": map[string]interface{}{}}, nil},
+		{"roles of the wrong type", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": "admin"}}, nil},
+		{"roles", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"user", "admin"}}}, []string{"user", "admin"}},
+		{"non-string roles skipped", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"admin", 7}}}, []string{"admin"}},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []jwt.SigningMethod{jwt.SigningMethodHS256, jwt.SigningMethodRS256} {
+			t.Run(tt.name+"/"+method.Alg(), func(t *testing.T) {
+				got := realmRoles(signedClaims(t, method, tt.claims))
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("realmRoles() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	app := newTestApplication()
+	wrap := func(next http.HandlerFunc) http.HandlerFunc { return app.requireRole("admin", next) }
+
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantStatus int
+	}{
+		{"role present", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"user", "admin"}}}, http.StatusOK},
+		{"role absent", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"user"}}}, http.StatusForbidden},
+		{"missing realm_access", jwt.MapClaims{"sub": "user"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []jwt.SigningMethod{jwt.SigningMethodHS256, jwt.SigningMethodRS256} {
+			t.Run(tt.name+"/"+method.Alg(), func(t *testing.T) {
+				status, reached := serve(t, wrap, signedClaims(t, method, tt.claims))
+				if status != tt.wantStatus {
+					t.Errorf("status = %d, want %d", status, tt.wantStatus)
+				}
+				if reached != (tt.wantStatus == http.StatusOK) {
+					t.Errorf("handler reached = %v", reached)
+				}
+			})
+		}
+	}
+
+	t.Run("no claims", func(t *testing.T) {
+		status, reached := serve(t, wrap, nil)
+		if status != http.StatusUnauthorized || reached {
+			t.Errorf("status = %d, reached = %v, want 401 and not reached", status, reached)
+		}
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	app := newTestApplication()
+
+	tests := []struct {
+		name       string
+		scope      string
+		claims     jwt.MapClaims
+		wantStatus int
+	}{
+		{"scope present", "admin:content", jwt.MapClaims{"scope": "openid profile admin:content"}, http.StatusOK},
+		{"scope absent", "admin:content", jwt.MapClaims{"scope": "openid profile"}, http.StatusForbidden},
+		{"scope is only a prefix", "admin", jwt.MapClaims{"scope": "openid admin:content"}, http.StatusForbidden},
+		{"no scope claim", "admin:content", jwt.MapClaims{"sub": "user"}, http.StatusForbidden},
+		{"empty configured scope", "", jwt.MapClaims{"sub": "user"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []jwt.SigningMethod{jwt.SigningMethodHS256, jwt.SigningMethodRS256} {
+			t.Run(tt.name+"/"+method.Alg(), func(t *testing.T) {
+				wrap := func(next http.HandlerFunc) http.HandlerFunc { return app.requireScope(tt.scope, next) }
+				status, reached := serve(t, wrap, signedClaims(t, method, tt.claims))
+				if status != tt.wantStatus {
+					t.Errorf("status = %d, want %d", status, tt.wantStatus)
+				}
+				if reached != (tt.wantStatus == http.StatusOK) {
+					t.Errorf("handler reached = %v", reached)
+				}
+			})
+		}
+	}
+
+	t.Run("no claims", func(t *testing.T) {
+		wrap := func(next http.HandlerFunc) http.HandlerFunc { return app.requireScope("admin:content", next) }
+		status, reached := serve(t, wrap, nil)
+		if status != http.StatusUnauthorized || reached {
+			t.Errorf("status = %d, reached = %v, want 401 and not reached", status, reached)
+		}
+	})
+
+	t.Run("empty configured scope without claims", func(t *testing.T) {
+		wrap := func(next http.HandlerFunc) http.HandlerFunc { return app.requireScope("", next) }
+		status, reached := serve(t, wrap, nil)
+		if status != http.StatusOK || !reached {
+			t.Errorf("status = %d, reached = %v, want 200 and reached", status, reached)
+		}
+	})
+}
@@ -72,6 +72,8 @@ func (app *application) createPrepPackHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	app.evaluateAchievementsInBackground(userUUID)
+
 	err = app.writeJson(w, http.StatusCreated, envolope{"prep_pack": result}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
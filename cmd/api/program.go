@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// ProgramCompletedPayload is the payload of the "program.completed" event.
+type ProgramCompletedPayload struct {
+	UserID       uuid.UUID `json:"user_id"`
+	ProgramID    uuid.UUID `json:"program_id"`
+	EnrollmentID uuid.UUID `json:"enrollment_id"`
+	Title        string    `json:"title"`
+}
+
+// listProgramsHandler lists the active programs with the user's enrollment in each
+// GET /v1/programs                → all active programs
+// GET /v1/programs?enrolled=true  → programs the user is enrolled in
+func (app *application) listProgramsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	enrolledOnly := r.URL.Query().Get("enrolled") == "true"
+
+	programs, err := app.models.Program.GetAll(userID, enrolledOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	locale := app.getLocale(r)
+	for _, p := range programs {
+		p.ApplyLocale(locale)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"programs": programs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showProgramHandler returns a program with its days and items
+// GET /v1/programs/:id
+func (app *application) showProgramHandler(w http.ResponseWriter, r *http.Request) {
+	userID, program, ok := app.readProgram(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := app.models.Program.GetEnrollment(userID, program.ID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	program.Enrollment = enrollment
+	program.ApplyLocale(app.getLocale(r))
+
+	err = app.writeJson(w, http.StatusOK, envolope{"program": program}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// enrollProgramHandler enrolls the user in a program starting today in their timezone.
+// Enrolling again returns the existing enrollment.
+// POST /v1/programs/:id/enroll
+func (app *application) enrollProgramHandler(w http.ResponseWriter, r *http.Request) {
+	userID, program, ok := app.readProgram(w, r)
+	if !ok {
+		return
+	}
+
+	if !program.IsActive || program.DurationDays == 0 {
+		http.Error(w, "Program is not open for enrollment", http.StatusConflict)
+		return
+	}
+
+	loc, err := app.userTimezone(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	startDate := time.Now().In(loc).Format(data.SleepDateLayout)
+
+	enrollment, created, err := app.models.Program.Enroll(userID, program.ID, startDate)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	err = app.writeJson(w, status, envolope{"enrollment": enrollment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// unenrollProgramHandler leaves a program, discarding its progress
+// DELETE /v1/programs/:id/enroll
+func (app *application) unenrollProgramHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	programID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return
+	}
+
+	err = app.models.Program.Unenroll(userID, programID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Not enrolled in this program", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "Left program successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getProgramTodayHandler returns the user's current step in a program: the first
+// unlocked day not yet completed, with days unlocking daily in the user's timezone
+// GET /v1/programs/:id/today
+func (app *application) getProgramTodayHandler(w http.ResponseWriter, r *http.Request) {
+	userID, program, ok := app.readProgram(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, ok := app.readProgramEnrollment(w, r, userID, program.ID)
+	if !ok {
+		return
+	}
+
+	loc, err := app.userTimezone(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	program.ApplyLocale(app.getLocale(r))
+	today := program.Today(enrollment, time.Now(), loc)
+
+	err = app.writeJson(w, http.StatusOK, envolope{"today": today}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// completeProgramDayHandler marks an unlocked program day as completed
+// POST /v1/programs/:id/days/:day/complete
+func (app *application) completeProgramDayHandler(w http.ResponseWriter, r *http.Request) {
+	userID, program, ok := app.readProgram(w, r)
+	if !ok {
+		return
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	dayNumber, err := strconv.Atoi(params.ByName("day"))
+	if err != nil || program.Day(dayNumber) == nil {
+		http.Error(w, "Program day not found", http.StatusNotFound)
+		return
+	}
+
+	enrollment, ok := app.readProgramEnrollment(w, r, userID, program.ID)
+	if !ok {
+		return
+	}
+
+	loc, err := app.userTimezone(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(dayNumber <= enrollment.UnlockedDays(program.DurationDays, time.Now(), loc), "day", "is not unlocked yet")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	finished, err := app.models.Program.CompleteDay(enrollment.ID, dayNumber)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if finished {
+		app.publishEvent("program.completed", ProgramCompletedPayload{
+			UserID:       userID,
+			ProgramID:    program.ID,
+			EnrollmentID: enrollment.ID,
+			Title:        program.Title,
+		})
+	}
+
+	enrollment, err = app.models.Program.GetEnrollment(userID, program.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"enrollment": enrollment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readProgram(w http.ResponseWriter, r *http.Request) (uuid.UUID, *data.Program, bool) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, nil, false
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	programID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, nil, false
+	}
+
+	program, err := app.models.Program.Get(programID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Program not found", http.StatusNotFound)
+			return uuid.Nil, nil, false
+		}
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, nil, false
+	}
+
+	return userID, program, true
+}
+
+func (app *application) readProgramEnrollment(w http.ResponseWriter, r *http.Request, userID, programID uuid.UUID) (*data.ProgramEnrollment, bool) {
+	enrollment, err := app.models.Program.GetEnrollment(userID, programID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Not enrolled in this program", http.StatusNotFound)
+			return nil, false
+		}
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+
+	return enrollment, true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+)
+
+// SubmitQuizAttempt scores the user's answers for the quiz slides of a slide group
+// and records the attempt
+// POST /v1/learned/quiz-attempts
+// Body: { "collection_id": "uuid", "slide_group_id": "...", "answers": { "<slide_id>": ["<option_id>"] } }
+func (app *application) SubmitQuizAttempt(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		CollectionID string              `json:"collection_id"`
+		SlideGroupID string              `json:"slide_group_id"`
+		Answers      map[string][]string `json:"answers"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.CollectionID == "" || input.SlideGroupID == "" {
+		http.Error(w, "collection_id and slide_group_id are required", http.StatusBadRequest)
+		return
+	}
+
+	collectionID, err := uuid.Parse(input.CollectionID)
+	if err != nil {
+		http.Error(w, "Invalid collection_id format", http.StatusBadRequest)
+		return
+	}
+
+	template, err := app.models.UserJournal.GetTemplate(collectionID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	visible, err := app.templateVisible(r, template)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !visible {
+		app.notFoundRespond(w, r)
+		return
+	}
+
+	// Score against the localized slides so explanations come back in the user's language;
+	// option ids are shared between translations.
+	template.ApplyLocale(app.getLocale(r))
+
+	groups, err := template.ParseSlideGroups()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	idx := slices.IndexFunc(groups, func(g data.SlideGroup) bool { return g.ID == input.SlideGroupID })
+	if idx < 0 {
+		http.Error(w, "slide_group_id does not belong to this collection", http.StatusBadRequest)
+		return
+	}
+
+	if !groups[idx].HasQuiz() {
+		http.Error(w, "slide group has no quiz", http.StatusBadRequest)
+		return
+	}
+
+	if input.Answers == nil {
+		input.Answers = map[string][]string{}
+	}
+
+	result := data.ScoreQuiz(groups[idx], input.Answers)
+
+	attempt, err := app.models.QuizAttempt.Insert(&data.QuizAttempt{
+		UserID:       userID,
+		CollectionID: collectionID,
+		SlideGroupID: input.SlideGroupID,
+		Answers:      input.Answers,
+		Score:        result.Score,
+		Total:        result.Total,
+		Percent:      result.Percent,
+		Passed:       result.Passed,
+	})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusCreated, envolope{
+		"attempt": attempt,
+		"result":  result,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
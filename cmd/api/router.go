@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
 )
 
 func (app *application) routes() http.Handler {
@@ -22,20 +23,80 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPost, "/v1/users/sync", app.authMiddleWare(app.syncUserHandler))
 
 	//Exercises handlers
-	router.HandlerFunc(http.MethodGet, "/v1/exercise/:id", app.authMiddleWare(app.showExerciseHanlder))
+	router.HandlerFunc(http.MethodGet, "/v1/exercise/:id", app.authMiddleWare(app.matchParam("id", "recommended", app.getRecommendedExercisesHandler, app.showExerciseHanlder)))
 	router.HandlerFunc(http.MethodGet, "/v1/exercise", app.authMiddleWare(app.listExerciseHandler))
-	router.HandlerFunc(http.MethodPost, "/v1/exercise", app.authMiddleWare(app.createExerciseHandler))
-	router.HandlerFunc(http.MethodPatch, "/v1/exercise/:id", app.authMiddleWare(app.updateExerciseHandler))
-	router.HandlerFunc(http.MethodDelete, "/v1/exercise/:id", app.authMiddleWare(app.deleteExerciseHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/exercise", app.requireAdmin(app.createExerciseHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/exercise/:id", app.requireAdmin(app.updateExerciseHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/exercise/:id", app.requireAdmin(app.deleteExerciseHandler))
+
+	// Media asset routes
+	router.HandlerFunc(http.MethodGet, "/v1/media_assets", app.authMiddleWare(app.listMediaAssetsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/media_assets", app.requireAdmin(app.createMediaAssetHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/media_assets/:id", app.authMiddleWare(app.showMediaAssetHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/media_assets/:id", app.requireAdmin(app.updateMediaAssetHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/media_assets/:id", app.requireAdmin(app.deleteMediaAssetHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/media_assets/:id/stream", app.authMiddleWare(app.streamMediaAssetHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/media_assets/:id/variants/:locale", app.requireAdmin(app.uploadMediaVariantHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/media_assets/:id/variants/:locale", app.requireAdmin(app.deleteMediaVariantHandler))
 
 	//User completed exercise
 	router.HandlerFunc(http.MethodPost, "/v1/user_completed_exercise", app.authMiddleWare(app.createUserCompletedExerciseHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/user_completed_exercise", app.authMiddleWare(app.listCompletedExerciseHandler))
 
-	// router.HandlerFunc(http.MethodPut, "/v1/program_exercises/:id", app.authMiddleWare(app.updateProgramExerciseHandler))
+	// Exercise session routes
+	router.HandlerFunc(http.MethodPost, "/v1/exercise_sessions", app.authMiddleWare(app.startExerciseSessionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/exercise_sessions", app.authMiddleWare(app.listExerciseSessionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/exercise_sessions/:id", app.authMiddleWare(app.showExerciseSessionHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/exercise_sessions/:id/pause", app.authMiddleWare(app.exerciseSessionActionHandler(data.ExerciseSessionPause)))
+	router.HandlerFunc(http.MethodPost, "/v1/exercise_sessions/:id/resume", app.authMiddleWare(app.exerciseSessionActionHandler(data.ExerciseSessionResume)))
+	router.HandlerFunc(http.MethodPost, "/v1/exercise_sessions/:id/complete", app.authMiddleWare(app.exerciseSessionActionHandler(data.ExerciseSessionComplete)))
+	router.HandlerFunc(http.MethodPost, "/v1/exercise_sessions/:id/abandon", app.authMiddleWare(app.exerciseSessionActionHandler(data.ExerciseSessionAbandon)))
+	router.HandlerFunc(http.MethodPost, "/v1/exercise_sessions/:id/steps", app.authMiddleWare(app.completeExerciseSessionStepHandler))
+
+	// Exercise favourite and routine routes
+	router.HandlerFunc(http.MethodGet, "/v1/exercise_favourites", app.authMiddleWare(app.listExerciseFavouritesHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/exercise_favourites/:id", app.authMiddleWare(app.addExerciseFavouriteHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/exercise_favourites/:id", app.authMiddleWare(app.removeExerciseFavouriteHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/routines", app.authMiddleWare(app.createRoutineHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/routines", app.authMiddleWare(app.listRoutinesHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/routines/:id", app.authMiddleWare(app.showRoutineHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/routines/:id", app.authMiddleWare(app.updateRoutineHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/routines/:id", app.authMiddleWare(app.deleteRoutineHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/routines/:id/sessions", app.authMiddleWare(app.startRoutineSessionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/routine_sessions/:id", app.authMiddleWare(app.showRoutineSessionHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/routine_sessions/:id/next", app.authMiddleWare(app.advanceRoutineSessionHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/routine_sessions/:id/abandon", app.authMiddleWare(app.abandonRoutineSessionHandler))
+
+	// Program routes
+	router.HandlerFunc(http.MethodGet, "/v1/programs", app.authMiddleWare(app.listProgramsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/programs/:id", app.authMiddleWare(app.showProgramHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/programs/:id/today", app.authMiddleWare(app.getProgramTodayHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/programs/:id/enroll", app.authMiddleWare(app.enrollProgramHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/programs/:id/enroll", app.authMiddleWare(app.unenrollProgramHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/programs/:id/days/:day/complete", app.authMiddleWare(app.completeProgramDayHandler))
+
 	//emotion logs routes
 	router.HandlerFunc(http.MethodGet, "/v1/emotion_log", app.authMiddleWare(app.GetEmotionLogs))
 	router.HandlerFunc(http.MethodPost, "/v1/emotion_log", app.authMiddleWare(app.CreateEmotionLog))
+	router.HandlerFunc(http.MethodPut, "/v1/emotion_log/:id", app.authMiddleWare(app.UpdateEmotionLog))
+	router.HandlerFunc(http.MethodDelete, "/v1/emotion_log/:id", app.authMiddleWare(app.DeleteEmotionLog))
+	router.HandlerFunc(http.MethodGet, "/v1/emotion_taxonomy", app.authMiddleWare(app.GetEmotionTaxonomy))
+
+	// Insights routes
+	router.HandlerFunc(http.MethodGet, "/v1/insights/mood", app.authMiddleWare(app.getMoodInsightsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/insights/sleep", app.authMiddleWare(app.getSleepInsightsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/insights/correlations", app.authMiddleWare(app.getCorrelationInsightsHandler))
+
+	// Sleep diary routes
+	router.HandlerFunc(http.MethodGet, "/v1/sleep_entries", app.authMiddleWare(app.listSleepEntriesHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/sleep_entries", app.authMiddleWare(app.createSleepEntryHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/sleep_entries/:id", app.authMiddleWare(app.showSleepEntryHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/sleep_entries/:id", app.authMiddleWare(app.updateSleepEntryHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/sleep_entries/:id", app.authMiddleWare(app.deleteSleepEntryHandler))
+
+	// Nudge routes
+	router.HandlerFunc(http.MethodGet, "/v1/nudges", app.authMiddleWare(app.listNudgesHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/nudges", app.authMiddleWare(app.readNudgeHandler))
 
 	//User journal routes
 	router.HandlerFunc(http.MethodGet, "/v1/journal", app.authMiddleWare(app.GetUserJournal))
@@ -46,6 +107,13 @@ func (app *application) routes() http.Handler {
 
 	//chat log routes
 	router.HandlerFunc(http.MethodGet, "/v1/guider_chatlogs", app.authMiddleWare(app.getChatLogHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/guider/chat", app.authMiddleWare(app.guiderChatHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/chat_threads", app.authMiddleWare(app.listChatThreadsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/chat_threads", app.authMiddleWare(app.createChatThreadHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/chat_threads/:id", app.authMiddleWare(app.showChatThreadHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/chat_threads/:id", app.authMiddleWare(app.renameChatThreadHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/chat_threads/:id", app.authMiddleWare(app.deleteChatThreadHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/chat_threads/:id/messages", app.authMiddleWare(app.listChatThreadMessagesHandler))
 
 	//User info routes
 	router.HandlerFunc(http.MethodGet, "/v1/user_information", app.authMiddleWare(app.getUserInformationHandler))
@@ -57,6 +125,8 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPost, "/v1/user-template", app.authMiddleWare(app.CreateUserJournal))
 
 	router.HandlerFunc(http.MethodGet, "/v1/tempalte-gallary", app.authMiddleWare(app.GetAllTemplates))
+	router.HandlerFunc(http.MethodGet, "/v1/templates/recommended", app.authMiddleWare(app.getRecommendedTemplatesHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/templates/:id/publishing", app.requireAdmin(app.updateTemplatePublishingHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/user-template/:id", app.authMiddleWare(app.GetUserJournal))
 	router.HandlerFunc(http.MethodPut, "/v1/user-template/:id", app.authMiddleWare(app.UpdateUserJournal))
 	router.HandlerFunc(http.MethodDelete, "/v1/user-template/:id", app.authMiddleWare(app.DeleteUserJournal))
@@ -64,6 +134,15 @@ func (app *application) routes() http.Handler {
 	// UserStreak routes
 	router.HandlerFunc(http.MethodGet, "/v1/user_streaks", app.authMiddleWare(app.getUserStreakHandler))
 	router.HandlerFunc(http.MethodPut, "/v1/user_streaks", app.authMiddleWare(app.updateUserStreakHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/user_streaks/events", app.authMiddleWare(app.listStreakEventsHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/achievements", app.authMiddleWare(app.listAchievementsHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/goals", app.authMiddleWare(app.createGoalHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/goals", app.authMiddleWare(app.listGoalsHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/goals", app.authMiddleWare(app.updateGoalHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/goals", app.authMiddleWare(app.deleteGoalHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/goals/progress", app.authMiddleWare(app.getGoalsProgressHandler))
 
 	// Learned progress routes
 	router.HandlerFunc(http.MethodPost, "/v1/learned", app.authMiddleWare(app.CreateLearnedSlideGroup))
@@ -71,6 +150,13 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodGet, "/v1/learned/:collection_id", app.authMiddleWare(app.GetLearnedByCollection))
 	router.HandlerFunc(http.MethodDelete, "/v1/learned/:id", app.authMiddleWare(app.DeleteLearnedSlideGroup))
 
+	// Learn spaced-repetition review routes
+	router.HandlerFunc(http.MethodGet, "/v1/learned/:collection_id/due", app.authMiddleWare(app.matchParam("collection_id", "reviews", app.GetDueReviews, app.notFoundRespond)))
+	router.HandlerFunc(http.MethodPost, "/v1/learned/reviews/:id", app.authMiddleWare(app.RecordReview))
+
+	// Learn quiz routes
+	router.HandlerFunc(http.MethodPost, "/v1/learned/quiz-attempts", app.authMiddleWare(app.SubmitQuizAttempt))
+
 	// AI Memory routes (public — requires user auth)
 	router.HandlerFunc(http.MethodGet, "/v1/ai-memories", app.authMiddleWare(app.listAIMemoriesHandler))
 	router.HandlerFunc(http.MethodDelete, "/v1/ai-memories/:id", app.authMiddleWare(app.deleteAIMemoryHandler))
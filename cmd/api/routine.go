@@ -0,0 +1,342 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// createRoutineHandler saves a named, ordered list of exercises
+// POST /v1/routines
+func (app *application) createRoutineHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Title       string      `json:"title"`
+		Description string      `json:"description"`
+		ExerciseIDs []uuid.UUID `json:"exercise_ids"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	routine := &data.Routine{
+		UserID:      userID,
+		Title:       input.Title,
+		Description: input.Description,
+		ExerciseIDs: input.ExerciseIDs,
+	}
+
+	v := validator.New()
+	data.ValidateRoutine(v, routine)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	routine, err = app.models.Routine.Insert(routine)
+	if err != nil {
+		app.routineErrorResponse(w, r, err)
+		return
+	}
+	app.localizeRoutine(r, routine)
+
+	header := make(http.Header)
+	header.Set("Content-Location", fmt.Sprintf("v1/routines/%s", routine.ID))
+
+	err = app.writeJson(w, http.StatusCreated, envolope{"routine": routine}, header)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listRoutinesHandler returns the user's routines, newest first
+// GET /v1/routines
+func (app *application) listRoutinesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	routines, err := app.models.Routine.GetAllByUser(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	for _, routine := range routines {
+		app.localizeRoutine(r, routine)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"routines": routines}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showRoutineHandler returns a routine with its exercises in order
+// GET /v1/routines/:id
+func (app *application) showRoutineHandler(w http.ResponseWriter, r *http.Request) {
+	userID, routineID, ok := app.readRoutineIDs(w, r)
+	if !ok {
+		return
+	}
+
+	routine, err := app.models.Routine.Get(routineID, userID)
+	if err != nil {
+		app.routineErrorResponse(w, r, err)
+		return
+	}
+	app.localizeRoutine(r, routine)
+
+	err = app.writeJson(w, http.StatusOK, envolope{"routine": routine}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateRoutineHandler renames a routine or replaces its exercises
+// PATCH /v1/routines/:id
+func (app *application) updateRoutineHandler(w http.ResponseWriter, r *http.Request) {
+	userID, routineID, ok := app.readRoutineIDs(w, r)
+	if !ok {
+		return
+	}
+
+	routine, err := app.models.Routine.Get(routineID, userID)
+	if err != nil {
+		app.routineErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Title       *string     `json:"title"`
+		Description *string     `json:"description"`
+		ExerciseIDs []uuid.UUID `json:"exercise_ids"`
+	}
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Title != nil {
+		routine.Title = *input.Title
+	}
+	if input.Description != nil {
+		routine.Description = *input.Description
+	}
+
+	routine.ExerciseIDs = input.ExerciseIDs
+	if routine.ExerciseIDs == nil {
+		for _, e := range routine.Exercises {
+			routine.ExerciseIDs = append(routine.ExerciseIDs, e.ExerciseID)
+		}
+	}
+
+	v := validator.New()
+	data.ValidateRoutine(v, routine)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if input.ExerciseIDs == nil {
+		routine.ExerciseIDs = nil // keep the current exercises
+	}
+
+	routine, err = app.models.Routine.Update(routine)
+	if err != nil {
+		app.routineErrorResponse(w, r, err)
+		return
+	}
+	app.localizeRoutine(r, routine)
+
+	err = app.writeJson(w, http.StatusOK, envolope{"routine": routine}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteRoutineHandler deletes a routine and its sessions; exercises logged from them are kept
+// DELETE /v1/routines/:id
+func (app *application) deleteRoutineHandler(w http.ResponseWriter, r *http.Request) {
+	userID, routineID, ok := app.readRoutineIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Routine.Delete(routineID, userID)
+	if err != nil {
+		app.routineErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "routine deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// startRoutineSessionHandler starts playing a routine from its first exercise
+// POST /v1/routines/:id/sessions
+func (app *application) startRoutineSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, routineID, ok := app.readRoutineIDs(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := app.models.Routine.StartSession(routineID, userID)
+	if err != nil {
+		app.routineErrorResponse(w, r, err)
+		return
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Location", fmt.Sprintf("v1/routine_sessions/%s", session.ID))
+
+	err = app.writeJson(w, http.StatusCreated, envolope{"session": session}, header)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showRoutineSessionHandler returns a routine session and its current position
+// GET /v1/routine_sessions/:id
+func (app *application) showRoutineSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID, ok := app.readRoutineIDs(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := app.models.Routine.GetSession(sessionID, userID)
+	if err != nil {
+		app.routineSessionErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"session": session}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// advanceRoutineSessionHandler finishes the current exercise and moves to the next.
+// The exercise is logged as completed unless the body is {"skipped": true}.
+// POST /v1/routine_sessions/:id/next
+func (app *application) advanceRoutineSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID, ok := app.readRoutineIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Skipped bool `json:"skipped"`
+	}
+
+	if r.ContentLength != 0 {
+		err := app.readJson(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	session, logged, err := app.models.Routine.AdvanceSession(sessionID, userID, input.Skipped)
+	if err != nil {
+		app.routineSessionErrorResponse(w, r, err)
+		return
+	}
+
+	if logged {
+		app.recordActivity(userID, data.StreakActivityExercise)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"session": session}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// abandonRoutineSessionHandler stops a routine session; finished steps stay logged
+// POST /v1/routine_sessions/:id/abandon
+func (app *application) abandonRoutineSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID, ok := app.readRoutineIDs(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := app.models.Routine.AbandonSession(sessionID, userID)
+	if err != nil {
+		app.routineSessionErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"session": session}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) localizeRoutine(r *http.Request, routine *data.Routine) {
+	locale := app.getLocale(r)
+	for _, e := range routine.Exercises {
+		e.ApplyLocale(locale)
+	}
+}
+
+func (app *application) routineErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		http.Error(w, "Routine not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrUnknownExercise):
+		v := validator.New()
+		v.AddError("exercise_ids", "must reference existing exercises")
+		app.failedValidationResponse(w, r, v.Errors)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) routineSessionErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		http.Error(w, "Routine session not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrInvalidSessionTransition):
+		app.errorResponse(w, r, http.StatusConflict, err.Error())
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readRoutineIDs reads the user and the :id param, which is a routine or routine session id.
+func (app *application) readRoutineIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	id, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, id, true
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// sleepEntryInput is the request body for creating and updating sleep entries.
+// sleep_date defaults to the night the bedtime belongs to in the user's timezone.
+type sleepEntryInput struct {
+	SleepDate                  string    `json:"sleep_date"`
+	Bedtime                    time.Time `json:"bedtime"`
+	SleepOnsetLatencyMinutes   int       `json:"sleep_onset_latency_minutes"`
+	WakeCount                  int       `json:"wake_count"`
+	WakeAfterSleepOnsetMinutes int       `json:"wake_after_sleep_onset_minutes"`
+	FinalWakeAt                time.Time `json:"final_wake_at"`
+	RiseAt                     time.Time `json:"rise_at"`
+	Quality                    *int      `json:"quality"`
+	Notes                      string    `json:"notes"`
+}
+
+// readSleepEntry decodes and validates a sleep entry body. It writes the error
+// response itself and returns nil when the request should stop.
+func (app *application) readSleepEntry(w http.ResponseWriter, r *http.Request, userID uuid.UUID) *data.SleepEntry {
+	var input sleepEntryInput
+
+	err := app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return nil
+	}
+
+	v := validator.New()
+
+	if input.SleepDate == "" && !input.Bedtime.IsZero() {
+		loc, err := app.userLocation(r, userID, v)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return nil
+		}
+		input.SleepDate = data.DefaultSleepDate(input.Bedtime, loc)
+	}
+
+	entry := &data.SleepEntry{
+		UserID:                     userID,
+		SleepDate:                  input.SleepDate,
+		Bedtime:                    input.Bedtime,
+		SleepOnsetLatencyMinutes:   input.SleepOnsetLatencyMinutes,
+		WakeCount:                  input.WakeCount,
+		WakeAfterSleepOnsetMinutes: input.WakeAfterSleepOnsetMinutes,
+		FinalWakeAt:                input.FinalWakeAt,
+		RiseAt:                     input.RiseAt,
+		Quality:                    input.Quality,
+		Notes:                      input.Notes,
+	}
+
+	data.ValidateSleepEntry(v, entry)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return nil
+	}
+
+	return entry
+}
+
+// createSleepEntryHandler records a night in the sleep diary
+// POST /v1/sleep_entries
+func (app *application) createSleepEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	entry := app.readSleepEntry(w, r, userID)
+	if entry == nil {
+		return
+	}
+
+	created, err := app.models.SleepEntry.Insert(entry)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateSleepEntry) {
+			app.failedValidationResponse(w, r, map[string]string{"sleep_date": "a sleep entry for this night already exists"})
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.checkGoalsInBackground(userID)
+
+	err = app.writeJson(w, http.StatusCreated, envolope{"sleep_entry": created}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// listSleepEntriesHandler lists the sleep diary, newest night first
+// GET /v1/sleep_entries?page=&page_size=&sort=-sleep_date&start_time=&end_time=
+func (app *application) listSleepEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	filter := app.readQueryFilter(r.URL.Query(), v, TimeRangeFilterOptions(
+		"-sleep_date",
+		[]string{"sleep_date", "-sleep_date"},
+		"sleep_date",
+	))
+
+	filter.Validate(v)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	entries, metadata, err := app.models.SleepEntry.GetList(userID, filter)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"sleep_entries": entries,
+		"metadata":      metadata,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// showSleepEntryHandler returns one sleep entry
+// GET /v1/sleep_entries/:id
+func (app *application) showSleepEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := app.readSleepEntryIDs(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := app.models.SleepEntry.Get(entryID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"sleep_entry": entry}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateSleepEntryHandler replaces a sleep entry
+// PUT /v1/sleep_entries/:id
+func (app *application) updateSleepEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := app.readSleepEntryIDs(w, r)
+	if !ok {
+		return
+	}
+
+	entry := app.readSleepEntry(w, r, userID)
+	if entry == nil {
+		return
+	}
+	entry.ID = entryID
+
+	updated, err := app.models.SleepEntry.Update(entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundRespond(w, r)
+		case errors.Is(err, data.ErrDuplicateSleepEntry):
+			app.failedValidationResponse(w, r, map[string]string{"sleep_date": "a sleep entry for this night already exists"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"sleep_entry": updated}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteSleepEntryHandler deletes a sleep entry
+// DELETE /v1/sleep_entries/:id
+func (app *application) deleteSleepEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := app.readSleepEntryIDs(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.SleepEntry.Delete(entryID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"message": "sleep entry deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readSleepEntryIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	entryID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, entryID, true
+}
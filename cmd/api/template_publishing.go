@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// filterTemplatesForAudience drops templates whose audience rules do not match the
+// requesting user. Publish windows are already applied by the query.
+func (app *application) filterTemplatesForAudience(r *http.Request, templates []*data.JournalTemplate) ([]*data.JournalTemplate, error) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	// Users who have not finished onboarding have no information yet; they only
+	// see templates whose rules don't depend on it.
+	info, err := app.models.UserInformation.Get(userID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	locale := app.getLocale(r)
+	visible := make([]*data.JournalTemplate, 0, len(templates))
+	for _, t := range templates {
+		if t.Audience.IsEmpty() || t.Audience.Matches(info, locale) {
+			visible = append(visible, t)
+		}
+	}
+
+	return visible, nil
+}
+
+// templateVisible reports whether the requesting user can see the template now: it
+// must be published and its audience must include the user. Used by the paths that
+// load a single template by ID, which the gallery query does not filter.
+func (app *application) templateVisible(r *http.Request, template *data.JournalTemplate) (bool, error) {
+	if !template.IsPublished(time.Now()) {
+		return false, nil
+	}
+
+	visible, err := app.filterTemplatesForAudience(r, []*data.JournalTemplate{template})
+	if err != nil {
+		return false, err
+	}
+	return len(visible) == 1, nil
+}
+
+// updateTemplatePublishingHandler sets a template's active flag, publish window and audience
+// PUT /v1/templates/:id/publishing
+// Body: { "is_active": true, "publish_at": "...", "unpublish_at": "...", "audience": { "age_ranges": [], "locales": [], "kyc": {} } }
+func (app *application) updateTemplatePublishingHandler(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	templateID, err := uuid.Parse(params.ByName("id"))
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return
+	}
+
+	var input data.TemplatePublishing
+
+	err = app.readJson(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateTemplatePublishing(v, &input)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	template, err := app.models.UserJournal.UpdatePublishing(templateID, &input)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"template": template, "publishing": template.Publishing()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
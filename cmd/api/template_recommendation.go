@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
+)
+
+// recommendationLookback is how far back mood and emotion history is considered.
+const recommendationLookback = 14 * 24 * time.Hour
+
+// getRecommendedTemplatesHandler ranks active templates for the authenticated user
+// GET /v1/templates/recommended?limit=5
+func (app *application) getRecommendedTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	limit := app.readInt(r.URL.Query(), "limit", 5, v)
+	v.Check(limit > 0 && limit <= data.MaxPageSize, "limit", "must be between 1 and 100")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	templates, err := app.models.UserJournal.GetAllTemplates()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	templates, err = app.filterTemplatesForAudience(r, templates)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	signals, err := app.recommendationSignals(userID, templates)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	recommendations := data.RankTemplates(templates, signals)
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	// Localize only what is returned; ranking works on the canonical categories
+	locale := app.getLocale(r)
+	for _, rec := range recommendations {
+		rec.Template.ApplyLocale(locale)
+
+		err = rec.Template.StripQuizAnswers()
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"recommendations": recommendations,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// recommendationSignals gathers the user's mood trend, recent emotions, collection
+// usage and learn progress for the template ranking.
+func (app *application) recommendationSignals(userID uuid.UUID, templates []*data.JournalTemplate) (data.RecommendationSignals, error) {
+	now := time.Now()
+	since := now.Add(-recommendationLookback)
+
+	signals := data.RecommendationSignals{
+		LearnProgress: make(map[uuid.UUID]data.CollectionProgress),
+	}
+
+	points, err := app.models.UserJournal.GetMoodPoints(userID, since)
+	if err != nil {
+		return signals, err
+	}
+	signals.RecentMoodAverage, signals.MoodTrend = data.SummarizeMoodTrend(points, now)
+
+	signals.EmotionCounts, err = app.models.EmotionLog.GetEmotionCountsSince(userID, since)
+	if err != nil {
+		return signals, err
+	}
+
+	signals.CollectionUsage, err = app.models.UserJournal.CountByCollection(userID)
+	if err != nil {
+		return signals, err
+	}
+
+	learned, err := app.models.UserLearnedSlideGroup.GetAllByUser(userID)
+	if err != nil {
+		return signals, err
+	}
+
+	byCollection := make(map[uuid.UUID][]*data.UserLearnedSlideGroup)
+	for _, l := range learned {
+		byCollection[l.CollectionID] = append(byCollection[l.CollectionID], l)
+	}
+
+	for _, t := range templates {
+		records, ok := byCollection[t.ID]
+		if !ok {
+			continue
+		}
+
+		progress, err := data.CalculateCollectionProgress(t, records)
+		if err != nil {
+			return signals, err
+		}
+		signals.LearnProgress[t.ID] = progress
+	}
+
+	return signals, nil
+}
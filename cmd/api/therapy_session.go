@@ -359,6 +359,11 @@ func (app *application) toggleHomeworkHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if input.Completed {
+		app.evaluateAchievementsInBackground(userUUID)
+		app.checkGoalsInBackground(userUUID)
+	}
+
 	err = app.writeJson(w, http.StatusOK, envolope{"homework": result}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -14,8 +15,8 @@ func (app *application) createUserCompletedExerciseHandler(w http.ResponseWriter
 	var input struct {
 		UserId     uuid.UUID `json:"user_id"`
 		WeekNumber int       `json:"week_number"`
-		Duration   int8      `json:"duration"`
-		ExerciseId int       `json:"exercise_id"`
+		Duration   int       `json:"duration"`
+		ExerciseId uuid.UUID `json:"exercise_id"`
 		Notes      string    `json:"notes"`
 	}
 
@@ -37,14 +38,34 @@ func (app *application) createUserCompletedExerciseHandler(w http.ResponseWriter
 		ExerciseId: input.ExerciseId,
 	}
 
+	v := validator.New()
+	data.UserCompleteExercise(v, completedExercise)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Exercise.Get(completedExercise.ExerciseId)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			v.AddError("exercise_id", "must reference an existing exercise")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.models.UserCompletedExercise.Insert(completedExercise)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
+	app.recordActivity(userUUID, data.StreakActivityExercise)
+
 	header := make(http.Header)
-	header.Set("Content-Location", fmt.Sprintf("v1/user_completed_exercise/%d", completedExercise.Id))
+	header.Set("Content-Location", fmt.Sprintf("v1/user_completed_exercise/%s", completedExercise.Id))
 
 	err = app.writeJson(w, http.StatusCreated, completedExercise, header)
 	if err != nil {
@@ -103,7 +124,13 @@ func (app *application) listCompletedExerciseHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	err = app.writeJson(w, http.StatusOK, envolope{"metadata": metadata, "user_completed_exercises": completedExercises}, nil)
+	aggregates, err := app.models.UserCompletedExercise.Aggregates(fromTime, toTime, userUUID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"metadata": metadata, "user_completed_exercises": completedExercises, "aggregates": aggregates}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
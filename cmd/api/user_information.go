@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
 )
 
 func (app *application) getUserInformationHandler(w http.ResponseWriter, r *http.Request) {
@@ -46,6 +47,13 @@ func (app *application) createUserInformationHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	v := validator.New()
+	data.ValidateSettings(v, input.Settings)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	input.UserID = id
 	err = app.models.UserInformation.Insert(&input)
 	if err != nil {
@@ -83,12 +91,21 @@ func (app *application) updateUserInformationHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	previousTimezone := info.Timezone()
+
 	err = app.readJson(w, r, info)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
+	v := validator.New()
+	data.ValidateSettings(v, info.Settings)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	info.UserID = id
 
 	err = app.models.UserInformation.Update(info)
@@ -97,6 +114,11 @@ func (app *application) updateUserInformationHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Streak days depend on the timezone, so rebuild them when it changes
+	if info.Timezone() != previousTimezone {
+		app.recomputeStreakInBackground(id, info.Location())
+	}
+
 	err = app.writeJson(w, http.StatusOK, envolope{"user_info": info}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
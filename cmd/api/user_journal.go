@@ -111,19 +111,22 @@ func (app *application) GetAllTemplates(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	templates, err = app.filterTemplatesForAudience(r, templates)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	// Check requested locale — if Vietnamese, swap in vi fields where available
 	locale := app.getLocale(r)
-	if locale == "vi" {
-		for _, t := range templates {
-			if t.TitleVi != nil && *t.TitleVi != "" {
-				t.Title = *t.TitleVi
-			}
-			if t.DescriptionVi != nil && *t.DescriptionVi != "" {
-				t.Description = t.DescriptionVi
-			}
-			if t.SlideGroupsVi != nil && len(t.SlideGroupsVi) > 2 { // not empty "[]"
-				t.SlideGroups = t.SlideGroupsVi
-			}
+	for _, t := range templates {
+		t.ApplyLocale(locale)
+
+		// Quiz answers are scored server-side and must never reach the client
+		err = t.StripQuizAnswers()
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
 		}
 	}
 
@@ -163,10 +166,7 @@ func (app *application) CreateUserJournal(w http.ResponseWriter, r *http.Request
 	}
 
 	// Auto-update streak when a journal is created
-	if streakErr := app.models.UserStreak.UpdateOrReset(userID); streakErr != nil {
-		app.logger.PrintError(streakErr, map[string]string{"action": "update_streak_on_journal_create"})
-		// Don't fail journal creation if streak update fails
-	}
+	app.recordActivity(userID, data.StreakActivityJournal)
 
 	// Publish journal to AI service for Qdrant indexing (non-blocking)
 	// Skip if user has opted out of data collection
@@ -174,6 +174,9 @@ func (app *application) CreateUserJournal(w http.ResponseWriter, r *http.Request
 		app.publishJournalToAI(newJournal)
 	}
 
+	// Re-check for a sustained mood decline (non-blocking)
+	app.analyseWellbeingInBackground(userID)
+
 	err = app.writeJson(w, http.StatusCreated, newJournal, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -209,6 +212,9 @@ func (app *application) UpdateUserJournal(w http.ResponseWriter, r *http.Request
 		app.publishJournalToAI(updatedJournal)
 	}
 
+	// Re-check for a sustained mood decline (non-blocking)
+	app.analyseWellbeingInBackground(updatedJournal.UserID)
+
 	err = app.writeJson(w, http.StatusOK, updatedJournal, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
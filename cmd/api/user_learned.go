@@ -3,12 +3,25 @@ package main
 import (
 	"errors"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/julienschmidt/httprouter"
 	"tranquara.net/internal/data"
 )
 
+// LearnCollectionCompletedPayload is published when a user finishes the last
+// slide group of a learn collection.
+type LearnCollectionCompletedPayload struct {
+	UserID           uuid.UUID `json:"user_id"`
+	CollectionID     uuid.UUID `json:"collection_id"`
+	Title            string    `json:"title"`
+	TotalSlideGroups int       `json:"total_slide_groups"`
+	TimeSpentSeconds int       `json:"time_spent_seconds"`
+	CompletedAt      time.Time `json:"completed_at"`
+}
+
 // CreateLearnedSlideGroup marks a slide group as completed for the authenticated user
 // POST /v1/learned
 func (app *application) CreateLearnedSlideGroup(w http.ResponseWriter, r *http.Request) {
@@ -19,8 +32,9 @@ func (app *application) CreateLearnedSlideGroup(w http.ResponseWriter, r *http.R
 	}
 
 	var input struct {
-		CollectionID string `json:"collection_id"`
-		SlideGroupID string `json:"slide_group_id"`
+		CollectionID     string `json:"collection_id"`
+		SlideGroupID     string `json:"slide_group_id"`
+		TimeSpentSeconds int    `json:"time_spent_seconds"`
 	}
 
 	err = app.readJson(w, r, &input)
@@ -34,26 +48,143 @@ func (app *application) CreateLearnedSlideGroup(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if input.TimeSpentSeconds < 0 {
+		http.Error(w, "time_spent_seconds must not be negative", http.StatusBadRequest)
+		return
+	}
+
 	collectionID, err := uuid.Parse(input.CollectionID)
 	if err != nil {
 		http.Error(w, "Invalid collection_id format", http.StatusBadRequest)
 		return
 	}
 
+	template, err := app.models.UserJournal.GetTemplate(collectionID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	visible, err := app.templateVisible(r, template)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !visible {
+		app.notFoundRespond(w, r)
+		return
+	}
+
+	groups, err := template.ParseSlideGroups()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	idx := slices.IndexFunc(groups, func(g data.SlideGroup) bool { return g.ID == input.SlideGroupID })
+	if idx < 0 {
+		http.Error(w, "slide_group_id does not belong to this collection", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := app.models.UserLearnedSlideGroup.GetByCollection(userID, collectionID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	alreadyCompleted := slices.ContainsFunc(existing, func(l *data.UserLearnedSlideGroup) bool {
+		return l.SlideGroupID == input.SlideGroupID
+	})
+
+	// Slide groups with a quiz can only be completed once the quiz has been passed;
+	// an existing completion was only recorded after passing it
+	if !alreadyCompleted && groups[idx].HasQuiz() {
+		passed, err := app.models.QuizAttempt.HasPassed(userID, collectionID, input.SlideGroupID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if !passed {
+			app.failedValidationResponse(w, r, map[string]string{
+				"slide_group_id": "the quiz in this slide group must be passed first",
+			})
+			return
+		}
+	}
+
 	learned := &data.UserLearnedSlideGroup{
-		UserID:       userID,
-		CollectionID: collectionID,
-		SlideGroupID: input.SlideGroupID,
+		UserID:           userID,
+		CollectionID:     collectionID,
+		SlideGroupID:     input.SlideGroupID,
+		TimeSpentSeconds: input.TimeSpentSeconds,
 	}
 
-	result, err := app.models.UserLearnedSlideGroup.Insert(learned)
+	// Only the request that actually recorded the completion counts it, so concurrent
+	// submissions of the same slide group are counted once
+	result, inserted, err := app.models.UserLearnedSlideGroup.Insert(learned)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
+	if inserted {
+		app.recordActivity(userID, data.StreakActivityLearn)
+
+		// Learn content gets a spaced-repetition schedule starting the next day
+		if template.Type == "learn" {
+			err = app.models.LearnReview.Schedule(userID, collectionID, input.SlideGroupID, result.CompletedAt.Add(data.FirstReviewDelay))
+			if err != nil {
+				app.logger.PrintError(err, map[string]string{"action": "schedule_learn_review"})
+				// Don't fail the completion if scheduling the review fails
+			}
+		}
+	}
+
+	// Re-read after the insert so concurrent submissions of the last slide groups
+	// see each other's completions
+	existing, err = app.models.UserLearnedSlideGroup.GetByCollection(userID, collectionID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	template.ApplyLocale(app.getLocale(r))
+
+	progress, err := data.CalculateCollectionProgress(template, existing)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Only the first request to see the collection finished emits the event;
+	// re-completing a group in an already finished collection does not.
+	firstCompletion := false
+	if progress.IsCompleted && inserted {
+		firstCompletion, err = app.models.UserLearnedSlideGroup.MarkCollectionCompleted(userID, collectionID, *progress.CompletedAt)
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{"action": "mark_collection_completed"})
+		}
+	}
+
+	if firstCompletion {
+		app.publishEvent("learn.collection_completed", LearnCollectionCompletedPayload{
+			UserID:           userID,
+			CollectionID:     collectionID,
+			Title:            progress.Title,
+			TotalSlideGroups: progress.TotalSlideGroups,
+			TimeSpentSeconds: progress.TimeSpentSeconds,
+			CompletedAt:      result.CompletedAt,
+		})
+	}
+
 	err = app.writeJson(w, http.StatusCreated, envolope{
-		"learned": result,
+		"learned":  result,
+		"progress": progress,
 	}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -61,6 +192,7 @@ func (app *application) CreateLearnedSlideGroup(w http.ResponseWriter, r *http.R
 }
 
 // GetLearnedByCollection retrieves all completed slide groups for a collection
+// together with the progress summary for that collection
 // GET /v1/learned/:collection_id
 func (app *application) GetLearnedByCollection(w http.ResponseWriter, r *http.Request) {
 	userID, err := app.GetUserUUIDFromContext(r.Context())
@@ -78,14 +210,33 @@ func (app *application) GetLearnedByCollection(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	template, err := app.models.UserJournal.GetTemplate(collectionID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.notFoundRespond(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	learned, err := app.models.UserLearnedSlideGroup.GetByCollection(userID, collectionID)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
+	template.ApplyLocale(app.getLocale(r))
+
+	progress, err := data.CalculateCollectionProgress(template, learned)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJson(w, http.StatusOK, envolope{
-		"learned": learned,
+		"learned":  learned,
+		"progress": progress,
 	}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -93,6 +244,7 @@ func (app *application) GetLearnedByCollection(w http.ResponseWriter, r *http.Re
 }
 
 // GetAllLearned retrieves all completed slide groups for the authenticated user
+// together with a progress summary per learn collection
 // GET /v1/learned
 func (app *application) GetAllLearned(w http.ResponseWriter, r *http.Request) {
 	userID, err := app.GetUserUUIDFromContext(r.Context())
@@ -107,8 +259,39 @@ func (app *application) GetAllLearned(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	templates, err := app.models.UserJournal.GetAllTemplates()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	byCollection := make(map[uuid.UUID][]*data.UserLearnedSlideGroup)
+	for _, l := range learned {
+		byCollection[l.CollectionID] = append(byCollection[l.CollectionID], l)
+	}
+
+	// Report every learn collection, plus any other collection the user has progress in
+	locale := app.getLocale(r)
+	progress := []data.CollectionProgress{}
+	for _, t := range templates {
+		records, started := byCollection[t.ID]
+		if t.Type != "learn" && !started {
+			continue
+		}
+
+		t.ApplyLocale(locale)
+
+		p, err := data.CalculateCollectionProgress(t, records)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		progress = append(progress, p)
+	}
+
 	err = app.writeJson(w, http.StatusOK, envolope{
-		"learned": learned,
+		"learned":  learned,
+		"progress": progress,
 	}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -133,6 +316,12 @@ func (app *application) DeleteLearnedSlideGroup(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	err = app.models.LearnReview.DeleteForLearned(id, userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.models.UserLearnedSlideGroup.Delete(id, userID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
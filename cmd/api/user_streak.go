@@ -3,10 +3,60 @@ package main
 import (
 	"errors"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	"tranquara.net/internal/data"
+	"tranquara.net/internal/validator"
 )
 
+// recordActivity advances the user's streak when the streak policy counts the
+// activity, with day boundaries in the user's timezone, then re-evaluates
+// achievements and goals. Failures are logged only, so they never fail the request that
+// produced the activity.
+func (app *application) recordActivity(userID uuid.UUID, activity string) {
+	if app.config.streak.policy.Counts(activity) {
+		loc, err := app.userTimezone(userID)
+		if err == nil {
+			err = app.models.UserStreak.UpdateOrReset(userID, loc)
+		}
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{"action": "update_streak", "activity": activity})
+		}
+	}
+
+	app.evaluateAchievementsInBackground(userID)
+	app.checkGoalsInBackground(userID)
+}
+
+// activityChanged rebuilds the user's streak after an activity was edited or
+// deleted, since that can remove a day's only activity, then re-evaluates
+// achievements and goals. Failures are logged only.
+func (app *application) activityChanged(userID uuid.UUID, activity string) {
+	if app.config.streak.policy.Counts(activity) {
+		loc, err := app.userTimezone(userID)
+		if err == nil {
+			err = app.models.UserStreak.Recompute(userID, loc, app.config.streak.policy)
+		}
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{"action": "recompute_streak", "activity": activity})
+		}
+	}
+
+	app.evaluateAchievementsInBackground(userID)
+	app.checkGoalsInBackground(userID)
+}
+
+// recomputeStreakInBackground rebuilds the user's streak after their timezone changed.
+func (app *application) recomputeStreakInBackground(userID uuid.UUID, loc *time.Location) {
+	app.background(func() {
+		err := app.models.UserStreak.Recompute(userID, loc, app.config.streak.policy)
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{"action": "recompute_streak", "user_id": userID.String()})
+		}
+	})
+}
+
 func (app *application) getUserStreakHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := app.GetUserUUIDFromContext(r.Context())
 	if err != nil {
@@ -25,24 +75,71 @@ func (app *application) getUserStreakHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	loc, err := app.userTimezone(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	streak.ApplyLocation(loc, time.Now())
+
 	err = app.writeJson(w, http.StatusOK, envolope{"user_streak": streak}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// updateUserStreakHandler is kept for older clients. Streaks now advance server-side
+// from qualifying activities, so this only returns the current streak.
+// PUT /v1/user_streaks
 func (app *application) updateUserStreakHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := app.GetUserUUIDFromContext(r.Context())
+	app.getUserStreakHandler(w, r)
+}
+
+// listStreakEventsHandler lists the streak history: starts, extensions, resets and
+// freezes earned or used, newest first
+// GET /v1/user_streaks/events?event_type=freeze_used&page=&page_size=&start_time=&end_time=
+func (app *application) listStreakEventsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
 	if err != nil {
-		app.badRequestResponse(w, r, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.models.UserStreak.UpdateOrReset(id)
+	qs := r.URL.Query()
+	v := validator.New()
+
+	eventType := app.readString(qs, "event_type", "")
+	v.Check(eventType == "" || validator.In(eventType,
+		data.StreakEventStarted,
+		data.StreakEventExtended,
+		data.StreakEventFreezeUsed,
+		data.StreakEventFreezeEarned,
+		data.StreakEventReset,
+	), "event_type", "must be one of started, extended, freeze_used, freeze_earned, reset")
+
+	filter := app.readQueryFilter(qs, v, TimeRangeFilterOptions(
+		"-event_date",
+		[]string{"event_date", "-event_date"},
+		"event_date",
+	))
+
+	filter.Validate(v)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	events, metadata, err := app.models.StreakEvent.GetList(userID, eventType, filter)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	app.writeJson(w, http.StatusOK, envolope{"message": "User streak updated."}, nil)
+	err = app.writeJson(w, http.StatusOK, envolope{
+		"streak_events": events,
+		"metadata":      metadata,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/data"
+)
+
+// analyseWellbeingInBackground re-runs decline detection after a mood or emotion write
+// and publishes "wellbeing.decline_detected" when it raises a nudge. Failures are
+// logged; they never affect the request that triggered the check.
+func (app *application) analyseWellbeingInBackground(userID uuid.UUID) {
+	app.background(func() {
+		decline, err := app.models.AnalyseWellbeing(userID, time.Now())
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{
+				"action":  "analyse_wellbeing",
+				"user_id": userID.String(),
+			})
+			return
+		}
+
+		if decline != nil {
+			app.publishEvent("wellbeing.decline_detected", decline)
+		}
+	})
+}
+
+// listNudgesHandler retrieves the user's in-app nudges
+// GET /v1/nudges              → latest nudges
+// GET /v1/nudges?unread=true  → unread nudges only
+func (app *application) listNudgesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	nudges, err := app.models.Nudge.GetAllByUser(userID, unreadOnly)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	locale := app.getLocale(r)
+	for _, n := range nudges {
+		n.ApplyLocale(locale)
+	}
+
+	err = app.writeJson(w, http.StatusOK, envolope{"nudges": nudges}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readNudgeHandler marks a nudge as read
+// PATCH /v1/nudges?id=<uuid>
+func (app *application) readNudgeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := app.GetUserUUIDFromContext(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		http.Error(w, "id query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	nudgeID, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid id format", http.StatusBadRequest)
+		return
+	}
+
+	nudge, err := app.models.Nudge.MarkRead(nudgeID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			http.Error(w, "Nudge not found", http.StatusNotFound)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	nudge.ApplyLocale(app.getLocale(r))
+
+	err = app.writeJson(w, http.StatusOK, envolope{"nudge": nudge}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,82 @@
+// Command rebuild-streaks recomputes every user's streak from their activity history,
+// with day boundaries in each user's timezone.
+//
+//	go run ./cmd/rebuild-streaks -db-dsn=postgres://... [-user=<uuid>] [-streak-activities=journal,exercise]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"tranquara.net/internal/data"
+	"tranquara.net/internal/jsonlog"
+)
+
+func main() {
+	dsn := flag.String("db-dsn", os.Getenv("TRANQUARA_DB_DSN"), "postgres dsn")
+	user := flag.String("user", "", "Only rebuild the streak of this user id")
+	activities := flag.String("streak-activities", data.DefaultStreakActivities, "Comma-separated activities that advance streaks (journal,emotion_log,exercise,learn)")
+	flag.Parse()
+
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+
+	policy, err := data.ParseStreakPolicy(*activities)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = db.PingContext(ctx); err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	models := data.NewModels(db)
+
+	timezones, err := models.UserStreak.GetAllTimezones()
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	if *user != "" {
+		userID, err := uuid.Parse(*user)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+		}
+		timezones = map[uuid.UUID]string{userID: timezones[userID]}
+	}
+
+	rebuilt, failed := 0, 0
+	for userID, timezone := range timezones {
+		info := &data.UserInformation{Settings: map[string]any{data.TimezoneSettingKey: timezone}}
+
+		err = models.UserStreak.Recompute(userID, info.Location(), policy)
+		if err != nil {
+			failed++
+			logger.PrintError(err, map[string]string{"user_id": userID.String()})
+			continue
+		}
+		rebuilt++
+	}
+
+	logger.PrintInfo("streaks rebuilt", map[string]string{
+		"rebuilt": fmt.Sprint(rebuilt),
+		"failed":  fmt.Sprint(failed),
+	})
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
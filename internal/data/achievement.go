@@ -0,0 +1,263 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// achievementMetrics maps each metric an achievement can be defined on to the query
+// computing the user's ($1) current value. Achievements themselves live in the
+// achievements table, so new badges on these metrics need no code changes.
+var achievementMetrics = map[string]string{
+	"journal_count":     `SELECT COUNT(*) FROM user_journals WHERE user_id = $1`,
+	"emotion_log_count": `SELECT COUNT(*) FROM emotion_logs WHERE user_id = $1`,
+	"exercise_count":    `SELECT COUNT(*) FROM user_completed_exercises WHERE user_id = $1`,
+	"longest_streak":    `SELECT COALESCE(MAX(longest_streak), 0) FROM user_streaks WHERE user_id = $1`,
+	"learn_collections_completed": `
+		SELECT COUNT(*)
+		FROM journal_templates t
+		WHERE t.type = 'learn'
+		  AND jsonb_array_length(COALESCE(t.slide_groups, '[]'::jsonb)) > 0
+		  AND NOT EXISTS (
+			SELECT 1 FROM jsonb_array_elements(t.slide_groups) g
+			WHERE NOT EXISTS (
+				SELECT 1 FROM user_learned_slide_groups l
+				WHERE l.user_id = $1 AND l.collection_id = t.id AND l.slide_group_id = g->>'id'
+			)
+		  )`,
+	"homework_completed": `SELECT COUNT(*) FROM homework_items WHERE user_id = $1::text AND completed`,
+	"prep_pack_count":    `SELECT COUNT(*) FROM prep_packs WHERE user_id = $1::text`,
+}
+
+// Achievement is a badge definition, with the user's progress towards it when listed for a user.
+type Achievement struct {
+	Code          string     `json:"code"`
+	Metric        string     `json:"metric"`
+	Threshold     int        `json:"threshold"`
+	Title         string     `json:"title"`
+	TitleVi       *string    `json:"-"`
+	Description   string     `json:"description"`
+	DescriptionVi *string    `json:"-"`
+	Icon          *string    `json:"icon"`
+	Position      int        `json:"position"`
+	Progress      int        `json:"progress"`
+	Earned        bool       `json:"earned"`
+	AwardedAt     *time.Time `json:"awarded_at"`
+}
+
+// ApplyLocale swaps in the Vietnamese title and description when available.
+func (a *Achievement) ApplyLocale(locale string) {
+	if locale != "vi" {
+		return
+	}
+	if a.TitleVi != nil && *a.TitleVi != "" {
+		a.Title = *a.TitleVi
+	}
+	if a.DescriptionVi != nil && *a.DescriptionVi != "" {
+		a.Description = *a.DescriptionVi
+	}
+}
+
+// AchievementAwardedPayload is the payload of the "achievement.awarded" event.
+type AchievementAwardedPayload struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Code      string    `json:"code"`
+	Title     string    `json:"title"`
+	AwardedAt time.Time `json:"awarded_at"`
+}
+
+// AwardedPayload builds the "achievement.awarded" event for an achievement returned by Evaluate.
+func (a *Achievement) AwardedPayload(userID uuid.UUID) AchievementAwardedPayload {
+	return AchievementAwardedPayload{
+		UserID:    userID,
+		Code:      a.Code,
+		Title:     a.Title,
+		AwardedAt: *a.AwardedAt,
+	}
+}
+
+type AchievementModel struct {
+	DB *sql.DB
+}
+
+// GetAllForUser returns every active achievement in display order, marking those the
+// user has earned and their current progress (capped at the threshold).
+func (m AchievementModel) GetAllForUser(userID uuid.UUID) ([]*Achievement, error) {
+	query := `
+		SELECT a.code, a.metric, a.threshold, a.title, a.title_vi, a.description, a.description_vi,
+		       a.icon, a.position, ua.awarded_at
+		FROM achievements a
+		LEFT JOIN user_achievements ua ON ua.achievement_code = a.code AND ua.user_id = $1
+		WHERE a.is_active
+		ORDER BY a.position, a.code
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	achievements := []*Achievement{}
+	for rows.Next() {
+		var a Achievement
+		err = rows.Scan(
+			&a.Code,
+			&a.Metric,
+			&a.Threshold,
+			&a.Title,
+			&a.TitleVi,
+			&a.Description,
+			&a.DescriptionVi,
+			&a.Icon,
+			&a.Position,
+			&a.AwardedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.Earned = a.AwardedAt != nil
+		achievements = append(achievements, &a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	values, err := m.metricValues(ctx, userID, achievements)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range achievements {
+		a.Progress = min(values[a.Metric], a.Threshold)
+		if a.Earned {
+			a.Progress = a.Threshold
+		}
+	}
+
+	return achievements, nil
+}
+
+// Evaluate awards every active achievement whose threshold the user has reached and
+// returns the newly awarded ones. Awarding is idempotent: an achievement already
+// held, or awarded concurrently, is never returned twice.
+func (m AchievementModel) Evaluate(userID uuid.UUID) ([]*Achievement, error) {
+	query := `
+		SELECT a.code, a.metric, a.threshold, a.title, a.title_vi, a.description, a.description_vi,
+		       a.icon, a.position
+		FROM achievements a
+		WHERE a.is_active
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_achievements ua
+			WHERE ua.achievement_code = a.code AND ua.user_id = $1
+		  )
+		ORDER BY a.position, a.code
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []*Achievement
+	for rows.Next() {
+		var a Achievement
+		err = rows.Scan(
+			&a.Code,
+			&a.Metric,
+			&a.Threshold,
+			&a.Title,
+			&a.TitleVi,
+			&a.Description,
+			&a.DescriptionVi,
+			&a.Icon,
+			&a.Position,
+		)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, &a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	values, err := m.metricValues(ctx, userID, pending)
+	if err != nil {
+		return nil, err
+	}
+
+	award := `
+		INSERT INTO user_achievements (user_id, achievement_code)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, achievement_code) DO NOTHING
+		RETURNING awarded_at
+	`
+
+	var awarded []*Achievement
+	for _, a := range pending {
+		if values[a.Metric] < a.Threshold {
+			continue
+		}
+
+		var awardedAt time.Time
+		err = m.DB.QueryRowContext(ctx, award, userID, a.Code).Scan(&awardedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return awarded, err
+		}
+
+		a.Progress = a.Threshold
+		a.Earned = true
+		a.AwardedAt = &awardedAt
+		awarded = append(awarded, a)
+	}
+
+	return awarded, nil
+}
+
+// metricValues computes each distinct metric used by the achievements once.
+// Metrics without a query are skipped and read as 0.
+func (m AchievementModel) metricValues(ctx context.Context, userID uuid.UUID, achievements []*Achievement) (map[string]int, error) {
+	metrics := map[string]bool{}
+	for _, a := range achievements {
+		if _, ok := achievementMetrics[a.Metric]; ok {
+			metrics[a.Metric] = true
+		}
+	}
+
+	names := make([]string, 0, len(metrics))
+	for metric := range metrics {
+		names = append(names, metric)
+	}
+	sort.Strings(names)
+
+	values := make(map[string]int, len(names))
+	for _, metric := range names {
+		var value int
+		err := m.DB.QueryRowContext(ctx, achievementMetrics[metric], userID).Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+		values[metric] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,236 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
+)
+
+const (
+	// DefaultChatThreadTitle names a general thread whose first message has no text.
+	DefaultChatThreadTitle = "New conversation"
+	// DefaultJournalThreadTitle names a journal's thread when the journal has no title.
+	DefaultJournalThreadTitle = "Journal chat"
+)
+
+var (
+	// ErrDuplicateChatThread is returned when creating a second thread about the same journal.
+	ErrDuplicateChatThread = errors.New("the journal already has a chat thread")
+)
+
+// ChatThread is a titled conversation with the guider, optionally about a journal.
+type ChatThread struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Title         string     `json:"title"`
+	JournalID     *uuid.UUID `json:"journal_id"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func ValidateChatThread(v *validator.Validator, t *ChatThread) {
+	v.Check(strings.TrimSpace(t.Title) != "", "title", "must be provided")
+	v.Check(len(t.Title) <= 200, "title", "must not be more than 200 bytes long")
+}
+
+// ChatThreadTitle derives a thread title from its first message.
+func ChatThreadTitle(message string) string {
+	title := strings.Join(strings.Fields(message), " ")
+	if title == "" {
+		return DefaultChatThreadTitle
+	}
+
+	runes := []rune(title)
+	if len(runes) > 60 {
+		title = strings.TrimSpace(string(runes[:60])) + "…"
+	}
+	return title
+}
+
+type ChatThreadModel struct {
+	DB *sql.DB
+}
+
+const chatThreadColumns = `id, user_id, title, journal_id, last_message_at, created_at, updated_at`
+
+func scanChatThread(row interface{ Scan(...any) error }, prefix ...any) (*ChatThread, error) {
+	var t ChatThread
+	dest := append(prefix,
+		&t.ID,
+		&t.UserID,
+		&t.Title,
+		&t.JournalID,
+		&t.LastMessageAt,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Insert creates a thread. A user has at most one thread per journal; a second one
+// fails with ErrDuplicateChatThread.
+func (m ChatThreadModel) Insert(t *ChatThread) (*ChatThread, error) {
+	query := `
+		INSERT INTO chat_threads (user_id, title, journal_id)
+		VALUES ($1, $2, $3)
+		RETURNING ` + chatThreadColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	created, err := scanChatThread(m.DB.QueryRowContext(ctx, query, t.UserID, t.Title, t.JournalID))
+	if err != nil && strings.Contains(err.Error(), "idx_chat_threads_user_journal") {
+		return nil, ErrDuplicateChatThread
+	}
+	return created, err
+}
+
+func (m ChatThreadModel) Get(id, userID uuid.UUID) (*ChatThread, error) {
+	query := `SELECT ` + chatThreadColumns + ` FROM chat_threads WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t, err := scanChatThread(m.DB.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return t, err
+}
+
+// GetForJournal returns the user's thread about the journal, creating one titled
+// after the journal when there is none. Concurrent callers get the same thread.
+func (m ChatThreadModel) GetForJournal(userID, journalID uuid.UUID) (*ChatThread, error) {
+	query := `
+		SELECT ` + chatThreadColumns + `
+		FROM chat_threads
+		WHERE user_id = $1 AND journal_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	t, err := scanChatThread(m.DB.QueryRowContext(ctx, query, userID, journalID))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return t, err
+	}
+
+	// A thread created concurrently wins; the re-select below returns it
+	insert := `
+		INSERT INTO chat_threads (user_id, title, journal_id)
+		SELECT $1, LEFT(COALESCE(NULLIF(j.title, ''), $3), 200), $2
+		FROM (SELECT 1) one
+		LEFT JOIN user_journals j ON j.id = $2 AND j.user_id = $1
+		ON CONFLICT (user_id, journal_id) WHERE journal_id IS NOT NULL DO NOTHING`
+
+	_, err = m.DB.ExecContext(ctx, insert, userID, journalID, DefaultJournalThreadTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanChatThread(m.DB.QueryRowContext(ctx, query, userID, journalID))
+}
+
+func (m ChatThreadModel) GetList(userID uuid.UUID, journalID *uuid.UUID, filter *QueryFilter) ([]*ChatThread, Metadata, error) {
+	var queryBuilder strings.Builder
+	var args []interface{}
+	paramIndex := 1
+
+	queryBuilder.WriteString(`
+		SELECT COUNT(*) OVER(), ` + chatThreadColumns + `
+		FROM chat_threads
+		WHERE user_id = $1
+	`)
+	args = append(args, userID)
+	paramIndex++
+
+	if journalID != nil {
+		queryBuilder.WriteString(fmt.Sprintf(" AND journal_id = $%d", paramIndex))
+		args = append(args, *journalID)
+		paramIndex++
+	}
+
+	if filter.SortClause() != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s NULLS LAST, id", filter.SortClause()))
+	} else {
+		queryBuilder.WriteString(" ORDER BY COALESCE(last_message_at, created_at) DESC, id")
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1))
+	args = append(args, filter.Limit(), filter.Offset())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	threads := []*ChatThread{}
+
+	for rows.Next() {
+		t, err := scanChatThread(rows, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		threads = append(threads, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return threads, filter.CalculateMetadata(totalRecords), nil
+}
+
+func (m ChatThreadModel) Update(t *ChatThread) (*ChatThread, error) {
+	query := `
+		UPDATE chat_threads
+		SET title = $1
+		WHERE id = $2 AND user_id = $3
+		RETURNING ` + chatThreadColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	updated, err := scanChatThread(m.DB.QueryRowContext(ctx, query, t.Title, t.ID, t.UserID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return updated, err
+}
+
+// Delete removes the thread and its messages.
+func (m ChatThreadModel) Delete(id, userID uuid.UUID) error {
+	query := `DELETE FROM chat_threads WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
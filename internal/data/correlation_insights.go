@@ -0,0 +1,306 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Activity kinds correlated with mood. Exercises additionally produce
+// "exercise.<exercise_type>" kinds, e.g. "exercise.breathing".
+const (
+	ActivityExercise       = "exercise"
+	ActivityLearn          = "learn"
+	ActivityTherapySession = "therapy_session"
+	ActivityEfficientSleep = "efficient_sleep"
+)
+
+// Guards against spurious findings
+const (
+	// MinMoodDays is the fewest days with a mood score before any correlation is computed.
+	MinMoodDays = 14
+	// MinGroupDays is the fewest days needed on each side (with and without the activity).
+	MinGroupDays = 5
+	// MinEffectSize is the smallest |Cohen's d| reported.
+	MinEffectSize = 0.2
+	// MinTStatistic is the smallest |Welch t| reported, roughly p < 0.05.
+	MinTStatistic = 2.0
+)
+
+// CorrelationLags are the day offsets between an activity and the mood it is compared with.
+var CorrelationLags = []int{0, 1}
+
+var activityLabels = map[string][2]string{
+	ActivityExercise:       {"any exercise", "bài tập bất kỳ"},
+	ActivityLearn:          {"a learn lesson", "một bài học"},
+	ActivityTherapySession: {"a therapy session", "một buổi trị liệu"},
+	ActivityEfficientSleep: {"an efficient night of sleep", "một đêm ngủ hiệu quả"},
+}
+
+// ActivityEvent is one occurrence of an activity.
+type ActivityEvent struct {
+	Kind string
+	At   time.Time
+}
+
+// CorrelationFinding compares mood on days after (or on) an activity with the other days.
+type CorrelationFinding struct {
+	Activity string `json:"activity"`
+	Label    string `json:"label"`
+	// Lag is 0 for mood on the same day and 1 for mood on the following day.
+	Lag             int     `json:"lag"`
+	DaysWith        int     `json:"days_with"`
+	DaysWithout     int     `json:"days_without"`
+	MoodWith        float64 `json:"mood_with"`
+	MoodWithout     float64 `json:"mood_without"`
+	MeanDifference  float64 `json:"mean_difference"`
+	EffectSize      float64 `json:"effect_size"`
+	EffectMagnitude string  `json:"effect_magnitude"`
+	TStatistic      float64 `json:"t_statistic"`
+}
+
+// CorrelationInsights is the response of the correlations endpoint. Only findings
+// that pass every guard are listed; the rest are counted in Tested.
+type CorrelationInsights struct {
+	Range    string               `json:"range"`
+	Timezone string               `json:"timezone"`
+	MoodDays int                  `json:"mood_days"`
+	Tested   int                  `json:"tested"`
+	Findings []CorrelationFinding `json:"findings"`
+	// InsufficientData lists activities that could not be tested for lack of days.
+	InsufficientData []string `json:"insufficient_data"`
+}
+
+type ActivityEventModel struct {
+	DB *sql.DB
+}
+
+// GetSince returns the user's completed exercises, learned slide groups and past
+// therapy sessions since the given time.
+func (m ActivityEventModel) GetSince(userID uuid.UUID, since time.Time) ([]ActivityEvent, error) {
+	query := `
+		SELECT 'exercise', COALESCE(LOWER(e.exercise_type), ''), c.completed_at
+		FROM user_completed_exercises c
+		LEFT JOIN exercises e ON e.exercise_id = c.exercise_id
+		WHERE c.user_id = $1 AND c.completed_at >= $2
+		UNION ALL
+		SELECT 'learn', '', completed_at
+		FROM user_learned_slide_groups
+		WHERE user_id = $1 AND completed_at >= $2
+		UNION ALL
+		SELECT 'therapy_session', '', session_date::timestamp
+		FROM therapy_sessions
+		WHERE user_id = $1::text AND session_date >= $2 AND session_date <= NOW()
+		  AND COALESCE(status, '') <> 'cancelled'
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []ActivityEvent{}
+	for rows.Next() {
+		var kind, subtype string
+		var at time.Time
+		err = rows.Scan(&kind, &subtype, &at)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, ActivityEvent{Kind: kind, At: at})
+		if subtype != "" {
+			events = append(events, ActivityEvent{Kind: kind + "." + subtype, At: at})
+		}
+	}
+
+	return events, rows.Err()
+}
+
+// SleepActivityEvents turns nights at or above EfficientSleepThreshold into events
+// dated on the evening of the night, so lag 1 compares with the following day.
+func SleepActivityEvents(entries []*SleepEntry, loc *time.Location) []ActivityEvent {
+	events := []ActivityEvent{}
+	for _, e := range entries {
+		if e.Metrics.SleepEfficiency < EfficientSleepThreshold {
+			continue
+		}
+		night, err := time.ParseInLocation(SleepDateLayout, e.SleepDate, loc)
+		if err != nil {
+			continue
+		}
+		events = append(events, ActivityEvent{Kind: ActivityEfficientSleep, At: night.Add(12 * time.Hour)})
+	}
+	return events
+}
+
+// ComputeCorrelations compares the daily mood average on days following each
+// activity (by every lag in CorrelationLags) with the remaining days of the range.
+func ComputeCorrelations(points []MoodPoint, events []ActivityEvent, opts MoodInsightsOptions) CorrelationInsights {
+	start := RangeStart(opts)
+	insights := CorrelationInsights{
+		Range:            opts.Range,
+		Timezone:         opts.Location.String(),
+		Findings:         []CorrelationFinding{},
+		InsufficientData: []string{},
+	}
+
+	var inRange []MoodPoint
+	for _, p := range points {
+		if !p.At.Before(start) {
+			inRange = append(inRange, p)
+		}
+	}
+	dailyMood := DailyMoodAverages(inRange, opts.Location)
+	insights.MoodDays = len(dailyMood)
+
+	activityDays := map[string]map[string]bool{}
+	for _, e := range events {
+		if activityDays[e.Kind] == nil {
+			activityDays[e.Kind] = map[string]bool{}
+		}
+		activityDays[e.Kind][e.At.In(opts.Location).Format(SleepDateLayout)] = true
+	}
+
+	kinds := make([]string, 0, len(activityDays))
+	for kind := range activityDays {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	if insights.MoodDays < MinMoodDays {
+		insights.InsufficientData = kinds
+		return insights
+	}
+
+	for _, kind := range kinds {
+		tested := false
+		for _, lag := range CorrelationLags {
+			var with, without []float64
+			for day, mood := range dailyMood {
+				d, _ := time.ParseInLocation(SleepDateLayout, day, opts.Location)
+				if activityDays[kind][d.AddDate(0, 0, -lag).Format(SleepDateLayout)] {
+					with = append(with, mood)
+				} else {
+					without = append(without, mood)
+				}
+			}
+
+			if len(with) < MinGroupDays || len(without) < MinGroupDays {
+				continue
+			}
+			tested = true
+			insights.Tested++
+
+			finding, ok := compareGroups(with, without)
+			if !ok {
+				continue
+			}
+			finding.Activity = kind
+			finding.Label = activityLabel(kind, opts.Locale)
+			finding.Lag = lag
+			insights.Findings = append(insights.Findings, finding)
+		}
+
+		if !tested {
+			insights.InsufficientData = append(insights.InsufficientData, kind)
+		}
+	}
+
+	sort.SliceStable(insights.Findings, func(i, j int) bool {
+		return math.Abs(insights.Findings[i].EffectSize) > math.Abs(insights.Findings[j].EffectSize)
+	})
+
+	return insights
+}
+
+// compareGroups computes Cohen's d and Welch's t and reports whether the
+// difference passes MinEffectSize and MinTStatistic.
+func compareGroups(with, without []float64) (CorrelationFinding, bool) {
+	meanWith, varWith := meanVariance(with)
+	meanWithout, varWithout := meanVariance(without)
+	nWith, nWithout := float64(len(with)), float64(len(without))
+
+	pooled := math.Sqrt(((nWith-1)*varWith + (nWithout-1)*varWithout) / (nWith + nWithout - 2))
+	standardError := math.Sqrt(varWith/nWith + varWithout/nWithout)
+	if pooled == 0 || standardError == 0 {
+		return CorrelationFinding{}, false
+	}
+
+	difference := meanWith - meanWithout
+	d := difference / pooled
+	t := difference / standardError
+
+	if math.Abs(d) < MinEffectSize || math.Abs(t) < MinTStatistic {
+		return CorrelationFinding{}, false
+	}
+
+	return CorrelationFinding{
+		DaysWith:        len(with),
+		DaysWithout:     len(without),
+		MoodWith:        round2(meanWith),
+		MoodWithout:     round2(meanWithout),
+		MeanDifference:  round2(difference),
+		EffectSize:      round2(d),
+		EffectMagnitude: effectMagnitude(d),
+		TStatistic:      round2(t),
+	}, true
+}
+
+// meanVariance returns the mean and the sample variance.
+func meanVariance(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values) - 1)
+
+	return mean, variance
+}
+
+func effectMagnitude(d float64) string {
+	switch d = math.Abs(d); {
+	case d >= 0.8:
+		return "large"
+	case d >= 0.5:
+		return "medium"
+	default:
+		return "small"
+	}
+}
+
+func activityLabel(kind, locale string) string {
+	i := 0
+	if locale == "vi" {
+		i = 1
+	}
+
+	if labels, ok := activityLabels[kind]; ok {
+		return labels[i]
+	}
+
+	// "exercise.breathing" → "breathing exercise" / "bài tập breathing"
+	if subtype, ok := strings.CutPrefix(kind, ActivityExercise+"."); ok {
+		if i == 1 {
+			return "bài tập " + subtype
+		}
+		return subtype + " exercise"
+	}
+
+	return kind
+}
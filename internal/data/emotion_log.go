@@ -3,50 +3,184 @@ package data
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
 )
 
 type EmotionLog struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Emotion   string    `json:"emotion"`
-	Source    string    `json:"source"`
-	Context   string    `json:"context"`
-	CreatedAt time.Time `json:"created_at"`
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Emotion is the primary (first) emotion code; legacy logs hold free text here.
+	Emotion  string         `json:"emotion"`
+	Emotions []EmotionEntry `json:"emotions"`
+	// TaxonomyVersion is nil for logs written before the taxonomy existed.
+	TaxonomyVersion *int      `json:"taxonomy_version,omitempty"`
+	Source          string    `json:"source"`
+	Context         string    `json:"context"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// NormalizeEmotions maps legacy labels to taxonomy codes, defaults missing
+// intensities and sets the primary emotion and taxonomy version. A log that only
+// carries the legacy "emotion" field is turned into a single-entry log, unless the
+// label has no taxonomy code; then it stays a legacy free-text log so older
+// clients don't lose entries.
+func (l *EmotionLog) NormalizeEmotions() {
+	if len(l.Emotions) == 0 && l.Emotion != "" {
+		code, ok := NormalizeEmotionCode(l.Emotion)
+		if !ok {
+			l.Emotion = strings.TrimSpace(l.Emotion)
+			l.Emotions = []EmotionEntry{}
+			l.TaxonomyVersion = nil
+			return
+		}
+		l.Emotions = []EmotionEntry{{Code: code}}
+	}
+
+	for i := range l.Emotions {
+		if code, ok := NormalizeEmotionCode(l.Emotions[i].Code); ok {
+			l.Emotions[i].Code = code
+		}
+		if l.Emotions[i].Intensity == 0 {
+			l.Emotions[i].Intensity = DefaultEmotionIntensity
+		}
+	}
+
+	if len(l.Emotions) > 0 {
+		l.Emotion = l.Emotions[0].Code
+	}
+
+	version := EmotionTaxonomyVersion
+	l.TaxonomyVersion = &version
+}
+
+// IsLegacy reports whether the log holds a free-text emotion outside the taxonomy.
+func (l *EmotionLog) IsLegacy() bool {
+	return l.TaxonomyVersion == nil
+}
+
+func ValidateEmotionLog(v *validator.Validator, l *EmotionLog) {
+	if l.IsLegacy() {
+		v.Check(l.Emotion != "", "emotion", "must be provided")
+		v.Check(len(l.Emotion) <= 50, "emotion", "must not be more than 50 characters")
+	} else {
+		v.Check(len(l.Emotions) > 0, "emotions", "must contain at least one emotion")
+		v.Check(len(l.Emotions) <= MaxEmotionsPerLog, "emotions", fmt.Sprintf("must not contain more than %d emotions", MaxEmotionsPerLog))
+
+		codes := make([]string, 0, len(l.Emotions))
+		for _, entry := range l.Emotions {
+			v.Check(IsValidEmotionCode(entry.Code), "emotions", fmt.Sprintf("%q is not a known emotion code", entry.Code))
+			v.Check(entry.Intensity >= MinEmotionIntensity && entry.Intensity <= MaxEmotionIntensity,
+				"emotions", fmt.Sprintf("intensity must be between %d and %d", MinEmotionIntensity, MaxEmotionIntensity))
+			codes = append(codes, entry.Code)
+		}
+		v.Check(validator.Unique(codes), "emotions", "must not contain duplicate emotions")
+	}
+
+	ValidateEmotionLogNotes(v, l)
+}
+
+// ValidateEmotionLogNotes checks the source and context of a log, for edits that
+// leave its emotions untouched.
+func ValidateEmotionLogNotes(v *validator.Validator, l *EmotionLog) {
+	v.Check(len(l.Source) <= 50, "source", "must not be more than 50 characters")
+	v.Check(len(l.Context) <= 2000, "context", "must not be more than 2000 characters")
 }
 
 type EmotionLogModel struct {
 	DB *sql.DB
 }
 
-func (emo EmotionLogModel) GetList(userId uuid.UUID, filter *QueryFilter) ([]*EmotionLog, Metadata, error) {
-	query := `
-				SELECT COUNT(*) OVER(), id, emotion, source, context, created_at FROM emotion_logs 
-				WHERE user_id = $1
-				AND created_at BETWEEN $2 and $3
-				ORDER BY created_at
-			`
+// GetList returns a page of the user's emotion logs. Besides the filter's search
+// (over context), time range and sort, logs can be narrowed to an emotion code —
+// which also matches its more specific codes — and to a source.
+func (emo EmotionLogModel) GetList(userID uuid.UUID, filter *QueryFilter, emotion, source string) ([]*EmotionLog, Metadata, error) {
+	var queryBuilder strings.Builder
+	var args []interface{}
+	paramIndex := 1
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	queryBuilder.WriteString(`
+		SELECT COUNT(*) OVER(), id, user_id, COALESCE(emotion, ''), emotions, taxonomy_version,
+		       COALESCE(source, ''), COALESCE(context, ''), created_at
+		FROM emotion_logs
+		WHERE user_id = $1
+	`)
+	args = append(args, userID)
+	paramIndex++
 
-	defer cancel()
+	if emotion != "" {
+		queryBuilder.WriteString(fmt.Sprintf(` AND (
+			LOWER(emotion) = $%[1]d OR EXISTS (
+				SELECT 1 FROM jsonb_array_elements(emotions) e
+				WHERE e->>'code' = $%[1]d OR e->>'code' LIKE $%[1]d || '.%%'
+			))`, paramIndex))
+		args = append(args, emotion)
+		paramIndex++
+	}
 
-	totalRecords := 0
-	emotionLogs := []*EmotionLog{}
+	if source != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND source = $%d", paramIndex))
+		args = append(args, source)
+		paramIndex++
+	}
 
-	rows, err := emo.DB.QueryContext(ctx, query, userId, filter.StartTime, filter.EndTime)
+	if filter.HasSearch() {
+		searchSQL, searchArgs := filter.SearchConditionSQL(paramIndex)
+		if searchSQL != "" {
+			queryBuilder.WriteString(" AND ")
+			queryBuilder.WriteString(searchSQL)
+			args = append(args, searchArgs...)
+			paramIndex++
+		}
+	}
 
+	if filter.HasTimeRange() {
+		timeSQL, timeArgs, nextIdx := filter.TimeRangeConditionSQL(paramIndex)
+		if timeSQL != "" {
+			queryBuilder.WriteString(" AND ")
+			queryBuilder.WriteString(timeSQL)
+			args = append(args, timeArgs...)
+			paramIndex = nextIdx
+		}
+	}
+
+	if filter.SortClause() != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s, id", filter.SortClause()))
+	} else {
+		queryBuilder.WriteString(" ORDER BY created_at DESC, id")
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1))
+	args = append(args, filter.Limit(), filter.Offset())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := emo.DB.QueryContext(ctx, queryBuilder.String(), args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
+	defer rows.Close()
+
+	totalRecords := 0
+	emotionLogs := []*EmotionLog{}
+
 	for rows.Next() {
 		var emotionLog EmotionLog
+		var emotionsRaw []byte
 		err = rows.Scan(
 			&totalRecords,
 			&emotionLog.ID,
+			&emotionLog.UserID,
 			&emotionLog.Emotion,
+			&emotionsRaw,
+			&emotionLog.TaxonomyVersion,
 			&emotionLog.Source,
 			&emotionLog.Context,
 			&emotionLog.CreatedAt,
@@ -56,6 +190,11 @@ func (emo EmotionLogModel) GetList(userId uuid.UUID, filter *QueryFilter) ([]*Em
 			return nil, Metadata{}, err
 		}
 
+		err = json.Unmarshal(emotionsRaw, &emotionLog.Emotions)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
 		emotionLogs = append(emotionLogs, &emotionLog)
 	}
 
@@ -68,29 +207,234 @@ func (emo EmotionLogModel) GetList(userId uuid.UUID, filter *QueryFilter) ([]*Em
 	return emotionLogs, metadata, nil
 }
 
-func (emo EmotionLogModel) Insert(emotionLog *EmotionLog) (*EmotionLog, error) {
+// Get retrieves a single emotion log by ID and user
+func (emo EmotionLogModel) Get(id, userID uuid.UUID) (*EmotionLog, error) {
 	query := `
-		INSERT INTO emotion_logs (user_id, emotion, source, context)
+		SELECT id, user_id, COALESCE(emotion, ''), emotions, taxonomy_version,
+		       COALESCE(source, ''), COALESCE(context, ''), created_at
+		FROM emotion_logs
+		WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var emotionLog EmotionLog
+	var emotionsRaw []byte
+	err := emo.DB.QueryRowContext(ctx, query, id, userID).Scan(
+		&emotionLog.ID,
+		&emotionLog.UserID,
+		&emotionLog.Emotion,
+		&emotionsRaw,
+		&emotionLog.TaxonomyVersion,
+		&emotionLog.Source,
+		&emotionLog.Context,
+		&emotionLog.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(emotionsRaw, &emotionLog.Emotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &emotionLog, nil
+}
+
+// Update replaces the emotions, source and context of an emotion log
+func (emo EmotionLogModel) Update(emotionLog *EmotionLog) error {
+	query := `
+		UPDATE emotion_logs
+		SET emotion = $1, emotions = $2, taxonomy_version = $3, source = $4, context = $5
+		WHERE id = $6 AND user_id = $7
+		RETURNING created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	emotionsJSON, err := json.Marshal(emotionLog.Emotions)
+	if err != nil {
+		return err
+	}
+
+	err = emo.DB.QueryRowContext(ctx, query,
+		emotionLog.Emotion,
+		emotionsJSON,
+		emotionLog.TaxonomyVersion,
+		emotionLog.Source,
+		emotionLog.Context,
+		emotionLog.ID,
+		emotionLog.UserID,
+	).Scan(&emotionLog.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes an emotion log
+func (emo EmotionLogModel) Delete(id, userID uuid.UUID) error {
+	query := `
+		DELETE FROM emotion_logs
+		WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := emo.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// emotionLogInsertQuery stores a new emotion log; insertArgs binds its placeholders.
+const emotionLogInsertQuery = `
+		INSERT INTO emotion_logs (user_id, emotion, emotions, taxonomy_version, source, context)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, emotion, source, context, created_at
+		RETURNING id, created_at
 `
 
+// insertArgs returns the values for emotionLogInsertQuery, one per column in order.
+func (emotionLog *EmotionLog) insertArgs() ([]any, error) {
+	emotionsJSON, err := json.Marshal(emotionLog.Emotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		emotionLog.UserID,
+		emotionLog.Emotion,
+		emotionsJSON,
+		emotionLog.TaxonomyVersion,
+		emotionLog.Source,
+		emotionLog.Context}, nil
+}
+
+func (emo EmotionLogModel) Insert(emotionLog *EmotionLog) (*EmotionLog, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 
 	defer cancel()
 
-	args := []any{emotionLog.UserID, emotionLog.Emotion, emotionLog.Source, emotionLog.Context}
+	args, err := emotionLog.insertArgs()
+	if err != nil {
+		return nil, err
+	}
 	argsResponse := []any{
 		&emotionLog.ID,
-		&emotionLog.Emotion,
-		&emotionLog.Source,
-		&emotionLog.Context,
 		&emotionLog.CreatedAt}
 
-	err := emo.DB.QueryRowContext(ctx, query, args...).Scan(argsResponse...)
+	err = emo.DB.QueryRowContext(ctx, emotionLogInsertQuery, args...).Scan(argsResponse...)
 
 	if err != nil {
 		return nil, err
 	}
 	return emotionLog, nil
 }
+
+// GetSince returns the user's emotion logs created since the given time, oldest first.
+func (emo EmotionLogModel) GetSince(userID uuid.UUID, since time.Time) ([]*EmotionLog, error) {
+	query := `
+		SELECT id, user_id, COALESCE(emotion, ''), emotions, taxonomy_version,
+		       COALESCE(source, ''), COALESCE(context, ''), created_at
+		FROM emotion_logs
+		WHERE user_id = $1 AND created_at >= $2
+		ORDER BY created_at ASC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := emo.DB.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emotionLogs := []*EmotionLog{}
+	for rows.Next() {
+		var emotionLog EmotionLog
+		var emotionsRaw []byte
+		err = rows.Scan(
+			&emotionLog.ID,
+			&emotionLog.UserID,
+			&emotionLog.Emotion,
+			&emotionsRaw,
+			&emotionLog.TaxonomyVersion,
+			&emotionLog.Source,
+			&emotionLog.Context,
+			&emotionLog.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(emotionsRaw, &emotionLog.Emotions)
+		if err != nil {
+			return nil, err
+		}
+
+		emotionLogs = append(emotionLogs, &emotionLog)
+	}
+
+	return emotionLogs, rows.Err()
+}
+
+// GetEmotionCountsSince returns how often each emotion was logged since the given time.
+// Every emotion of a multi-emotion log counts; legacy logs count their free-text
+// emotion, lower-cased so that "Anxious" and "anxious" count together.
+func (emo EmotionLogModel) GetEmotionCountsSince(userID uuid.UUID, since time.Time) (map[string]int, error) {
+	query := `
+		SELECT LOWER(COALESCE(e.entry->>'code', l.emotion)) AS emotion, COUNT(*)
+		FROM emotion_logs l
+		LEFT JOIN LATERAL jsonb_array_elements(l.emotions) AS e(entry) ON true
+		WHERE l.user_id = $1 AND l.created_at >= $2
+		  AND COALESCE(e.entry->>'code', l.emotion) IS NOT NULL
+		GROUP BY 1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := emo.DB.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var emotion string
+		var count int
+		err = rows.Scan(&emotion, &count)
+		if err != nil {
+			return nil, err
+		}
+		counts[emotion] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// The create path once bound 4 arguments to 6 placeholders; every column, placeholder
+// and argument of the insert must line up.
+func TestEmotionLogInsertArgs(t *testing.T) {
+	version := EmotionTaxonomyVersion
+	userID := uuid.New()
+
+	logs := map[string]*EmotionLog{
+		"taxonomy log": {
+			UserID:          userID,
+			Emotion:         "fear.anxious",
+			Emotions:        []EmotionEntry{{Code: "fear.anxious", Intensity: 7}},
+			TaxonomyVersion: &version,
+			Source:          "check_in",
+			Context:         "before an exam",
+		},
+		"legacy log": {
+			UserID:   userID,
+			Emotion:  "storm",
+			Emotions: []EmotionEntry{},
+		},
+	}
+
+	columnsMatch := regexp.MustCompile(`(?s)INSERT INTO emotion_logs \((.*?)\)`).FindStringSubmatch(emotionLogInsertQuery)
+	if columnsMatch == nil {
+		t.Fatal("no column list in the insert query")
+	}
+	columns := strings.Split(columnsMatch[1], ",")
+
+	placeholders := map[int]bool{}
+	highest := 0
+	for _, m := range regexp.MustCompile(`\$(\d+)`).FindAllStringSubmatch(emotionLogInsertQuery, -1) {
+		n, _ := strconv.Atoi(m[1])
+		placeholders[n] = true
+		highest = max(highest, n)
+	}
+	if len(placeholders) != highest {
+		t.Fatalf("placeholders are not numbered $1..$%d: %v", highest, placeholders)
+	}
+	if len(columns) != highest {
+		t.Fatalf("%d columns but %d placeholders", len(columns), highest)
+	}
+
+	for name, log := range logs {
+		t.Run(name, func(t *testing.T) {
+			args, err := log.insertArgs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(args) != highest {
+				t.Fatalf("got %d arguments for %d placeholders", len(args), highest)
+			}
+
+			byColumn := map[string]any{}
+			for i, column := range columns {
+				byColumn[strings.TrimSpace(column)] = args[i]
+			}
+			if byColumn["user_id"] != log.UserID || byColumn["emotion"] != log.Emotion ||
+				byColumn["taxonomy_version"] != log.TaxonomyVersion ||
+				byColumn["source"] != log.Source || byColumn["context"] != log.Context {
+				t.Errorf("arguments do not line up with the columns: %v", byColumn)
+			}
+
+			emotions, ok := byColumn["emotions"].([]byte)
+			if !ok || !strings.HasPrefix(string(emotions), "[") {
+				t.Errorf("emotions bound as %v, want a JSON array", byColumn["emotions"])
+			}
+		})
+	}
+}
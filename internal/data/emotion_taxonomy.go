@@ -0,0 +1,299 @@
+package data
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// EmotionTaxonomyVersion is stored on every emotion log so codes can be migrated
+// if the wheel below changes. Bump it whenever a code is renamed or removed;
+// adding new codes does not need a new version.
+const EmotionTaxonomyVersion = 1
+
+// Emotion intensity bounds and per-log limits
+const (
+	MinEmotionIntensity     = 1
+	MaxEmotionIntensity     = 10
+	DefaultEmotionIntensity = 5
+	MaxEmotionsPerLog       = 5
+)
+
+// Emotion wheel levels
+const (
+	EmotionLevelCore      = 1
+	EmotionLevelSecondary = 2
+	EmotionLevelTertiary  = 3
+)
+
+// EmotionNode is one emotion of the taxonomy wheel. Codes are dot-separated paths
+// from the core emotion, e.g. "fear.anxious.worried".
+type EmotionNode struct {
+	Code     string        `json:"code"`
+	Level    int           `json:"level"`
+	Label    string        `json:"label"`
+	LabelVi  string        `json:"label_vi"`
+	Children []EmotionNode `json:"children,omitempty"`
+}
+
+// EmotionEntry is one emotion felt in a log, with how strongly it was felt.
+type EmotionEntry struct {
+	Code      string `json:"code"`
+	Intensity int    `json:"intensity"`
+}
+
+// emotionWheel holds the taxonomy with codes relative to their parent;
+// init expands them into full paths.
+var emotionWheel = []EmotionNode{
+	emotion("joy", "Happy", "Vui vẻ",
+		emotion("content", "Content", "Hài lòng",
+			emotion("calm", "Calm", "Bình yên"),
+			emotion("relieved", "Relieved", "Nhẹ nhõm"),
+		),
+		emotion("proud", "Proud", "Tự hào",
+			emotion("confident", "Confident", "Tự tin"),
+			emotion("accomplished", "Accomplished", "Có thành tựu"),
+		),
+		emotion("grateful", "Grateful", "Biết ơn",
+			emotion("thankful", "Thankful", "Cảm kích"),
+			emotion("touched", "Touched", "Cảm động"),
+		),
+		emotion("hopeful", "Hopeful", "Hy vọng",
+			emotion("optimistic", "Optimistic", "Lạc quan"),
+			emotion("inspired", "Inspired", "Được truyền cảm hứng"),
+		),
+		emotion("loving", "Loving", "Yêu thương",
+			emotion("connected", "Connected", "Gắn kết"),
+			emotion("caring", "Caring", "Quan tâm"),
+		),
+		emotion("playful", "Playful", "Tinh nghịch",
+			emotion("excited", "Excited", "Hào hứng"),
+			emotion("cheerful", "Cheerful", "Vui tươi"),
+		),
+	),
+	emotion("sadness", "Sad", "Buồn",
+		emotion("lonely", "Lonely", "Cô đơn",
+			emotion("isolated", "Isolated", "Bị cô lập"),
+			emotion("abandoned", "Abandoned", "Bị bỏ rơi"),
+		),
+		emotion("hurt", "Hurt", "Tổn thương",
+			emotion("disappointed", "Disappointed", "Thất vọng"),
+			emotion("rejected", "Rejected", "Bị từ chối"),
+		),
+		emotion("guilty", "Guilty", "Tội lỗi",
+			emotion("ashamed", "Ashamed", "Xấu hổ"),
+			emotion("remorseful", "Remorseful", "Hối hận"),
+		),
+		emotion("depressed", "Depressed", "Chán nản",
+			emotion("empty", "Empty", "Trống rỗng"),
+			emotion("hopeless", "Hopeless", "Tuyệt vọng"),
+		),
+		emotion("grief", "Grieving", "Đau buồn",
+			emotion("heartbroken", "Heartbroken", "Đau lòng"),
+			emotion("nostalgic", "Nostalgic", "Hoài niệm"),
+		),
+	),
+	emotion("fear", "Fearful", "Sợ hãi",
+		emotion("anxious", "Anxious", "Lo âu",
+			emotion("worried", "Worried", "Lo lắng"),
+			emotion("overwhelmed", "Overwhelmed", "Quá tải"),
+		),
+		emotion("scared", "Scared", "Hoảng sợ",
+			emotion("frightened", "Frightened", "Khiếp sợ"),
+			emotion("panicked", "Panicked", "Hoảng loạn"),
+		),
+		emotion("insecure", "Insecure", "Bất an",
+			emotion("inadequate", "Inadequate", "Thấy mình kém cỏi"),
+			emotion("vulnerable", "Vulnerable", "Dễ tổn thương"),
+		),
+		emotion("stressed", "Stressed", "Căng thẳng",
+			emotion("pressured", "Pressured", "Bị áp lực"),
+			emotion("nervous", "Nervous", "Bồn chồn"),
+		),
+	),
+	emotion("anger", "Angry", "Tức giận",
+		emotion("frustrated", "Frustrated", "Bực bội",
+			emotion("irritated", "Irritated", "Cáu kỉnh"),
+			emotion("annoyed", "Annoyed", "Khó chịu"),
+		),
+		emotion("resentful", "Resentful", "Oán giận",
+			emotion("bitter", "Bitter", "Cay đắng"),
+			emotion("jealous", "Jealous", "Ghen tị"),
+		),
+		emotion("furious", "Furious", "Giận dữ",
+			emotion("enraged", "Enraged", "Phẫn nộ"),
+			emotion("hostile", "Hostile", "Thù địch"),
+		),
+		emotion("critical", "Critical", "Chỉ trích",
+			emotion("dismissive", "Dismissive", "Coi thường"),
+			emotion("skeptical", "Skeptical", "Hoài nghi"),
+		),
+	),
+	emotion("surprise", "Surprised", "Ngạc nhiên",
+		emotion("amazed", "Amazed", "Kinh ngạc",
+			emotion("astonished", "Astonished", "Sửng sốt"),
+			emotion("awed", "Awed", "Thán phục"),
+		),
+		emotion("confused", "Confused", "Bối rối",
+			emotion("perplexed", "Perplexed", "Hoang mang"),
+			emotion("unsure", "Unsure", "Không chắc chắn"),
+		),
+		emotion("startled", "Startled", "Giật mình",
+			emotion("shocked", "Shocked", "Bị sốc"),
+			emotion("dismayed", "Dismayed", "Choáng váng"),
+		),
+	),
+	emotion("disgust", "Disgusted", "Chán ghét",
+		emotion("disapproving", "Disapproving", "Phản đối",
+			emotion("judgmental", "Judgmental", "Phán xét"),
+			emotion("offended", "Offended", "Bị xúc phạm"),
+		),
+		emotion("awful", "Awful", "Kinh khủng",
+			emotion("nauseated", "Nauseated", "Buồn nôn"),
+			emotion("revolted", "Revolted", "Ghê tởm"),
+		),
+		emotion("avoidant", "Avoidant", "Né tránh",
+			emotion("withdrawn", "Withdrawn", "Thu mình"),
+			emotion("hesitant", "Hesitant", "Do dự"),
+		),
+	),
+	emotion("tired", "Tired", "Mệt mỏi",
+		emotion("exhausted", "Exhausted", "Kiệt sức",
+			emotion("drained", "Drained", "Cạn năng lượng"),
+			emotion("sleepy", "Sleepy", "Buồn ngủ"),
+		),
+		emotion("bored", "Bored", "Chán",
+			emotion("apathetic", "Apathetic", "Thờ ơ"),
+			emotion("indifferent", "Indifferent", "Dửng dưng"),
+		),
+		emotion("busy", "Busy", "Bận rộn",
+			emotion("rushed", "Rushed", "Vội vã"),
+			emotion("scattered", "Scattered", "Phân tán"),
+		),
+	),
+}
+
+// emotionsByCode indexes every node of the wheel by its full code.
+// emotionsByLabel maps lower-cased EN/VI labels to codes for legacy free-text emotions.
+var (
+	emotionsByCode  = map[string]EmotionNode{}
+	emotionsByLabel = map[string]string{}
+)
+
+func init() {
+	emotionWheel = expandEmotionCodes(emotionWheel, "", EmotionLevelCore)
+	indexEmotions(emotionWheel)
+}
+
+func emotion(code, label, labelVi string, children ...EmotionNode) EmotionNode {
+	return EmotionNode{Code: code, Label: label, LabelVi: labelVi, Children: children}
+}
+
+func expandEmotionCodes(nodes []EmotionNode, parent string, level int) []EmotionNode {
+	for i := range nodes {
+		if parent != "" {
+			nodes[i].Code = parent + "." + nodes[i].Code
+		}
+		nodes[i].Level = level
+		nodes[i].Children = expandEmotionCodes(nodes[i].Children, nodes[i].Code, level+1)
+	}
+	return nodes
+}
+
+func indexEmotions(nodes []EmotionNode) {
+	for _, node := range nodes {
+		emotionsByCode[node.Code] = node
+
+		// Broader emotions win when labels repeat across levels
+		for _, label := range []string{node.Label, node.LabelVi} {
+			key := strings.ToLower(label)
+			if _, exists := emotionsByLabel[key]; !exists {
+				emotionsByLabel[key] = node.Code
+			}
+		}
+	}
+	for _, node := range nodes {
+		indexEmotions(node.Children)
+	}
+}
+
+// EmotionTaxonomy returns the wheel with labels in the requested locale.
+func EmotionTaxonomy(locale string) []EmotionNode {
+	return localizeEmotions(emotionWheel, locale)
+}
+
+func localizeEmotions(nodes []EmotionNode, locale string) []EmotionNode {
+	localized := make([]EmotionNode, len(nodes))
+	for i, node := range nodes {
+		localized[i] = node
+		if locale == "vi" && node.LabelVi != "" {
+			localized[i].Label = node.LabelVi
+		}
+		localized[i].Children = localizeEmotions(node.Children, locale)
+	}
+	return localized
+}
+
+// LookupEmotion returns the taxonomy node for a code.
+func LookupEmotion(code string) (EmotionNode, bool) {
+	node, ok := emotionsByCode[code]
+	return node, ok
+}
+
+// IsValidEmotionCode reports whether code belongs to the current taxonomy.
+func IsValidEmotionCode(code string) bool {
+	_, ok := emotionsByCode[code]
+	return ok
+}
+
+// CoreEmotion returns the core emotion of a code, e.g. "fear" for "fear.anxious.worried".
+func CoreEmotion(code string) string {
+	core, _, _ := strings.Cut(code, ".")
+	return core
+}
+
+// NormalizeEmotionCode maps a code or a legacy free-text label ("Anxious", "lo âu",
+// "worried") to a taxonomy code.
+func NormalizeEmotionCode(value string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(value))
+	if key == "" {
+		return "", false
+	}
+
+	if IsValidEmotionCode(key) {
+		return key, true
+	}
+
+	code, ok := emotionsByLabel[key]
+	return code, ok
+}
+
+// EmotionCodesIn returns the taxonomy codes an emotion stands for: its own code when
+// it is a code or a known label, otherwise the code of each word that is one
+// ("feeling anxious" → "fear.anxious"). Other free-text labels stand for nothing.
+func EmotionCodesIn(value string) []string {
+	if code, ok := NormalizeEmotionCode(value); ok {
+		return []string{code}
+	}
+
+	var codes []string
+	words := strings.FieldsFunc(value, func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		code, ok := NormalizeEmotionCode(word)
+		if ok && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// InEmotionBranch reports whether code is one of branches or lies below one of them,
+// comparing whole path segments ("fear.anxious" is in "fear", "fearless" is not).
+func InEmotionBranch(code string, branches []string) bool {
+	for _, branch := range branches {
+		if code == branch || strings.HasPrefix(code, branch+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"tranquara.net/internal/validator"
+)
+
+// walkEmotions calls fn for every node of the taxonomy.
+func walkEmotions(nodes []EmotionNode, fn func(EmotionNode)) {
+	for _, node := range nodes {
+		fn(node)
+		walkEmotions(node.Children, fn)
+	}
+}
+
+func TestEmotionTaxonomy(t *testing.T) {
+	count := 0
+	walkEmotions(EmotionTaxonomy("en"), func(node EmotionNode) {
+		count++
+
+		if got := strings.Count(node.Code, ".") + 1; got != node.Level {
+			t.Errorf("%q: level %d does not match its depth %d", node.Code, node.Level, got)
+		}
+		if node.Label == "" || node.LabelVi == "" {
+			t.Errorf("%q: missing a label", node.Code)
+		}
+		for _, child := range node.Children {
+			if !strings.HasPrefix(child.Code, node.Code+".") {
+				t.Errorf("%q: child %q is not below its parent", node.Code, child.Code)
+			}
+		}
+		if CoreEmotion(node.Code) != strings.Split(node.Code, ".")[0] {
+			t.Errorf("%q: got core emotion %q", node.Code, CoreEmotion(node.Code))
+		}
+	})
+
+	if count != len(emotionsByCode) {
+		t.Errorf("got %d nodes in the taxonomy, %d indexed", count, len(emotionsByCode))
+	}
+
+	vi := EmotionTaxonomy("vi")
+	if vi[0].Label != vi[0].LabelVi {
+		t.Errorf("got vi label %q, want %q", vi[0].Label, vi[0].LabelVi)
+	}
+	if emotionWheel[0].Label == emotionWheel[0].LabelVi {
+		t.Error("localizing the taxonomy changed the shared wheel")
+	}
+}
+
+func TestNormalizeEmotionCode(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   string
+		wantOK bool
+	}{
+		{"fear.anxious.worried", "fear.anxious.worried", true},
+		{"  Fear.Anxious ", "fear.anxious", true},
+		{"Anxious", "fear.anxious", true},
+		{"worried", "fear.anxious.worried", true},
+		{"lo âu", "fear.anxious", true},
+		{"Lo Âu", "fear.anxious", true},
+		{"Happy", "joy", true},
+		{"Stressed", "fear.stressed", true},
+		{"Storm", "", false},
+		{"Partly Cloudy", "", false},
+		{"Slightly Tense", "", false},
+		{"Breaking Point", "", false},
+		{"fear.unknown", "", false},
+		{"", "", false},
+		{"   ", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeEmotionCode(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("NormalizeEmotionCode(%q) = %q, %v; want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// Every code and label of the taxonomy resolves to a code of the taxonomy
+	walkEmotions(EmotionTaxonomy("en"), func(node EmotionNode) {
+		if got, ok := NormalizeEmotionCode(node.Code); !ok || got != node.Code {
+			t.Errorf("code %q normalized to %q, %v", node.Code, got, ok)
+		}
+		for _, label := range []string{node.Label, node.LabelVi} {
+			if got, ok := NormalizeEmotionCode(label); !ok || !IsValidEmotionCode(got) {
+				t.Errorf("label %q normalized to %q, %v", label, got, ok)
+			}
+		}
+	})
+}
+
+func TestEmotionCodesIn(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"fear.anxious", []string{"fear.anxious"}},
+		{"Anxious", []string{"fear.anxious"}},
+		{"feeling anxious and lonely", []string{"fear.anxious", "sadness.lonely"}},
+		{"anxious, anxious", []string{"fear.anxious"}},
+		{"discontent", nil},
+		{"Storm", nil},
+	}
+
+	for _, tt := range tests {
+		got := EmotionCodesIn(tt.value)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("EmotionCodesIn(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestInEmotionBranch(t *testing.T) {
+	tests := []struct {
+		code     string
+		branches []string
+		want     bool
+	}{
+		{"fear", []string{"fear"}, true},
+		{"fear.anxious.worried", []string{"fear"}, true},
+		{"fear.anxious.worried", []string{"fear.anxious"}, true},
+		{"fear.scared", []string{"fear.anxious"}, false},
+		{"fearless", []string{"fear"}, false},
+		{"joy.content", []string{"sadness", "joy"}, true},
+		{"joy", []string{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := InEmotionBranch(tt.code, tt.branches); got != tt.want {
+			t.Errorf("InEmotionBranch(%q, %v) = %v, want %v", tt.code, tt.branches, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEmotions(t *testing.T) {
+	tests := []struct {
+		name        string
+		log         EmotionLog
+		wantEmotion string
+		wantEntries []EmotionEntry
+		wantLegacy  bool
+	}{
+		{
+			name:        "legacy label with a code",
+			log:         EmotionLog{Emotion: "Anxious"},
+			wantEmotion: "fear.anxious",
+			wantEntries: []EmotionEntry{{Code: "fear.anxious", Intensity: DefaultEmotionIntensity}},
+		},
+		{
+			name:        "legacy label without a code",
+			log:         EmotionLog{Emotion: " Partly Cloudy "},
+			wantEmotion: "Partly Cloudy",
+			wantEntries: []EmotionEntry{},
+			wantLegacy:  true,
+		},
+		{
+			name: "entries are normalized and the first is primary",
+			log: EmotionLog{Emotion: "ignored", Emotions: []EmotionEntry{
+				{Code: "Worried", Intensity: 8},
+				{Code: "joy.content"},
+			}},
+			wantEmotion: "fear.anxious.worried",
+			wantEntries: []EmotionEntry{
+				{Code: "fear.anxious.worried", Intensity: 8},
+				{Code: "joy.content", Intensity: DefaultEmotionIntensity},
+			},
+		},
+		{
+			name:        "unknown entries are kept for validation to reject",
+			log:         EmotionLog{Emotions: []EmotionEntry{{Code: "storm", Intensity: 3}}},
+			wantEmotion: "storm",
+			wantEntries: []EmotionEntry{{Code: "storm", Intensity: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := tt.log
+			l.NormalizeEmotions()
+
+			if l.Emotion != tt.wantEmotion {
+				t.Errorf("got emotion %q, want %q", l.Emotion, tt.wantEmotion)
+			}
+			if len(l.Emotions) != len(tt.wantEntries) || l.Emotions == nil {
+				t.Fatalf("got entries %v, want %v", l.Emotions, tt.wantEntries)
+			}
+			for i := range tt.wantEntries {
+				if l.Emotions[i] != tt.wantEntries[i] {
+					t.Errorf("entry %d: got %v, want %v", i, l.Emotions[i], tt.wantEntries[i])
+				}
+			}
+			if l.IsLegacy() != tt.wantLegacy {
+				t.Errorf("got legacy %v, want %v", l.IsLegacy(), tt.wantLegacy)
+			}
+			if !tt.wantLegacy && *l.TaxonomyVersion != EmotionTaxonomyVersion {
+				t.Errorf("got taxonomy version %d, want %d", *l.TaxonomyVersion, EmotionTaxonomyVersion)
+			}
+		})
+	}
+}
+
+func TestValidateEmotionLog(t *testing.T) {
+	entries := func(codes ...string) []EmotionEntry {
+		e := make([]EmotionEntry, len(codes))
+		for i, code := range codes {
+			e[i] = EmotionEntry{Code: code, Intensity: DefaultEmotionIntensity}
+		}
+		return e
+	}
+
+	tests := []struct {
+		name      string
+		log       EmotionLog
+		wantField string
+	}{
+		{name: "one emotion", log: EmotionLog{Emotion: "Sad"}},
+		{name: "several emotions", log: EmotionLog{Emotions: entries("joy", "fear.anxious.worried", "tired.busy.rushed")}},
+		{name: "legacy free text", log: EmotionLog{Emotion: "Breaking Point"}},
+		{name: "no emotion", log: EmotionLog{}, wantField: "emotions"},
+		{name: "unknown code", log: EmotionLog{Emotions: entries("joy", "storm")}, wantField: "emotions"},
+		{name: "duplicate codes", log: EmotionLog{Emotions: entries("joy", "Happy")}, wantField: "emotions"},
+		{name: "too many emotions", log: EmotionLog{Emotions: entries("joy", "sadness", "fear", "anger", "surprise", "disgust")}, wantField: "emotions"},
+		{name: "intensity too high", log: EmotionLog{Emotions: []EmotionEntry{{Code: "joy", Intensity: MaxEmotionIntensity + 1}}}, wantField: "emotions"},
+		{name: "intensity too low", log: EmotionLog{Emotions: []EmotionEntry{{Code: "joy", Intensity: -1}}}, wantField: "emotions"},
+		{name: "legacy text too long", log: EmotionLog{Emotion: strings.Repeat("x", 51)}, wantField: "emotion"},
+		{name: "context too long", log: EmotionLog{Emotion: "joy", Context: strings.Repeat("x", 2001)}, wantField: "context"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := tt.log
+			l.NormalizeEmotions()
+
+			v := validator.New()
+			ValidateEmotionLog(v, &l)
+
+			if tt.wantField == "" {
+				if !v.Valid() {
+					t.Errorf("got errors %v, want none", v.Errors)
+				}
+				return
+			}
+			if _, ok := v.Errors[tt.wantField]; !ok {
+				t.Errorf("got errors %v, want one for %q", v.Errors, tt.wantField)
+			}
+		})
+	}
+
+	// Every code of the taxonomy is accepted on its own
+	walkEmotions(EmotionTaxonomy("en"), func(node EmotionNode) {
+		l := EmotionLog{Emotions: entries(node.Code)}
+		l.NormalizeEmotions()
+
+		v := validator.New()
+		ValidateEmotionLog(v, &l)
+		if !v.Valid() {
+			t.Errorf("%q: got errors %v", node.Code, v.Errors)
+		}
+	})
+}
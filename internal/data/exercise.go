@@ -5,137 +5,292 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"tranquara.net/internal/validator"
+)
+
+// Exercise difficulties
+const (
+	ExerciseDifficultyBeginner     = "beginner"
+	ExerciseDifficultyIntermediate = "intermediate"
+	ExerciseDifficultyAdvanced     = "advanced"
+)
+
+const (
+	// MaxExerciseDuration caps the estimated duration of an exercise, in minutes.
+	MaxExerciseDuration = 240
+	// MaxExerciseTags caps the number of tags on an exercise.
+	MaxExerciseTags = 20
+)
+
+var (
+	// ErrExerciseInUse is returned when deleting an exercise users have sessions or completions for.
+	ErrExerciseInUse = errors.New("exercise has recorded sessions or completions")
 )
 
 type Exercise struct {
-	ExerciseID   int64  `json:"exercise_id"`
-	Title        string `json:"title"`
-	Description  string `json:"description"`
-	MediaLink    string `json:"media_link"`
-	ExerciseType string `json:"exercise_type"`
+	ExerciseID    uuid.UUID `json:"exercise_id"`
+	Title         string    `json:"title"`
+	TitleVi       *string   `json:"title_vi,omitempty"`
+	Description   string    `json:"description"`
+	DescriptionVi *string   `json:"description_vi,omitempty"`
+	MediaLink     string    `json:"media_link"`
+	// MediaAssetID links the exercise to a registered audio or video asset.
+	MediaAssetID    *uuid.UUID `json:"media_asset_id"`
+	ExerciseType    string     `json:"exercise_type"`
+	Category        string     `json:"category"`
+	Tags            []string   `json:"tags"`
+	Difficulty      string     `json:"difficulty"`
+	DurationMinutes *int       `json:"duration_minutes"`
+	Steps           []string   `json:"steps"`
+	StepsVi         []string   `json:"steps_vi,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ExerciseCriteria narrows an exercise listing. Empty fields match everything.
+type ExerciseCriteria struct {
+	// Search is matched against the English and Vietnamese title and description, and the tags.
+	Search       string
+	ExerciseType string
+	Category     string
+	Difficulty   string
+	Tags         []string
+	// MaxDuration keeps exercises estimated to take at most this many minutes (0 = no limit).
+	MaxDuration int
+}
+
+func ValidateExercise(v *validator.Validator, e *Exercise) {
+	v.Check(e.Title != "", "title", "must be provided")
+	v.Check(len(e.Title) <= 255, "title", "must not be more than 255 bytes long")
+	if e.TitleVi != nil {
+		v.Check(len(*e.TitleVi) <= 255, "title_vi", "must not be more than 255 bytes long")
+	}
+	v.Check(len(e.ExerciseType) <= 100, "exercise_type", "must not be more than 100 bytes long")
+	v.Check(len(e.Category) <= 100, "category", "must not be more than 100 bytes long")
+	v.Check(validator.In(e.Difficulty, ExerciseDifficultyBeginner, ExerciseDifficultyIntermediate, ExerciseDifficultyAdvanced),
+		"difficulty", "must be one of beginner, intermediate, advanced")
+	if e.DurationMinutes != nil {
+		v.Check(*e.DurationMinutes >= 1 && *e.DurationMinutes <= MaxExerciseDuration, "duration_minutes", "must be between 1 and 240")
+	}
+	v.Check(len(e.Tags) <= MaxExerciseTags, "tags", "must not contain more than 20 tags")
+	v.Check(validator.Unique(e.Tags), "tags", "must not contain duplicate values")
+	for _, tag := range e.Tags {
+		v.Check(tag != "" && len(tag) <= 50, "tags", "must be between 1 and 50 bytes long")
+	}
+	for _, step := range e.Steps {
+		v.Check(strings.TrimSpace(step) != "", "steps", "must not contain empty steps")
+	}
+	for _, step := range e.StepsVi {
+		v.Check(strings.TrimSpace(step) != "", "steps_vi", "must not contain empty steps")
+	}
+}
+
+// ApplyLocale swaps in the Vietnamese title, description and steps when available.
+func (e *Exercise) ApplyLocale(locale string) {
+	if locale != "vi" {
+		return
+	}
+	if e.TitleVi != nil && *e.TitleVi != "" {
+		e.Title = *e.TitleVi
+	}
+	if e.DescriptionVi != nil && *e.DescriptionVi != "" {
+		e.Description = *e.DescriptionVi
+	}
+	if len(e.StepsVi) > 0 {
+		e.Steps = e.StepsVi
+	}
 }
 
 type ExerciseModel struct {
 	DB *sql.DB
 }
 
-func (e ExerciseModel) Insert(exercise *Exercise) error {
+const exerciseColumns = `exercise_id, title, title_vi, COALESCE(description, ''), description_vi,
+	COALESCE(media_link, ''), COALESCE(exercise_type, ''), COALESCE(category, ''), tags, difficulty,
+	duration_minutes, steps, steps_vi, media_asset_id, created_at, updated_at`
+
+func scanExercise(row interface{ Scan(...any) error }, prefix ...any) (*Exercise, error) {
+	var e Exercise
+	dest := append(prefix,
+		&e.ExerciseID,
+		&e.Title,
+		&e.TitleVi,
+		&e.Description,
+		&e.DescriptionVi,
+		&e.MediaLink,
+		&e.ExerciseType,
+		&e.Category,
+		pq.Array(&e.Tags),
+		&e.Difficulty,
+		&e.DurationMinutes,
+		pq.Array(&e.Steps),
+		pq.Array(&e.StepsVi),
+		&e.MediaAssetID,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (e ExerciseModel) Insert(exercise *Exercise) (*Exercise, error) {
 	query := `
-		INSERT INTO exercises (title, description, media_link, exercise_type)
-		VALUES ($1, $2, $3, $4)
-		RETURNING *
-	`
+		INSERT INTO exercises (title, title_vi, description, description_vi, media_link, exercise_type,
+		                       category, tags, difficulty, duration_minutes, steps, steps_vi, media_asset_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13)
+		RETURNING ` + exerciseColumns
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-
 	defer cancel()
 
-	args := []any{exercise.Title, exercise.Description, exercise.MediaLink, exercise.ExerciseType}
-	argsResponse := []any{&exercise.ExerciseID, &exercise.Title, &exercise.Description, &exercise.MediaLink, &exercise.ExerciseType}
-
-	return e.DB.QueryRowContext(ctx, query, args...).Scan(argsResponse...)
+	created, err := scanExercise(e.DB.QueryRowContext(ctx, query, exercise.args()...))
+	if err != nil && strings.Contains(err.Error(), "fk_exercises_media_asset") {
+		return nil, ErrUnknownMediaAsset
+	}
+	return created, err
 }
 
-func (e ExerciseModel) Get(id int64) (*Exercise, error) {
-	query := `
-				SELECT  exercise_id, title, description, media_link, exercise_type  FROM exercises 
-				WHERE exercise_id = $1
-			`
+func (e ExerciseModel) Get(id uuid.UUID) (*Exercise, error) {
+	query := `SELECT ` + exerciseColumns + ` FROM exercises WHERE exercise_id = $1`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-
 	defer cancel()
 
-	var exercise Exercise
+	exercise, err := scanExercise(e.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return exercise, err
+}
 
-	err := e.DB.QueryRowContext(ctx, query, id).Scan(
-		&exercise.ExerciseID,
-		&exercise.Title,
-		&exercise.Description,
-		&exercise.MediaLink,
-		&exercise.ExerciseType,
-	)
+// GetList retrieves exercises matching the criteria.
+// Supports:
+//   - Full-text search on title, description (both languages) and tags
+//   - Exercise type, category, difficulty, tag and duration filtering
+//   - Pagination and sorting; search results are ordered by relevance first
+func (e ExerciseModel) GetList(criteria ExerciseCriteria, filter *QueryFilter) ([]*Exercise, Metadata, error) {
+	var queryBuilder strings.Builder
+	var args []interface{}
+	paramIndex := 1
+
+	queryBuilder.WriteString(`SELECT COUNT(*) OVER(), ` + exerciseColumns + ` FROM exercises WHERE TRUE`)
+
+	search := strings.TrimSpace(criteria.Search)
+	searchIndex := 0
+	if search != "" {
+		searchIndex = paramIndex
+		queryBuilder.WriteString(fmt.Sprintf(
+			" AND (search_vector @@ plainto_tsquery('simple', $%d) OR LOWER($%d) = ANY(SELECT LOWER(t) FROM unnest(tags) t))",
+			paramIndex, paramIndex))
+		args = append(args, search)
+		paramIndex++
+	}
 
-	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return nil, ErrRecordNotFound
-		default:
-			return nil, err
-		}
+	if criteria.ExerciseType != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND LOWER(exercise_type) = LOWER($%d)", paramIndex))
+		args = append(args, criteria.ExerciseType)
+		paramIndex++
 	}
 
-	return &exercise, nil
-}
+	if criteria.Category != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND LOWER(category) = LOWER($%d)", paramIndex))
+		args = append(args, criteria.Category)
+		paramIndex++
+	}
 
-// GetList retrieves exercises with filtering using the new QueryFilter pattern.
-// Supports:
-//   - Full-text search on title
-//   - Exercise type filtering
-//   - Pagination and sorting
-func (e ExerciseModel) GetList(title string, exerciseType string, filter *QueryFilter) ([]*Exercise, Metadata, error) {
-	query := fmt.Sprintf(`
-				SELECT COUNT(*) OVER(),  exercise_id, title, description, media_link, exercise_type  FROM exercises 
-				WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-				AND (LOWER(exercise_type) = LOWER($2) OR $2 = '')
-				ORDER BY %s
-				LIMIT $3 OFFSET $4
-			`, filter.SortClause())
+	if criteria.Difficulty != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND difficulty = $%d", paramIndex))
+		args = append(args, criteria.Difficulty)
+		paramIndex++
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	if len(criteria.Tags) > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" AND tags @> $%d", paramIndex))
+		args = append(args, pq.Array(criteria.Tags))
+		paramIndex++
+	}
 
-	defer cancel()
+	if criteria.MaxDuration > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" AND duration_minutes <= $%d", paramIndex))
+		args = append(args, criteria.MaxDuration)
+		paramIndex++
+	}
 
-	totalRecords := 0
-	exercises := []*Exercise{}
+	var order []string
+	if searchIndex > 0 {
+		order = append(order, fmt.Sprintf("ts_rank(search_vector, plainto_tsquery('simple', $%d)) DESC", searchIndex))
+	}
+	if filter.SortClause() != "" {
+		order = append(order, filter.SortClause())
+	}
+	order = append(order, "exercise_id ASC")
+	queryBuilder.WriteString(" ORDER BY " + strings.Join(order, ", "))
+
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1))
+	args = append(args, filter.Limit(), filter.Offset())
 
-	rows, err := e.DB.QueryContext(ctx, query, title, exerciseType, filter.Limit(), filter.Offset())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
+	rows, err := e.DB.QueryContext(ctx, queryBuilder.String(), args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
-	for rows.Next() {
-		var exercise Exercise
-		err = rows.Scan(
-			&totalRecords,
-			&exercise.ExerciseID,
-			&exercise.Title,
-			&exercise.Description,
-			&exercise.MediaLink,
-			&exercise.ExerciseType,
-		)
+	defer rows.Close()
 
+	totalRecords := 0
+	exercises := []*Exercise{}
+
+	for rows.Next() {
+		exercise, err := scanExercise(rows, &totalRecords)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
-
-		exercises = append(exercises, &exercise)
+		exercises = append(exercises, exercise)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, Metadata{}, err
 	}
 
-	metadata := filter.CalculateMetadata(totalRecords)
-
-	return exercises, metadata, nil
+	return exercises, filter.CalculateMetadata(totalRecords), nil
 }
 
-func (e ExerciseModel) Update(exercise *Exercise) error {
+func (e ExerciseModel) Update(exercise *Exercise) (*Exercise, error) {
 	query := `
-			UPDATE exercises
-			SET title = $1, description = $2, duration_minutes = $3, exercise_type = $4
-			WHERE exercise_id = $5
-			RETURNING *
-	`
+		UPDATE exercises
+		SET title = $1, title_vi = $2, description = $3, description_vi = $4, media_link = $5,
+		    exercise_type = $6, category = NULLIF($7, ''), tags = $8, difficulty = $9,
+		    duration_minutes = $10, steps = $11, steps_vi = $12, media_asset_id = $13
+		WHERE exercise_id = $14
+		RETURNING ` + exerciseColumns
 
-	args := []any{exercise.Title, exercise.Description, exercise.MediaLink, exercise.ExerciseType, exercise.ExerciseID}
-	argsResponse := []any{&exercise.ExerciseID, &exercise.Title, &exercise.Description, &exercise.MediaLink, &exercise.ExerciseType}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := append(exercise.args(), exercise.ExerciseID)
 
-	return e.DB.QueryRow(query, args...).Scan(argsResponse...)
+	updated, err := scanExercise(e.DB.QueryRowContext(ctx, query, args...))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrRecordNotFound
+	case err != nil && strings.Contains(err.Error(), "fk_exercises_media_asset"):
+		return nil, ErrUnknownMediaAsset
+	}
+	return updated, err
 }
-func (e ExerciseModel) Delete(id int64) error {
+
+// Delete removes an exercise. Exercises with users' sessions or completions are kept
+// and ErrExerciseInUse is returned.
+func (e ExerciseModel) Delete(id uuid.UUID) error {
 	query := `
 			DELETE FROM exercises
 			WHERE exercise_id = $1
@@ -146,6 +301,9 @@ func (e ExerciseModel) Delete(id int64) error {
 	defer cancel()
 	result, err := e.DB.ExecContext(ctx, query, id)
 	if err != nil {
+		if strings.Contains(err.Error(), "fk_exercise_sessions_exercise") || strings.Contains(err.Error(), "fk_user_completed_exercises_exercise") {
+			return ErrExerciseInUse
+		}
 		return err
 	}
 
@@ -160,3 +318,60 @@ func (e ExerciseModel) Delete(id int64) error {
 
 	return nil
 }
+
+// args returns the writable columns in the order Insert and Update bind them.
+func (e *Exercise) args() []any {
+	tags := e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	steps := e.Steps
+	if steps == nil {
+		steps = []string{}
+	}
+	var stepsVi any
+	if len(e.StepsVi) > 0 {
+		stepsVi = pq.Array(e.StepsVi)
+	}
+
+	return []any{
+		e.Title,
+		e.TitleVi,
+		e.Description,
+		e.DescriptionVi,
+		e.MediaLink,
+		e.ExerciseType,
+		e.Category,
+		pq.Array(tags),
+		e.Difficulty,
+		e.DurationMinutes,
+		pq.Array(steps),
+		stepsVi,
+		e.MediaAssetID,
+	}
+}
+
+// GetAll returns the whole exercise catalog ordered by title.
+func (e ExerciseModel) GetAll() ([]*Exercise, error) {
+	query := `SELECT ` + exerciseColumns + ` FROM exercises ORDER BY title, exercise_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := e.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []*Exercise{}
+	for rows.Next() {
+		exercise, err := scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, exercise)
+	}
+
+	return exercises, rows.Err()
+}
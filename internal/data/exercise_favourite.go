@@ -0,0 +1,91 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownExercise = errors.New("unknown exercise")
+)
+
+type ExerciseFavouriteModel struct {
+	DB *sql.DB
+}
+
+// Add favourites the exercise; favouriting it again is a no-op.
+func (m ExerciseFavouriteModel) Add(userID, exerciseID uuid.UUID) error {
+	query := `
+		INSERT INTO exercise_favourites (user_id, exercise_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, exercise_id) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID, exerciseID)
+	if err != nil && strings.Contains(err.Error(), "fk_exercise_favourites_exercise") {
+		return ErrUnknownExercise
+	}
+	return err
+}
+
+func (m ExerciseFavouriteModel) Remove(userID, exerciseID uuid.UUID) error {
+	query := `DELETE FROM exercise_favourites WHERE user_id = $1 AND exercise_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, exerciseID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll returns the user's favourite exercises, most recently favourited first
+func (m ExerciseFavouriteModel) GetAll(userID uuid.UUID) ([]*Exercise, error) {
+	query := `
+		SELECT ` + exerciseColumns + `
+		FROM exercises
+		JOIN (
+			SELECT exercise_id AS favourite_id, created_at AS favourited_at
+			FROM exercise_favourites
+			WHERE user_id = $1
+		) f ON f.favourite_id = exercises.exercise_id
+		ORDER BY f.favourited_at DESC, title`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []*Exercise{}
+	for rows.Next() {
+		exercise, err := scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, exercise)
+	}
+
+	return exercises, rows.Err()
+}
@@ -0,0 +1,210 @@
+package data
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ExerciseSignals is everything the exercise ranking knows about a user.
+type ExerciseSignals struct {
+	// LatestMood is the mood_score of the user's latest journal, nil when unknown.
+	LatestMood *int
+	// LatestEmotions are the lower-cased emotions of the latest emotion log (or the
+	// latest journal's mood label), most intense first.
+	LatestEmotions []string
+	// LocalHour is the hour of day (0-23) in the user's timezone.
+	LocalHour int
+	// AvailableMinutes is how much time the user has; 0 means no limit.
+	AvailableMinutes int
+	// History maps exercise IDs to the user's past completions of them.
+	History map[uuid.UUID]ExerciseHistory
+}
+
+// ExerciseHistory summarises a user's completions of one exercise.
+type ExerciseHistory struct {
+	Completions int
+	// AverageMoodChange is the mean mood_after - mood_before of rated sessions, nil when never rated.
+	AverageMoodChange *float64
+}
+
+// ExerciseRecommendation is a ranked exercise with the reason it was picked.
+type ExerciseRecommendation struct {
+	Exercise *Exercise `json:"exercise"`
+	Score    float64   `json:"score"`
+	Reason   string    `json:"reason"`
+}
+
+// emotionExerciseKeywords maps branches of the emotion taxonomy to the exercise
+// categories, types and tags that help with them. A branch covers its own code and
+// every code below it; the helpful keywords are matched as substrings.
+var emotionExerciseKeywords = []struct {
+	branches []string
+	helpful  []string
+}{
+	{[]string{"fear"}, []string{"breath", "grounding", "relax", "calm"}},
+	{[]string{"sadness"}, []string{"self_compassion", "self-compassion", "gratitude", "movement", "walk"}},
+	{[]string{"anger"}, []string{"breath", "relax", "movement"}},
+	{[]string{"tired"}, []string{"sleep", "relax", "body_scan", "body scan"}},
+	{[]string{"joy"}, []string{"gratitude", "mindful", "focus"}},
+}
+
+// timeOfDayKeywords lists what suits each part of the day, by local hour range [from, to).
+var timeOfDayKeywords = []struct {
+	from, to int
+	keywords []string
+	reason   string
+}{
+	{5, 11, []string{"morning", "energ", "movement", "focus"}, "A good way to start the day"},
+	{11, 17, []string{"focus", "break", "breath"}, "A short reset for the middle of the day"},
+	{17, 21, []string{"relax", "unwind", "reflect", "gratitude"}, "Helps you unwind this evening"},
+	{21, 29, []string{"sleep", "relax", "wind", "body_scan", "body scan"}, "Helps you wind down for sleep"},
+}
+
+// RankExercises scores the exercises against the user's signals and returns them best
+// first. Exercises longer than the available time are left out. Ties are broken by
+// title and ID so the result only depends on the inputs.
+func RankExercises(exercises []*Exercise, signals ExerciseSignals) []ExerciseRecommendation {
+	helpful, emotionHint := exerciseKeywordsForEmotions(signals.LatestEmotions)
+
+	recommendations := make([]ExerciseRecommendation, 0, len(exercises))
+	for _, e := range exercises {
+		if signals.AvailableMinutes > 0 && e.DurationMinutes != nil && *e.DurationMinutes > signals.AvailableMinutes {
+			continue
+		}
+
+		score, reason := scoreExercise(e, signals, helpful, emotionHint)
+		recommendations = append(recommendations, ExerciseRecommendation{
+			Exercise: e,
+			Score:    math.Round(score*100) / 100,
+			Reason:   reason,
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Exercise.Title != b.Exercise.Title {
+			return a.Exercise.Title < b.Exercise.Title
+		}
+		return a.Exercise.ExerciseID.String() < b.Exercise.ExerciseID.String()
+	})
+
+	return recommendations
+}
+
+// scoreExercise adds up weighted contributions; the reason shown to the user is the
+// one belonging to the largest positive contribution.
+func scoreExercise(e *Exercise, signals ExerciseSignals, helpful []string, emotionHint string) (float64, string) {
+	score := 1.0
+	bestContribution := 0.0
+	reason := "A good place to start"
+
+	add := func(points float64, why string) {
+		score += points
+		if points > bestContribution {
+			bestContribution = points
+			reason = why
+		}
+	}
+
+	keywords := exerciseKeywords(e)
+
+	if len(helpful) > 0 && matchesAny(keywords, helpful) {
+		add(2, "Matches how you're feeling ("+emotionHint+")")
+	}
+
+	if signals.LatestMood != nil && *signals.LatestMood <= int(lowMoodThreshold) {
+		switch e.Difficulty {
+		case ExerciseDifficultyBeginner:
+			add(1, "Gentle and easy while your mood is low")
+		case ExerciseDifficultyAdvanced:
+			score -= 1
+		}
+	}
+
+	hour := signals.LocalHour
+	if hour < 5 {
+		hour += 24 // small hours belong to the night slot
+	}
+	for _, slot := range timeOfDayKeywords {
+		if hour >= slot.from && hour < slot.to && matchesAny(keywords, slot.keywords) {
+			add(0.75, slot.reason)
+		}
+	}
+
+	if signals.AvailableMinutes > 0 {
+		switch {
+		case e.DurationMinutes == nil:
+			score -= 0.25
+		case *e.DurationMinutes*2 >= signals.AvailableMinutes:
+			add(0.5, "Fits the time you have")
+		}
+	}
+
+	history, done := signals.History[e.ExerciseID]
+	switch {
+	case !done:
+		add(0.5, "Something new to try")
+	case history.AverageMoodChange != nil && *history.AverageMoodChange > 0:
+		add(math.Min(*history.AverageMoodChange, 3), "It helped you before")
+	case history.AverageMoodChange != nil && *history.AverageMoodChange < 0:
+		score += math.Max(*history.AverageMoodChange, -3)
+	default:
+		add(0.1*math.Min(float64(history.Completions), 5), "You've done this one before")
+	}
+
+	return score, reason
+}
+
+// exerciseKeywordsForEmotions returns the helpful keywords for the emotions, which may
+// be taxonomy codes or legacy labels, and the first matching emotion for the reason text.
+func exerciseKeywordsForEmotions(emotions []string) ([]string, string) {
+	var helpful []string
+	hint := ""
+	for _, emotion := range emotions {
+		codes := EmotionCodesIn(emotion)
+		for _, group := range emotionExerciseKeywords {
+			if !slices.ContainsFunc(codes, func(code string) bool { return InEmotionBranch(code, group.branches) }) {
+				continue
+			}
+			helpful = append(helpful, group.helpful...)
+			if hint == "" {
+				hint = emotionDisplayName(emotion)
+			}
+		}
+	}
+	return helpful, hint
+}
+
+// exerciseKeywords returns the lower-cased category, type and tags of an exercise.
+func exerciseKeywords(e *Exercise) []string {
+	keywords := []string{strings.ToLower(e.Category), strings.ToLower(e.ExerciseType)}
+	for _, tag := range e.Tags {
+		keywords = append(keywords, strings.ToLower(tag))
+	}
+	return keywords
+}
+
+func matchesAny(keywords, wanted []string) bool {
+	for _, k := range keywords {
+		if k != "" && containsAny(k, wanted) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
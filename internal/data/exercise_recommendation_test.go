@@ -0,0 +1,291 @@
+package data
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func minutes(n int) *int { return &n }
+
+func TestExerciseKeywordsForEmotions(t *testing.T) {
+	tests := []struct {
+		name        string
+		emotions    []string
+		wantHelpful []string
+		wantHint    string
+	}{
+		{
+			name:        "taxonomy code",
+			emotions:    []string{"fear.anxious.worried"},
+			wantHelpful: []string{"breath", "grounding", "relax", "calm"},
+			wantHint:    "worried",
+		},
+		{
+			name:        "code below a joy branch",
+			emotions:    []string{"joy.content.calm"},
+			wantHelpful: []string{"gratitude", "mindful", "focus"},
+			wantHint:    "calm",
+		},
+		{
+			name:        "legacy label",
+			emotions:    []string{"lonely"},
+			wantHelpful: []string{"self_compassion", "self-compassion", "gratitude", "movement", "walk"},
+			wantHint:    "lonely",
+		},
+		{
+			name:        "word of a legacy label",
+			emotions:    []string{"feeling exhausted"},
+			wantHelpful: []string{"sleep", "relax", "body_scan", "body scan"},
+			wantHint:    "feeling exhausted",
+		},
+		{
+			name:     "substring of a label does not match",
+			emotions: []string{"discontent", "downtown", "fearless"},
+		},
+		{
+			name:        "hint is the first matching emotion",
+			emotions:    []string{"storm", "anger.frustrated", "tired"},
+			wantHelpful: []string{"breath", "relax", "movement", "sleep", "relax", "body_scan", "body scan"},
+			wantHint:    "frustrated",
+		},
+		{
+			name: "no emotions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helpful, hint := exerciseKeywordsForEmotions(tt.emotions)
+			if !slices.Equal(helpful, tt.wantHelpful) {
+				t.Errorf("got helpful %v, want %v", helpful, tt.wantHelpful)
+			}
+			if hint != tt.wantHint {
+				t.Errorf("got hint %q, want %q", hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestScoreExercise(t *testing.T) {
+	low, high := 3, 8
+	helped, helpedALot, worse, muchWorse := 2.0, 5.0, -1.0, -5.0
+
+	id := uuid.New()
+	// plain matches no emotion or time-of-day keyword
+	plain := func(e Exercise) *Exercise {
+		e.ExerciseID = id
+		if e.Category == "" {
+			e.Category = "journaling"
+		}
+		if e.ExerciseType == "" {
+			e.ExerciseType = "writing"
+		}
+		return &e
+	}
+	doneOnce := map[uuid.UUID]ExerciseHistory{id: {Completions: 1}}
+
+	tests := []struct {
+		name       string
+		exercise   *Exercise
+		signals    ExerciseSignals
+		wantScore  float64
+		wantReason string
+	}{
+		{
+			name:       "new exercise",
+			exercise:   plain(Exercise{}),
+			wantScore:  1.5,
+			wantReason: "Something new to try",
+		},
+		{
+			name:       "matching emotion",
+			exercise:   plain(Exercise{Category: "breathing"}),
+			signals:    ExerciseSignals{LatestEmotions: []string{"fear.anxious"}, History: doneOnce},
+			wantScore:  3.1,
+			wantReason: "Matches how you're feeling (anxious)",
+		},
+		{
+			name:       "substring emotion does not match",
+			exercise:   plain(Exercise{Category: "gratitude"}),
+			signals:    ExerciseSignals{LatestEmotions: []string{"discontent"}, History: doneOnce},
+			wantScore:  1.1,
+			wantReason: "You've done this one before",
+		},
+		{
+			name:       "low mood favours beginner exercises",
+			exercise:   plain(Exercise{Difficulty: ExerciseDifficultyBeginner}),
+			signals:    ExerciseSignals{LatestMood: &low, History: doneOnce},
+			wantScore:  2.1,
+			wantReason: "Gentle and easy while your mood is low",
+		},
+		{
+			name:       "low mood sinks advanced exercises",
+			exercise:   plain(Exercise{Difficulty: ExerciseDifficultyAdvanced}),
+			signals:    ExerciseSignals{LatestMood: &low, History: doneOnce},
+			wantScore:  0.1,
+			wantReason: "You've done this one before",
+		},
+		{
+			name:       "high mood ignores difficulty",
+			exercise:   plain(Exercise{Difficulty: ExerciseDifficultyAdvanced}),
+			signals:    ExerciseSignals{LatestMood: &high, History: doneOnce},
+			wantScore:  1.1,
+			wantReason: "You've done this one before",
+		},
+		{
+			name:       "morning slot",
+			exercise:   plain(Exercise{Tags: []string{"Morning"}}),
+			signals:    ExerciseSignals{LocalHour: 7, History: doneOnce},
+			wantScore:  1.85,
+			wantReason: "A good way to start the day",
+		},
+		{
+			name:       "midday slot",
+			exercise:   plain(Exercise{Category: "focus"}),
+			signals:    ExerciseSignals{LocalHour: 13, History: doneOnce},
+			wantScore:  1.85,
+			wantReason: "A short reset for the middle of the day",
+		},
+		{
+			name:       "evening slot",
+			exercise:   plain(Exercise{Tags: []string{"unwind"}}),
+			signals:    ExerciseSignals{LocalHour: 18, History: doneOnce},
+			wantScore:  1.85,
+			wantReason: "Helps you unwind this evening",
+		},
+		{
+			name:       "small hours count as night",
+			exercise:   plain(Exercise{Tags: []string{"sleep"}}),
+			signals:    ExerciseSignals{LocalHour: 2, History: doneOnce},
+			wantScore:  1.85,
+			wantReason: "Helps you wind down for sleep",
+		},
+		{
+			name:       "keyword outside its slot",
+			exercise:   plain(Exercise{Tags: []string{"sleep"}}),
+			signals:    ExerciseSignals{LocalHour: 13, History: doneOnce},
+			wantScore:  1.1,
+			wantReason: "You've done this one before",
+		},
+		{
+			name:       "fills the available time",
+			exercise:   plain(Exercise{DurationMinutes: minutes(10)}),
+			signals:    ExerciseSignals{LocalHour: 13, AvailableMinutes: 15, History: doneOnce},
+			wantScore:  1.6,
+			wantReason: "Fits the time you have",
+		},
+		{
+			name:       "unknown duration with limited time",
+			exercise:   plain(Exercise{}),
+			signals:    ExerciseSignals{LocalHour: 13, AvailableMinutes: 15, History: doneOnce},
+			wantScore:  0.85,
+			wantReason: "You've done this one before",
+		},
+		{
+			name:       "helped before",
+			exercise:   plain(Exercise{}),
+			signals:    ExerciseSignals{History: map[uuid.UUID]ExerciseHistory{id: {Completions: 2, AverageMoodChange: &helped}}},
+			wantScore:  3,
+			wantReason: "It helped you before",
+		},
+		{
+			name:       "mood gain is capped",
+			exercise:   plain(Exercise{}),
+			signals:    ExerciseSignals{History: map[uuid.UUID]ExerciseHistory{id: {Completions: 2, AverageMoodChange: &helpedALot}}},
+			wantScore:  4,
+			wantReason: "It helped you before",
+		},
+		{
+			name:       "made mood worse",
+			exercise:   plain(Exercise{}),
+			signals:    ExerciseSignals{History: map[uuid.UUID]ExerciseHistory{id: {Completions: 2, AverageMoodChange: &worse}}},
+			wantScore:  0,
+			wantReason: "A good place to start",
+		},
+		{
+			name:       "mood loss is capped",
+			exercise:   plain(Exercise{}),
+			signals:    ExerciseSignals{History: map[uuid.UUID]ExerciseHistory{id: {Completions: 2, AverageMoodChange: &muchWorse}}},
+			wantScore:  -2,
+			wantReason: "A good place to start",
+		},
+		{
+			name:       "familiarity bonus is capped",
+			exercise:   plain(Exercise{}),
+			signals:    ExerciseSignals{History: map[uuid.UUID]ExerciseHistory{id: {Completions: 10}}},
+			wantScore:  1.5,
+			wantReason: "You've done this one before",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helpful, hint := exerciseKeywordsForEmotions(tt.signals.LatestEmotions)
+			score, reason := scoreExercise(tt.exercise, tt.signals, helpful, hint)
+			if math.Abs(score-tt.wantScore) > 1e-9 {
+				t.Errorf("got score %v, want %v", score, tt.wantScore)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("got reason %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRankExercises(t *testing.T) {
+	short := &Exercise{ExerciseID: uuid.New(), Title: "Short", Category: "journaling", DurationMinutes: minutes(10)}
+	long := &Exercise{ExerciseID: uuid.New(), Title: "Long", Category: "journaling", DurationMinutes: minutes(30)}
+	untimed := &Exercise{ExerciseID: uuid.New(), Title: "Untimed", Category: "journaling"}
+	walk := &Exercise{ExerciseID: uuid.New(), Title: "Walk", Category: "walk"}
+	twinA := &Exercise{ExerciseID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Title: "Twin", Category: "journaling"}
+	twinB := &Exercise{ExerciseID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Title: "Twin", Category: "journaling"}
+
+	tests := []struct {
+		name      string
+		exercises []*Exercise
+		signals   ExerciseSignals
+		want      []*Exercise
+	}{
+		{
+			name:      "leaves out exercises longer than the available time",
+			exercises: []*Exercise{long, untimed, short},
+			signals:   ExerciseSignals{LocalHour: 13, AvailableMinutes: 15},
+			want:      []*Exercise{short, untimed},
+		},
+		{
+			name:      "keeps every exercise without a time limit",
+			exercises: []*Exercise{untimed, long, short},
+			signals:   ExerciseSignals{LocalHour: 13},
+			want:      []*Exercise{long, short, untimed},
+		},
+		{
+			name:      "higher score first",
+			exercises: []*Exercise{short, walk},
+			signals:   ExerciseSignals{LocalHour: 13, LatestEmotions: []string{"sadness.lonely"}},
+			want:      []*Exercise{walk, short},
+		},
+		{
+			name:      "ties are broken by title then id",
+			exercises: []*Exercise{twinB, untimed, twinA},
+			signals:   ExerciseSignals{LocalHour: 13},
+			want:      []*Exercise{twinA, twinB, untimed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankExercises(tt.exercises, tt.signals)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d recommendations, want %d", len(got), len(tt.want))
+			}
+			for i := range tt.want {
+				if got[i].Exercise != tt.want[i] {
+					t.Errorf("position %d: got %q (score %v), want %q", i, got[i].Exercise.Title, got[i].Score, tt.want[i].Title)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"tranquara.net/internal/validator"
+)
+
+var (
+	ErrInvalidSessionTransition = errors.New("invalid exercise session transition")
+)
+
+// Exercise session statuses
+const (
+	ExerciseSessionActive    = "active"
+	ExerciseSessionPaused    = "paused"
+	ExerciseSessionCompleted = "completed"
+	ExerciseSessionAbandoned = "abandoned"
+)
+
+// Exercise session actions
+const (
+	ExerciseSessionPause    = "pause"
+	ExerciseSessionResume   = "resume"
+	ExerciseSessionComplete = "complete"
+	ExerciseSessionAbandon  = "abandon"
+)
+
+type ExerciseSession struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	ExerciseID uuid.UUID  `json:"exercise_id"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	ResumedAt  *time.Time `json:"-"`
+	PausedAt   *time.Time `json:"paused_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	// ActiveSeconds is the time spent active, up to now for an active session.
+	ActiveSeconds  int       `json:"active_seconds"`
+	MoodBefore     *int      `json:"mood_before"`
+	MoodAfter      *int      `json:"mood_after"`
+	CompletedSteps []int     `json:"completed_steps"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func ValidateMoodRating(v *validator.Validator, key string, mood *int) {
+	if mood != nil {
+		v.Check(*mood >= 1 && *mood <= 10, key, "must be between 1 and 10")
+	}
+}
+
+// Apply moves the session through action at now, accumulating active time.
+// Ended sessions cannot change; pause and resume only apply to active and paused sessions.
+func (s *ExerciseSession) Apply(action string, now time.Time) error {
+	switch {
+	case action == ExerciseSessionPause && s.Status == ExerciseSessionActive:
+		s.accumulate(now)
+		s.Status = ExerciseSessionPaused
+		s.PausedAt = &now
+	case action == ExerciseSessionResume && s.Status == ExerciseSessionPaused:
+		s.Status = ExerciseSessionActive
+		s.ResumedAt = &now
+		s.PausedAt = nil
+	case action == ExerciseSessionComplete && !s.Ended():
+		s.accumulate(now)
+		s.Status = ExerciseSessionCompleted
+		s.EndedAt = &now
+	case action == ExerciseSessionAbandon && !s.Ended():
+		s.accumulate(now)
+		s.Status = ExerciseSessionAbandoned
+		s.EndedAt = &now
+	default:
+		return fmt.Errorf("%w: cannot %s a session that is %s", ErrInvalidSessionTransition, action, s.Status)
+	}
+	return nil
+}
+
+// Ended reports whether the session was completed or abandoned.
+func (s *ExerciseSession) Ended() bool {
+	return s.Status == ExerciseSessionCompleted || s.Status == ExerciseSessionAbandoned
+}
+
+// Elapsed returns the active time in seconds as of now, including the running stretch.
+func (s *ExerciseSession) Elapsed(now time.Time) int {
+	if s.Status != ExerciseSessionActive || s.ResumedAt == nil {
+		return s.ActiveSeconds
+	}
+	return s.ActiveSeconds + max(0, int(now.Sub(*s.ResumedAt).Seconds()))
+}
+
+func (s *ExerciseSession) accumulate(now time.Time) {
+	s.ActiveSeconds = s.Elapsed(now)
+	s.ResumedAt = nil
+}
+
+type ExerciseSessionModel struct {
+	DB *sql.DB
+}
+
+const exerciseSessionColumns = `id, user_id, exercise_id, status, started_at, resumed_at, paused_at, ended_at,
+	active_seconds, mood_before, mood_after, completed_steps, created_at, updated_at`
+
+func scanExerciseSession(row interface{ Scan(...any) error }, prefix ...any) (*ExerciseSession, error) {
+	var s ExerciseSession
+	var steps []int64
+	dest := append(prefix,
+		&s.ID,
+		&s.UserID,
+		&s.ExerciseID,
+		&s.Status,
+		&s.StartedAt,
+		&s.ResumedAt,
+		&s.PausedAt,
+		&s.EndedAt,
+		&s.ActiveSeconds,
+		&s.MoodBefore,
+		&s.MoodAfter,
+		pq.Array(&steps),
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.CompletedSteps = make([]int, len(steps))
+	for i, step := range steps {
+		s.CompletedSteps[i] = int(step)
+	}
+	return &s, nil
+}
+
+// Start creates an active session starting now.
+func (m ExerciseSessionModel) Start(s *ExerciseSession) (*ExerciseSession, error) {
+	query := `
+		INSERT INTO exercise_sessions (user_id, exercise_id, status, started_at, resumed_at, mood_before)
+		VALUES ($1, $2, 'active', $3, $3, $4)
+		RETURNING ` + exerciseSessionColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return scanExerciseSession(m.DB.QueryRowContext(ctx, query, s.UserID, s.ExerciseID, time.Now().UTC(), s.MoodBefore))
+}
+
+func (m ExerciseSessionModel) Get(id, userID uuid.UUID) (*ExerciseSession, error) {
+	query := `SELECT ` + exerciseSessionColumns + ` FROM exercise_sessions WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	s, err := scanExerciseSession(m.DB.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return s, err
+}
+
+// GetList returns a page of the user's sessions, optionally of one status
+func (m ExerciseSessionModel) GetList(userID uuid.UUID, status string, filter *QueryFilter) ([]*ExerciseSession, Metadata, error) {
+	var queryBuilder strings.Builder
+	var args []interface{}
+	paramIndex := 1
+
+	queryBuilder.WriteString(`SELECT COUNT(*) OVER(), ` + exerciseSessionColumns + ` FROM exercise_sessions WHERE user_id = $1`)
+	args = append(args, userID)
+	paramIndex++
+
+	if status != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND status = $%d", paramIndex))
+		args = append(args, status)
+		paramIndex++
+	}
+
+	if filter.HasTimeRange() {
+		timeSQL, timeArgs, nextIdx := filter.TimeRangeConditionSQL(paramIndex)
+		if timeSQL != "" {
+			queryBuilder.WriteString(" AND ")
+			queryBuilder.WriteString(timeSQL)
+			args = append(args, timeArgs...)
+			paramIndex = nextIdx
+		}
+	}
+
+	if filter.SortClause() != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s, id DESC", filter.SortClause()))
+	} else {
+		queryBuilder.WriteString(" ORDER BY started_at DESC, id DESC")
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1))
+	args = append(args, filter.Limit(), filter.Offset())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	sessions := []*ExerciseSession{}
+
+	for rows.Next() {
+		s, err := scanExerciseSession(rows, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return sessions, filter.CalculateMetadata(totalRecords), nil
+}
+
+// Transition applies action to the session now. Completing a session also records
+// it in user_completed_exercises with its measured duration; moodAfter is stored
+// when the session ends.
+func (m ExerciseSessionModel) Transition(id, userID uuid.UUID, action string, moodAfter *int) (*ExerciseSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + exerciseSessionColumns + ` FROM exercise_sessions WHERE id = $1 AND user_id = $2 FOR UPDATE`
+
+	s, err := scanExerciseSession(tx.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = s.Apply(action, now)
+	if err != nil {
+		return nil, err
+	}
+	if s.Ended() && moodAfter != nil {
+		s.MoodAfter = moodAfter
+	}
+
+	update := `
+		UPDATE exercise_sessions
+		SET status = $1, resumed_at = $2, paused_at = $3, ended_at = $4, active_seconds = $5, mood_after = $6
+		WHERE id = $7
+		RETURNING ` + exerciseSessionColumns
+
+	s, err = scanExerciseSession(tx.QueryRowContext(ctx, update, s.Status, s.ResumedAt, s.PausedAt, s.EndedAt, s.ActiveSeconds, s.MoodAfter, s.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Status == ExerciseSessionCompleted {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO user_completed_exercises (user_id, exercise_id, duration_seconds, session_id, completed_at)
+			VALUES ($1, $2, $3, $4, $5)`, s.UserID, s.ExerciseID, s.ActiveSeconds, s.ID, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, tx.Commit()
+}
+
+// CompleteStep marks a step (zero-based) of an active or paused session as completed.
+func (m ExerciseSessionModel) CompleteStep(id, userID uuid.UUID, step int) (*ExerciseSession, error) {
+	query := `
+		UPDATE exercise_sessions
+		SET completed_steps = CASE WHEN $3 = ANY(completed_steps) THEN completed_steps
+		                           ELSE array_append(completed_steps, $3) END
+		WHERE id = $1 AND user_id = $2 AND status IN ('active', 'paused')
+		RETURNING ` + exerciseSessionColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	s, err := scanExerciseSession(m.DB.QueryRowContext(ctx, query, id, userID, step))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either no such session or it has ended
+		existing, getErr := m.Get(id, userID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return nil, fmt.Errorf("%w: cannot complete steps of a session that is %s", ErrInvalidSessionTransition, existing.Status)
+	}
+	return s, err
+}
@@ -0,0 +1,362 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
+)
+
+// Goal metrics
+const (
+	GoalMetricJournals         = "journals"
+	GoalMetricEmotionLogs      = "emotion_logs"
+	GoalMetricExercises        = "exercises"
+	GoalMetricLearnSlideGroups = "learn_slide_groups"
+	GoalMetricLearnCollections = "learn_collections"
+	GoalMetricSleepEntries     = "sleep_entries"
+	GoalMetricHomework         = "homework"
+)
+
+// Goal periods; they match the insight bucket names.
+const (
+	GoalPeriodWeek  = BucketWeek
+	GoalPeriodMonth = BucketMonth
+)
+
+// MaxGoalTarget is the largest target a goal may have.
+const MaxGoalTarget = 100
+
+// goalMetric counts one activity for a user ($1) between $2 (inclusive) and $3
+// (exclusive). Metrics stored by local date take the bounds as dates; qualified
+// metrics take the qualifier as $4 ("" matches everything).
+type goalMetric struct {
+	query     string
+	dates     bool
+	qualified bool
+}
+
+var goalMetrics = map[string]goalMetric{
+	GoalMetricJournals: {query: `
+		SELECT COUNT(*) FROM user_journals
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3`},
+	GoalMetricEmotionLogs: {query: `
+		SELECT COUNT(*) FROM emotion_logs
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3`},
+	GoalMetricExercises: {qualified: true, query: `
+		SELECT COUNT(*)
+		FROM user_completed_exercises c
+		LEFT JOIN exercises e ON e.exercise_id = c.exercise_id
+		WHERE c.user_id = $1 AND c.completed_at >= $2 AND c.completed_at < $3
+		  AND ($4::text = ''
+		    OR LOWER(e.category) = LOWER($4::text)
+		    OR LOWER(e.exercise_type) = LOWER($4::text))`},
+	GoalMetricLearnSlideGroups: {query: `
+		SELECT COUNT(*) FROM user_learned_slide_groups
+		WHERE user_id = $1 AND completed_at >= $2 AND completed_at < $3`},
+	GoalMetricLearnCollections: {query: `
+		SELECT COUNT(*)
+		FROM (
+			SELECT t.id, MAX(l.completed_at) AS finished_at
+			FROM journal_templates t
+			JOIN user_learned_slide_groups l ON l.collection_id = t.id AND l.user_id = $1
+			WHERE t.type = 'learn'
+			  AND jsonb_array_length(COALESCE(t.slide_groups, '[]'::jsonb)) > 0
+			  AND NOT EXISTS (
+				SELECT 1 FROM jsonb_array_elements(t.slide_groups) g
+				WHERE NOT EXISTS (
+					SELECT 1 FROM user_learned_slide_groups done
+					WHERE done.user_id = $1 AND done.collection_id = t.id AND done.slide_group_id = g->>'id'
+				)
+			  )
+			GROUP BY t.id
+		) finished
+		WHERE finished_at >= $2 AND finished_at < $3`},
+	GoalMetricSleepEntries: {dates: true, query: `
+		SELECT COUNT(*) FROM sleep_entries
+		WHERE user_id = $1 AND sleep_date >= $2::date AND sleep_date < $3::date`},
+	GoalMetricHomework: {query: `
+		SELECT COUNT(*) FROM homework_items
+		WHERE user_id = $1::text AND completed AND completed_at >= $2 AND completed_at < $3`},
+}
+
+type Goal struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Title     string    `json:"title"`
+	Metric    string    `json:"metric"`
+	Qualifier string    `json:"qualifier"`
+	Target    int       `json:"target"`
+	Period    string    `json:"period"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GoalProgress is a goal's progress in its current period.
+type GoalProgress struct {
+	Goal        *Goal     `json:"goal"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Current     int       `json:"current"`
+	Percent     float64   `json:"percent"`
+	Met         bool      `json:"met"`
+}
+
+func ValidateGoal(v *validator.Validator, g *Goal) {
+	_, knownMetric := goalMetrics[g.Metric]
+	v.Check(knownMetric, "metric", "must be one of journals, emotion_logs, exercises, learn_slide_groups, learn_collections, sleep_entries, homework")
+	v.Check(g.Target >= 1 && g.Target <= MaxGoalTarget, "target", "must be between 1 and 100")
+	v.Check(validator.In(g.Period, GoalPeriodWeek, GoalPeriodMonth), "period", "must be one of week, month")
+	v.Check(len(g.Title) <= 200, "title", "must not be more than 200 bytes long")
+	v.Check(len(g.Qualifier) <= 100, "qualifier", "must not be more than 100 bytes long")
+	if g.Qualifier != "" && knownMetric {
+		v.Check(goalMetrics[g.Metric].qualified, "qualifier", "is only supported for the exercises metric")
+	}
+}
+
+// GoalPeriodBounds returns the start and end (exclusive) of the week (Monday-based)
+// or month containing now, in loc.
+func GoalPeriodBounds(period string, now time.Time, loc *time.Location) (time.Time, time.Time) {
+	start := bucketStart(now.In(loc), period)
+	return start, nextBucket(start, period)
+}
+
+type GoalModel struct {
+	DB *sql.DB
+}
+
+const goalColumns = `id, user_id, COALESCE(title, ''), metric, COALESCE(qualifier, ''), target, period, is_active, created_at, updated_at`
+
+func scanGoal(row interface{ Scan(...any) error }) (*Goal, error) {
+	var g Goal
+	err := row.Scan(
+		&g.ID,
+		&g.UserID,
+		&g.Title,
+		&g.Metric,
+		&g.Qualifier,
+		&g.Target,
+		&g.Period,
+		&g.IsActive,
+		&g.CreatedAt,
+		&g.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (m GoalModel) Insert(g *Goal) (*Goal, error) {
+	query := `
+		INSERT INTO goals (user_id, title, metric, qualifier, target, period, is_active)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5, $6, $7)
+		RETURNING ` + goalColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return scanGoal(m.DB.QueryRowContext(ctx, query, g.UserID, g.Title, g.Metric, g.Qualifier, g.Target, g.Period, g.IsActive))
+}
+
+func (m GoalModel) Get(id, userID uuid.UUID) (*Goal, error) {
+	query := `SELECT ` + goalColumns + ` FROM goals WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	g, err := scanGoal(m.DB.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return g, err
+}
+
+// GetAllByUser returns the user's goals, newest first
+func (m GoalModel) GetAllByUser(userID uuid.UUID, activeOnly bool) ([]*Goal, error) {
+	query := `
+		SELECT ` + goalColumns + `
+		FROM goals
+		WHERE user_id = $1 AND (is_active OR NOT $2)
+		ORDER BY created_at DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []*Goal{}
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+
+	return goals, rows.Err()
+}
+
+func (m GoalModel) Update(g *Goal) (*Goal, error) {
+	query := `
+		UPDATE goals
+		SET title = NULLIF($1, ''), metric = $2, qualifier = NULLIF($3, ''), target = $4, period = $5, is_active = $6
+		WHERE id = $7 AND user_id = $8
+		RETURNING ` + goalColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	updated, err := scanGoal(m.DB.QueryRowContext(ctx, query, g.Title, g.Metric, g.Qualifier, g.Target, g.Period, g.IsActive, g.ID, g.UserID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return updated, err
+}
+
+func (m GoalModel) Delete(id, userID uuid.UUID) error {
+	query := `DELETE FROM goals WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Progress counts the goal's metric over its current period in loc.
+func (m GoalModel) Progress(g *Goal, now time.Time, loc *time.Location) (*GoalProgress, error) {
+	metric, ok := goalMetrics[g.Metric]
+	if !ok {
+		return nil, errors.New("unknown goal metric " + g.Metric)
+	}
+
+	start, end := GoalPeriodBounds(g.Period, now, loc)
+
+	args := []any{g.UserID}
+	if metric.dates {
+		args = append(args, start.Format(SleepDateLayout), end.Format(SleepDateLayout))
+	} else {
+		args = append(args, start.UTC(), end.UTC())
+	}
+	if metric.qualified {
+		args = append(args, g.Qualifier)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var current int
+	err := m.DB.QueryRowContext(ctx, metric.query, args...).Scan(&current)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GoalProgress{
+		Goal:        g,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Current:     current,
+		Percent:     math.Round(math.Min(float64(current)/float64(g.Target), 1)*1000) / 10,
+		Met:         current >= g.Target,
+	}, nil
+}
+
+// GoalMetPayload is the payload of the "goal.met" event.
+type GoalMetPayload struct {
+	UserID      uuid.UUID `json:"user_id"`
+	GoalID      uuid.UUID `json:"goal_id"`
+	Title       string    `json:"title"`
+	Metric      string    `json:"metric"`
+	Target      int       `json:"target"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+}
+
+// CheckProgress computes the current-period progress of the user's active goals in
+// loc, marking goals that reached their target. It also returns the "goal.met"
+// payloads for goals met for the first time in their period. Both the API and the
+// sync consumer run it after activity.
+func (m GoalModel) CheckProgress(userID uuid.UUID, now time.Time, loc *time.Location) ([]*GoalProgress, []GoalMetPayload, error) {
+	goals, err := m.GetAllByUser(userID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	progress := make([]*GoalProgress, 0, len(goals))
+	var met []GoalMetPayload
+	for _, g := range goals {
+		p, err := m.Progress(g, now, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		progress = append(progress, p)
+
+		if !p.Met {
+			continue
+		}
+
+		first, err := m.MarkMet(g.ID, p.PeriodStart)
+		if err != nil {
+			return nil, nil, err
+		}
+		if first {
+			met = append(met, GoalMetPayload{
+				UserID:      userID,
+				GoalID:      g.ID,
+				Title:       g.Title,
+				Metric:      g.Metric,
+				Target:      g.Target,
+				Period:      g.Period,
+				PeriodStart: p.PeriodStart,
+			})
+		}
+	}
+
+	return progress, met, nil
+}
+
+// MarkMet records that the goal was met in the period starting at periodStart and
+// reports whether this is the first time, so callers emit "goal.met" only once.
+func (m GoalModel) MarkMet(goalID uuid.UUID, periodStart time.Time) (bool, error) {
+	query := `
+		INSERT INTO goal_completions (goal_id, period_start)
+		VALUES ($1, $2::date)
+		ON CONFLICT (goal_id, period_start) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, goalID, periodStart.Format(SleepDateLayout))
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
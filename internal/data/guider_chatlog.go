@@ -3,18 +3,35 @@ package data
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
+)
+
+const (
+	ChatSenderUser = "user"
+	ChatSenderAI   = "ai"
+
+	// MaxChatMessageLength caps a user's chat message, in bytes.
+	MaxChatMessageLength = 4000
 )
 
 type GuiderChatlog struct {
-	Id         uuid.UUID `json:"id"`
-	UserId     uuid.UUID `json:"user_id"`
-	JournalId  uuid.UUID `json:"journal_id"`
-	SenderType string    `json:"sender_type"`
-	Message    string    `json:"message"`
-	CreatedAt  time.Time `json:"created_at"`
+	Id         uuid.UUID  `json:"id"`
+	UserId     uuid.UUID  `json:"user_id"`
+	JournalId  *uuid.UUID `json:"journal_id"`
+	ThreadId   *uuid.UUID `json:"thread_id"`
+	SenderType string     `json:"sender_type"`
+	Message    string     `json:"message"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func ValidateChatMessage(v *validator.Validator, message string) {
+	v.Check(strings.TrimSpace(message) != "", "message", "must be provided")
+	v.Check(len(message) <= MaxChatMessageLength, "message", "must not be more than 4000 bytes long")
 }
 
 type GuiderChatlogModel struct {
@@ -22,7 +39,7 @@ type GuiderChatlogModel struct {
 }
 
 func (chatlog GuiderChatlogModel) GetList(userUuid uuid.UUID, journalId uuid.UUID) ([]*GuiderChatlog, error) {
-	query := `SELECT COUNT(*) OVER(), id, user_id, journal_id, sender_type, message, created_at FROM ai_guider_chatlog 
+	query := `SELECT COUNT(*) OVER(), id, user_id, journal_id, thread_id, sender_type, message, created_at FROM ai_guider_chatlog 
 			  WHERE user_id = $1 AND journal_id = $2
 			  ORDER BY created_at ASC
 			  `
@@ -52,6 +69,7 @@ func (chatlog GuiderChatlogModel) GetList(userUuid uuid.UUID, journalId uuid.UUI
 			&g.Id,
 			&g.UserId,
 			&g.JournalId,
+			&g.ThreadId,
 			&g.SenderType,
 			&g.Message,
 			&g.CreatedAt,
@@ -67,16 +85,107 @@ func (chatlog GuiderChatlogModel) GetList(userUuid uuid.UUID, journalId uuid.UUI
 }
 
 func (chatlog GuiderChatlogModel) Insert(chatLog *GuiderChatlog) (*GuiderChatlog, error) {
-	query := `INSERT INTO ai_guider_chatlog  (user_id, sender_type, message, journal_id)
-			  VALUES ($1, $2, $3, $4)
-			  RETURNING id, user_id, sender_type, message, journal_id, created_at`
+	query := `INSERT INTO ai_guider_chatlog  (user_id, sender_type, message, journal_id, thread_id)
+			  VALUES ($1, $2, $3, $4, $5)
+			  RETURNING id, user_id, sender_type, message, journal_id, thread_id, created_at`
 
-	argsResponse := []any{&chatLog.Id, &chatLog.UserId, &chatLog.SenderType, &chatLog.Message, &chatLog.JournalId, &chatLog.CreatedAt}
+	argsResponse := []any{&chatLog.Id, &chatLog.UserId, &chatLog.SenderType, &chatLog.Message, &chatLog.JournalId, &chatLog.ThreadId, &chatLog.CreatedAt}
 
 	context, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := chatlog.DB.QueryRowContext(context, query, chatLog.UserId, chatLog.SenderType, chatLog.Message, chatLog.JournalId).Scan(argsResponse...)
+	err := chatlog.DB.QueryRowContext(context, query, chatLog.UserId, chatLog.SenderType, chatLog.Message, chatLog.JournalId, chatLog.ThreadId).Scan(argsResponse...)
+	if err != nil {
+		return chatLog, err
+	}
+
+	if chatLog.ThreadId != nil {
+		_, err = chatlog.DB.ExecContext(context, `UPDATE chat_threads SET last_message_at = $1 WHERE id = $2`, chatLog.CreatedAt, *chatLog.ThreadId)
+	}
 
 	return chatLog, err
 }
+
+// GetThreadMessages returns a page of the messages in one of the user's threads.
+func (chatlog GuiderChatlogModel) GetThreadMessages(threadID, userID uuid.UUID, filter *QueryFilter) ([]*GuiderChatlog, Metadata, error) {
+	sort := filter.SortClause()
+	if sort == "" {
+		sort = "created_at ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), id, user_id, journal_id, thread_id, sender_type, message, created_at
+		FROM ai_guider_chatlog
+		WHERE thread_id = $1 AND user_id = $2
+		ORDER BY %s, id
+		LIMIT $3 OFFSET $4`, sort)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := chatlog.DB.QueryContext(ctx, query, threadID, userID, filter.Limit(), filter.Offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	messages := []*GuiderChatlog{}
+
+	for rows.Next() {
+		var g GuiderChatlog
+		err := rows.Scan(
+			&totalRecords,
+			&g.Id,
+			&g.UserId,
+			&g.JournalId,
+			&g.ThreadId,
+			&g.SenderType,
+			&g.Message,
+			&g.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		messages = append(messages, &g)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return messages, filter.CalculateMetadata(totalRecords), nil
+}
+
+// RecentThreadMessages returns the latest messages of a thread, oldest first.
+func (chatlog GuiderChatlogModel) RecentThreadMessages(threadID uuid.UUID, limit int) ([]*GuiderChatlog, error) {
+	query := `
+		SELECT id, user_id, journal_id, thread_id, sender_type, message, created_at
+		FROM (
+			SELECT * FROM ai_guider_chatlog
+			WHERE thread_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		) recent
+		ORDER BY created_at, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := chatlog.DB.QueryContext(ctx, query, threadID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*GuiderChatlog{}
+	for rows.Next() {
+		var g GuiderChatlog
+		err := rows.Scan(&g.Id, &g.UserId, &g.JournalId, &g.ThreadId, &g.SenderType, &g.Message, &g.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, &g)
+	}
+
+	return messages, rows.Err()
+}
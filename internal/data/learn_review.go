@@ -0,0 +1,258 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SM-2 tuning constants
+const (
+	DefaultEasinessFactor = 2.5
+	MinEasinessFactor     = 1.3
+	MinRecallQuality      = 0
+	MaxRecallQuality      = 5
+	// PassingRecallQuality is the lowest quality that counts as a successful recall.
+	PassingRecallQuality = 3
+	// FirstReviewDelay is how long after completing a slide group its first review is due.
+	FirstReviewDelay = 24 * time.Hour
+)
+
+// LearnReview is the spaced-repetition schedule of one completed learn slide group.
+type LearnReview struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	CollectionID    uuid.UUID  `json:"collection_id"`
+	CollectionTitle string     `json:"collection_title,omitempty"`
+	SlideGroupID    string     `json:"slide_group_id"`
+	SlideGroupTitle string     `json:"slide_group_title,omitempty"`
+	EasinessFactor  float64    `json:"easiness_factor"`
+	IntervalDays    int        `json:"interval_days"`
+	Repetitions     int        `json:"repetitions"`
+	ReviewCount     int        `json:"review_count"`
+	LastQuality     *int       `json:"last_quality,omitempty"`
+	LastReviewedAt  *time.Time `json:"last_reviewed_at,omitempty"`
+	DueAt           time.Time  `json:"due_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ApplyRecall updates the schedule with the SM-2 algorithm for a recall of the
+// given quality (0-5) made at reviewedAt.
+//
+// A failed recall (quality < 3) restarts the repetition sequence with a one day
+// interval. Successful recalls use intervals of 1 day, 6 days, and then the
+// previous interval multiplied by the easiness factor. The easiness factor is
+// adjusted after every review and never drops below 1.3.
+func (r *LearnReview) ApplyRecall(quality int, reviewedAt time.Time) {
+	if r.EasinessFactor == 0 {
+		r.EasinessFactor = DefaultEasinessFactor
+	}
+
+	if quality < PassingRecallQuality {
+		r.Repetitions = 0
+		r.IntervalDays = 1
+	} else {
+		switch r.Repetitions {
+		case 0:
+			r.IntervalDays = 1
+		case 1:
+			r.IntervalDays = 6
+		default:
+			r.IntervalDays = int(math.Round(float64(r.IntervalDays) * r.EasinessFactor))
+		}
+		r.Repetitions++
+	}
+
+	q := float64(MaxRecallQuality - quality)
+	r.EasinessFactor += 0.1 - q*(0.08+q*0.02)
+	if r.EasinessFactor < MinEasinessFactor {
+		r.EasinessFactor = MinEasinessFactor
+	}
+
+	r.ReviewCount++
+	r.LastQuality = &quality
+	r.LastReviewedAt = &reviewedAt
+	r.DueAt = reviewedAt.AddDate(0, 0, r.IntervalDays)
+}
+
+type LearnReviewModel struct {
+	DB *sql.DB
+}
+
+// Schedule creates the initial review for a completed slide group.
+// Completing the same slide group again keeps the existing schedule.
+func (m LearnReviewModel) Schedule(userID, collectionID uuid.UUID, slideGroupID string, dueAt time.Time) error {
+	query := `
+		INSERT INTO learn_reviews (user_id, collection_id, slide_group_id, easiness_factor, due_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, collection_id, slide_group_id) DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID, collectionID, slideGroupID, DefaultEasinessFactor, dueAt)
+	return err
+}
+
+// Get retrieves a single review by ID and user
+func (m LearnReviewModel) Get(id, userID uuid.UUID) (*LearnReview, error) {
+	query := `
+		SELECT id, user_id, collection_id, slide_group_id, easiness_factor, interval_days, repetitions,
+		       review_count, last_quality, last_reviewed_at, due_at, created_at, updated_at
+		FROM learn_reviews
+		WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var review LearnReview
+	err := m.DB.QueryRowContext(ctx, query, id, userID).Scan(
+		&review.ID,
+		&review.UserID,
+		&review.CollectionID,
+		&review.SlideGroupID,
+		&review.EasinessFactor,
+		&review.IntervalDays,
+		&review.Repetitions,
+		&review.ReviewCount,
+		&review.LastQuality,
+		&review.LastReviewedAt,
+		&review.DueAt,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// GetDue retrieves reviews due at or before the given time, oldest first.
+// Collection and slide group titles are resolved in the requested locale.
+func (m LearnReviewModel) GetDue(userID uuid.UUID, before time.Time, locale string, limit int) ([]*LearnReview, error) {
+	query := `
+		SELECT r.id, r.user_id, r.collection_id,
+		       CASE WHEN $3 = 'vi' AND COALESCE(t.title_vi, '') <> '' THEN t.title_vi ELSE t.title END,
+		       r.slide_group_id,
+		       COALESCE(
+		           (SELECT g->>'title' FROM jsonb_array_elements(
+		                CASE WHEN $3 = 'vi' AND jsonb_array_length(COALESCE(t.slide_groups_vi, '[]'::jsonb)) > 0
+		                     THEN t.slide_groups_vi ELSE t.slide_groups END) g
+		            WHERE g->>'id' = r.slide_group_id LIMIT 1),
+		           ''),
+		       r.easiness_factor, r.interval_days, r.repetitions, r.review_count,
+		       r.last_quality, r.last_reviewed_at, r.due_at, r.created_at, r.updated_at
+		FROM learn_reviews r
+		JOIN journal_templates t ON t.id = r.collection_id
+		WHERE r.user_id = $1 AND r.due_at <= $2 AND t.is_active = true
+		ORDER BY r.due_at ASC
+		LIMIT $4
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, before, locale, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*LearnReview{}
+	for rows.Next() {
+		var review LearnReview
+		err = rows.Scan(
+			&review.ID,
+			&review.UserID,
+			&review.CollectionID,
+			&review.CollectionTitle,
+			&review.SlideGroupID,
+			&review.SlideGroupTitle,
+			&review.EasinessFactor,
+			&review.IntervalDays,
+			&review.Repetitions,
+			&review.ReviewCount,
+			&review.LastQuality,
+			&review.LastReviewedAt,
+			&review.DueAt,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, &review)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// Update persists the schedule computed by ApplyRecall
+func (m LearnReviewModel) Update(review *LearnReview) error {
+	query := `
+		UPDATE learn_reviews
+		SET easiness_factor = $1, interval_days = $2, repetitions = $3, review_count = $4,
+		    last_quality = $5, last_reviewed_at = $6, due_at = $7
+		WHERE id = $8 AND user_id = $9
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query,
+		review.EasinessFactor,
+		review.IntervalDays,
+		review.Repetitions,
+		review.ReviewCount,
+		review.LastQuality,
+		review.LastReviewedAt,
+		review.DueAt,
+		review.ID,
+		review.UserID,
+	).Scan(&review.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// DeleteForLearned removes the review schedule belonging to a completion record.
+// Call it before deleting the completion record itself.
+func (m LearnReviewModel) DeleteForLearned(learnedID, userID uuid.UUID) error {
+	query := `
+		DELETE FROM learn_reviews r
+		USING user_learned_slide_groups l
+		WHERE l.id = $1 AND l.user_id = $2
+		  AND r.user_id = l.user_id
+		  AND r.collection_id = l.collection_id
+		  AND r.slide_group_id = l.slide_group_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, learnedID, userID)
+	return err
+}
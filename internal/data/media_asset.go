@@ -0,0 +1,379 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"tranquara.net/internal/validator"
+)
+
+const (
+	MediaKindAudio = "audio"
+	MediaKindVideo = "video"
+
+	// DefaultMediaLocale is served when an asset has no variant for the requested locale.
+	DefaultMediaLocale = "en"
+)
+
+var (
+	ErrUnknownMediaAsset = errors.New("unknown media asset")
+
+	// MediaLocaleRX matches locales such as "en", "vi" or "pt-BR".
+	MediaLocaleRX = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+)
+
+// MediaAsset is a piece of audio or video content with one variant per locale.
+type MediaAsset struct {
+	ID        uuid.UUID       `json:"id"`
+	Title     string          `json:"title"`
+	Kind      string          `json:"kind"`
+	Variants  []*MediaVariant `json:"variants"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// MediaVariant is the stored file of an asset for one locale.
+type MediaVariant struct {
+	ID              uuid.UUID `json:"id"`
+	AssetID         uuid.UUID `json:"asset_id"`
+	Locale          string    `json:"locale"`
+	MimeType        string    `json:"mime_type"`
+	StorageKey      string    `json:"-"`
+	SizeBytes       int64     `json:"size_bytes"`
+	Checksum        string    `json:"checksum"`
+	DurationSeconds *int      `json:"duration_seconds"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func ValidateMediaAsset(v *validator.Validator, a *MediaAsset) {
+	v.Check(a.Title != "", "title", "must be provided")
+	v.Check(len(a.Title) <= 255, "title", "must not be more than 255 bytes long")
+	v.Check(validator.In(a.Kind, MediaKindAudio, MediaKindVideo), "kind", "must be one of audio, video")
+}
+
+func ValidateMediaVariant(v *validator.Validator, kind string, mv *MediaVariant) {
+	v.Check(validator.Matches(mv.Locale, MediaLocaleRX), "locale", "must be a locale such as en or vi")
+	v.Check(strings.HasPrefix(mv.MimeType, kind+"/"), "content_type", fmt.Sprintf("must match the asset kind (%s/*)", kind))
+	v.Check(len(mv.MimeType) <= 100, "content_type", "must not be more than 100 bytes long")
+	if mv.DurationSeconds != nil {
+		v.Check(*mv.DurationSeconds > 0, "duration_seconds", "must be greater than zero")
+	}
+}
+
+// Variant picks the variant to serve for a locale: an exact match, then the same
+// language, then the default locale, then whichever was uploaded first.
+func (a *MediaAsset) Variant(locale string) *MediaVariant {
+	if len(a.Variants) == 0 {
+		return nil
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	var sameLanguage, fallback *MediaVariant
+	for _, mv := range a.Variants {
+		variantLanguage, _, _ := strings.Cut(mv.Locale, "-")
+		switch {
+		case mv.Locale == locale:
+			return mv
+		case sameLanguage == nil && variantLanguage == language:
+			sameLanguage = mv
+		case fallback == nil && mv.Locale == DefaultMediaLocale:
+			fallback = mv
+		}
+	}
+
+	switch {
+	case sameLanguage != nil:
+		return sameLanguage
+	case fallback != nil:
+		return fallback
+	default:
+		return a.Variants[0]
+	}
+}
+
+type MediaAssetModel struct {
+	DB *sql.DB
+}
+
+const mediaAssetColumns = `id, title, kind, created_at, updated_at`
+
+const mediaVariantColumns = `id, asset_id, locale, mime_type, storage_key, size_bytes, checksum, duration_seconds, created_at`
+
+func scanMediaAsset(row interface{ Scan(...any) error }, prefix ...any) (*MediaAsset, error) {
+	a := MediaAsset{Variants: []*MediaVariant{}}
+	dest := append(prefix,
+		&a.ID,
+		&a.Title,
+		&a.Kind,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func scanMediaVariant(row interface{ Scan(...any) error }) (*MediaVariant, error) {
+	var mv MediaVariant
+	err := row.Scan(
+		&mv.ID,
+		&mv.AssetID,
+		&mv.Locale,
+		&mv.MimeType,
+		&mv.StorageKey,
+		&mv.SizeBytes,
+		&mv.Checksum,
+		&mv.DurationSeconds,
+		&mv.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &mv, nil
+}
+
+func (m MediaAssetModel) Insert(a *MediaAsset) (*MediaAsset, error) {
+	query := `
+		INSERT INTO media_assets (title, kind)
+		VALUES ($1, $2)
+		RETURNING ` + mediaAssetColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return scanMediaAsset(m.DB.QueryRowContext(ctx, query, a.Title, a.Kind))
+}
+
+// Get returns the asset with its variants.
+func (m MediaAssetModel) Get(id uuid.UUID) (*MediaAsset, error) {
+	query := `SELECT ` + mediaAssetColumns + ` FROM media_assets WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	a, err := scanMediaAsset(m.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	err = m.loadVariants(ctx, []*MediaAsset{a})
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// GetList returns a page of assets with their variants, optionally of one kind.
+func (m MediaAssetModel) GetList(kind string, filter *QueryFilter) ([]*MediaAsset, Metadata, error) {
+	var queryBuilder strings.Builder
+	var args []interface{}
+	paramIndex := 1
+
+	queryBuilder.WriteString(`
+		SELECT COUNT(*) OVER(), ` + mediaAssetColumns + `
+		FROM media_assets
+		WHERE 1 = 1
+	`)
+
+	if kind != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND kind = $%d", paramIndex))
+		args = append(args, kind)
+		paramIndex++
+	}
+
+	if filter.SortClause() != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s, id", filter.SortClause()))
+	} else {
+		queryBuilder.WriteString(" ORDER BY created_at DESC, id")
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1))
+	args = append(args, filter.Limit(), filter.Offset())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	assets := []*MediaAsset{}
+
+	for rows.Next() {
+		a, err := scanMediaAsset(rows, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		assets = append(assets, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	err = m.loadVariants(ctx, assets)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return assets, filter.CalculateMetadata(totalRecords), nil
+}
+
+func (m MediaAssetModel) Update(a *MediaAsset) (*MediaAsset, error) {
+	query := `
+		UPDATE media_assets
+		SET title = $1
+		WHERE id = $2
+		RETURNING ` + mediaAssetColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	updated, err := scanMediaAsset(m.DB.QueryRowContext(ctx, query, a.Title, a.ID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	updated.Variants = a.Variants
+
+	return updated, nil
+}
+
+// Delete removes the asset and its variants and returns the storage keys of the
+// variant files, which the caller deletes from storage.
+func (m MediaAssetModel) Delete(id uuid.UUID) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var keys []string
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(array_agg(storage_key), '{}')
+		FROM media_asset_variants
+		WHERE asset_id = $1`, id).Scan(pq.Array(&keys))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM media_assets WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return keys, tx.Commit()
+}
+
+// UpsertVariant records the uploaded file for the variant's locale, replacing any
+// previous upload. It returns the storage key of the replaced file, if any.
+func (m MediaAssetModel) UpsertVariant(mv *MediaVariant) (string, error) {
+	query := `
+		WITH previous AS (
+			SELECT storage_key FROM media_asset_variants WHERE asset_id = $1 AND locale = $2
+		)
+		INSERT INTO media_asset_variants (asset_id, locale, mime_type, storage_key, size_bytes, checksum, duration_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (asset_id, locale) DO UPDATE
+		SET mime_type = EXCLUDED.mime_type, storage_key = EXCLUDED.storage_key,
+		    size_bytes = EXCLUDED.size_bytes, checksum = EXCLUDED.checksum,
+		    duration_seconds = EXCLUDED.duration_seconds, created_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, COALESCE((SELECT storage_key FROM previous), '')`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var previousKey string
+	err := m.DB.QueryRowContext(ctx, query,
+		mv.AssetID, mv.Locale, mv.MimeType, mv.StorageKey, mv.SizeBytes, mv.Checksum, mv.DurationSeconds,
+	).Scan(&mv.ID, &mv.CreatedAt, &previousKey)
+	if err != nil {
+		if strings.Contains(err.Error(), "fk_media_asset_variants_asset") {
+			return "", ErrRecordNotFound
+		}
+		return "", err
+	}
+
+	return previousKey, nil
+}
+
+// DeleteVariant removes the asset's variant for a locale and returns its storage key.
+func (m MediaAssetModel) DeleteVariant(assetID uuid.UUID, locale string) (string, error) {
+	query := `
+		DELETE FROM media_asset_variants
+		WHERE asset_id = $1 AND locale = $2
+		RETURNING storage_key`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var key string
+	err := m.DB.QueryRowContext(ctx, query, assetID, locale).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrRecordNotFound
+	}
+	return key, err
+}
+
+func (m MediaAssetModel) loadVariants(ctx context.Context, assets []*MediaAsset) error {
+	if len(assets) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*MediaAsset, len(assets))
+	ids := make([]string, 0, len(assets))
+	for _, a := range assets {
+		byID[a.ID] = a
+		ids = append(ids, a.ID.String())
+	}
+
+	query := `
+		SELECT ` + mediaVariantColumns + `
+		FROM media_asset_variants
+		WHERE asset_id = ANY($1::uuid[])
+		ORDER BY created_at, locale`
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		mv, err := scanMediaVariant(rows)
+		if err != nil {
+			return err
+		}
+		byID[mv.AssetID].Variants = append(byID[mv.AssetID].Variants, mv)
+	}
+
+	return rows.Err()
+}
@@ -16,13 +16,27 @@ type Models struct {
 	UserInformation       UserInformationModel
 	GuiderChatlog         GuiderChatlogModel
 	UserStreak            UserStreakModel
+	StreakEvent           StreakEventModel
+	Achievement           AchievementModel
+	Goal                  GoalModel
+	Program               ProgramModel
+	ExerciseSession       ExerciseSessionModel
+	ExerciseFavourite     ExerciseFavouriteModel
+	Routine               RoutineModel
+	MediaAsset            MediaAssetModel
+	ChatThread            ChatThreadModel
 	EmotionLog            EmotionLogModel
 	UserJournal           UserJournalModel
 	UserLearnedSlideGroup UserLearnedSlideGroupModel
+	LearnReview           LearnReviewModel
+	QuizAttempt           QuizAttemptModel
 	AIMemory              AIMemoryModel
 	TherapySession        TherapySessionModel
 	HomeworkItem          HomeworkItemModel
 	PrepPack              PrepPackModel
+	Nudge                 NudgeModel
+	SleepEntry            SleepEntryModel
+	ActivityEvent         ActivityEventModel
 }
 
 func NewModels(db *sql.DB) Models {
@@ -33,13 +47,27 @@ func NewModels(db *sql.DB) Models {
 		UserInformation:       UserInformationModel{DB: db},
 		GuiderChatlog:         GuiderChatlogModel{DB: db},
 		UserStreak:            UserStreakModel{DB: db},
+		StreakEvent:           StreakEventModel{DB: db},
+		Achievement:           AchievementModel{DB: db},
+		Goal:                  GoalModel{DB: db},
+		Program:               ProgramModel{DB: db},
+		ExerciseSession:       ExerciseSessionModel{DB: db},
+		ExerciseFavourite:     ExerciseFavouriteModel{DB: db},
+		Routine:               RoutineModel{DB: db},
+		MediaAsset:            MediaAssetModel{DB: db},
+		ChatThread:            ChatThreadModel{DB: db},
 		EmotionLog:            EmotionLogModel{DB: db},
 		UserJournal:           UserJournalModel{DB: db},
 		UserLearnedSlideGroup: UserLearnedSlideGroupModel{DB: db},
+		LearnReview:           LearnReviewModel{DB: db},
+		QuizAttempt:           QuizAttemptModel{DB: db},
 		AIMemory:              AIMemoryModel{DB: db},
 		TherapySession:        TherapySessionModel{DB: db},
 		HomeworkItem:          HomeworkItemModel{DB: db},
 		PrepPack:              PrepPackModel{DB: db},
+		Nudge:                 NudgeModel{DB: db},
+		SleepEntry:            SleepEntryModel{DB: db},
+		ActivityEvent:         ActivityEventModel{DB: db},
 	}
 
 }
@@ -0,0 +1,392 @@
+package data
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Insight bucket sizes
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// InsightRanges maps the supported range values to their length in days.
+var InsightRanges = map[string]int{
+	"7d":   7,
+	"30d":  30,
+	"90d":  90,
+	"180d": 180,
+	"365d": 365,
+}
+
+// movingAverageWindow is how many buckets (current included) the moving average spans.
+var movingAverageWindow = map[string]int{
+	BucketDay:   7,
+	BucketWeek:  4,
+	BucketMonth: 3,
+}
+
+// topEmotionsLimit is how many of the most frequent emotions are reported.
+const topEmotionsLimit = 5
+
+// MoodStats summarizes a set of mood scores (1-10).
+type MoodStats struct {
+	Count    int      `json:"count"`
+	Average  *float64 `json:"average"`
+	Min      *int     `json:"min"`
+	Max      *int     `json:"max"`
+	Variance *float64 `json:"variance"`
+}
+
+// MoodBucket is one day, week or month of the chart, starting at Start in the user's timezone.
+type MoodBucket struct {
+	Start         time.Time `json:"start"`
+	Mood          MoodStats `json:"mood"`
+	MovingAverage *float64  `json:"moving_average"`
+	Journals      int       `json:"journals"`
+	EmotionLogs   int       `json:"emotion_logs"`
+	// AverageIntensity is the mean intensity of all emotions logged in the bucket.
+	AverageIntensity *float64 `json:"average_intensity"`
+}
+
+// EmotionFrequency is how often an emotion was logged in the range.
+type EmotionFrequency struct {
+	Code             string   `json:"code"`
+	Label            string   `json:"label"`
+	Count            int      `json:"count"`
+	AverageIntensity *float64 `json:"average_intensity"`
+}
+
+// PeriodDelta compares the last 7 days against the 7 days before.
+type PeriodDelta struct {
+	Current  float64  `json:"current"`
+	Previous float64  `json:"previous"`
+	Delta    float64  `json:"delta"`
+	Percent  *float64 `json:"percent"`
+}
+
+// WeekOverWeek holds week-over-week deltas across journals and emotion logs.
+type WeekOverWeek struct {
+	Journals         PeriodDelta  `json:"journals"`
+	EmotionLogs      PeriodDelta  `json:"emotion_logs"`
+	AverageMood      *PeriodDelta `json:"average_mood"`
+	AverageIntensity *PeriodDelta `json:"average_intensity"`
+}
+
+// MoodInsights is the response of the mood analytics endpoint.
+type MoodInsights struct {
+	Range        string             `json:"range"`
+	Bucket       string             `json:"bucket"`
+	Timezone     string             `json:"timezone"`
+	Start        time.Time          `json:"start"`
+	End          time.Time          `json:"end"`
+	Mood         MoodStats          `json:"mood"`
+	Buckets      []MoodBucket       `json:"buckets"`
+	TopEmotions  []EmotionFrequency `json:"top_emotions"`
+	WeekOverWeek WeekOverWeek       `json:"week_over_week"`
+}
+
+// MoodInsightsOptions describes the requested window.
+type MoodInsightsOptions struct {
+	Range    string
+	Bucket   string
+	Location *time.Location
+	Locale   string
+	Now      time.Time
+}
+
+// InsightsStart returns the earliest time BuildMoodInsights needs data from: the
+// start of the first bucket or the start of the previous week, whichever is earlier.
+func InsightsStart(opts MoodInsightsOptions) time.Time {
+	start := bucketStart(RangeStart(opts), opts.Bucket)
+	previousWeek := opts.Now.AddDate(0, 0, -14)
+	if previousWeek.Before(start) {
+		return previousWeek
+	}
+	return start
+}
+
+// RangeStart returns local midnight of the first day (or night) included in the range.
+func RangeStart(opts MoodInsightsOptions) time.Time {
+	now := opts.Now.In(opts.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, opts.Location)
+	return today.AddDate(0, 0, -(InsightRanges[opts.Range] - 1))
+}
+
+// bucketStart truncates t to the start of its day, ISO week (Monday) or month in t's location.
+func bucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch bucket {
+	case BucketWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return day
+	}
+}
+
+func nextBucket(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// BuildMoodInsights aggregates journal mood scores and emotion logs into chart
+// buckets in the user's timezone. points and logs may start before the range;
+// only the week-over-week comparison looks at data outside it.
+func BuildMoodInsights(points []MoodPoint, logs []*EmotionLog, opts MoodInsightsOptions) MoodInsights {
+	loc := opts.Location
+	start := RangeStart(opts)
+	end := opts.Now
+
+	insights := MoodInsights{
+		Range:    opts.Range,
+		Bucket:   opts.Bucket,
+		Timezone: loc.String(),
+		Start:    start,
+		End:      end.In(loc),
+	}
+
+	// Buckets cover the range; the first one may begin before the range start
+	type accumulator struct {
+		scores      []int
+		emotionLogs int
+		intensities []int
+	}
+	var starts []time.Time
+	index := map[time.Time]int{}
+	for s := bucketStart(start, opts.Bucket); s.Before(end); s = nextBucket(s, opts.Bucket) {
+		index[s] = len(starts)
+		starts = append(starts, s)
+	}
+	acc := make([]accumulator, len(starts))
+
+	inRange := func(t time.Time) bool { return !t.Before(start) && !t.After(end) }
+
+	var allScores []int
+	for _, p := range points {
+		if !inRange(p.At) {
+			continue
+		}
+		allScores = append(allScores, p.Score)
+		if i, ok := index[bucketStart(p.At.In(loc), opts.Bucket)]; ok {
+			acc[i].scores = append(acc[i].scores, p.Score)
+		}
+	}
+
+	emotionCounts := map[string]int{}
+	emotionIntensities := map[string][]int{}
+	for _, l := range logs {
+		if !inRange(l.CreatedAt) {
+			continue
+		}
+		i, hasBucket := index[bucketStart(l.CreatedAt.In(loc), opts.Bucket)]
+		if hasBucket {
+			acc[i].emotionLogs++
+		}
+		for _, e := range emotionEntries(l) {
+			emotionCounts[e.Code]++
+			if e.Intensity > 0 {
+				emotionIntensities[e.Code] = append(emotionIntensities[e.Code], e.Intensity)
+				if hasBucket {
+					acc[i].intensities = append(acc[i].intensities, e.Intensity)
+				}
+			}
+		}
+	}
+
+	insights.Mood = moodStats(allScores)
+
+	window := movingAverageWindow[opts.Bucket]
+	insights.Buckets = make([]MoodBucket, len(starts))
+	for i, s := range starts {
+		var windowScores []int
+		for j := max(0, i-window+1); j <= i; j++ {
+			windowScores = append(windowScores, acc[j].scores...)
+		}
+
+		insights.Buckets[i] = MoodBucket{
+			Start:            s,
+			Mood:             moodStats(acc[i].scores),
+			MovingAverage:    averageOf(windowScores),
+			Journals:         len(acc[i].scores),
+			EmotionLogs:      acc[i].emotionLogs,
+			AverageIntensity: averageOf(acc[i].intensities),
+		}
+	}
+
+	insights.TopEmotions = topEmotions(emotionCounts, emotionIntensities, opts.Locale)
+	insights.WeekOverWeek = weekOverWeek(points, logs, opts.Now)
+
+	return insights
+}
+
+// emotionEntries returns a log's emotions; legacy logs count their free-text
+// emotion once, without an intensity.
+func emotionEntries(l *EmotionLog) []EmotionEntry {
+	if len(l.Emotions) > 0 {
+		return l.Emotions
+	}
+	if l.Emotion == "" {
+		return nil
+	}
+	code, ok := NormalizeEmotionCode(l.Emotion)
+	if !ok {
+		code = strings.ToLower(strings.TrimSpace(l.Emotion))
+	}
+	return []EmotionEntry{{Code: code}}
+}
+
+func topEmotions(counts map[string]int, intensities map[string][]int, locale string) []EmotionFrequency {
+	frequencies := make([]EmotionFrequency, 0, len(counts))
+	for code, count := range counts {
+		label := code
+		if node, ok := LookupEmotion(code); ok {
+			label = node.Label
+			if locale == "vi" && node.LabelVi != "" {
+				label = node.LabelVi
+			}
+		}
+
+		frequencies = append(frequencies, EmotionFrequency{
+			Code:             code,
+			Label:            label,
+			Count:            count,
+			AverageIntensity: averageOf(intensities[code]),
+		})
+	}
+
+	sort.Slice(frequencies, func(i, j int) bool {
+		if frequencies[i].Count != frequencies[j].Count {
+			return frequencies[i].Count > frequencies[j].Count
+		}
+		return frequencies[i].Code < frequencies[j].Code
+	})
+
+	if len(frequencies) > topEmotionsLimit {
+		frequencies = frequencies[:topEmotionsLimit]
+	}
+
+	return frequencies
+}
+
+func weekOverWeek(points []MoodPoint, logs []*EmotionLog, now time.Time) WeekOverWeek {
+	currentStart := now.AddDate(0, 0, -7)
+	previousStart := now.AddDate(0, 0, -14)
+
+	// period returns 0 for the current week, 1 for the previous one and -1 otherwise
+	period := func(t time.Time) int {
+		switch {
+		case !t.Before(currentStart) && !t.After(now):
+			return 0
+		case !t.Before(previousStart) && t.Before(currentStart):
+			return 1
+		default:
+			return -1
+		}
+	}
+
+	var scores, intensities [2][]int
+	var emotionLogs [2]int
+	for _, p := range points {
+		if i := period(p.At); i >= 0 {
+			scores[i] = append(scores[i], p.Score)
+		}
+	}
+	for _, l := range logs {
+		i := period(l.CreatedAt)
+		if i < 0 {
+			continue
+		}
+		emotionLogs[i]++
+		for _, e := range l.Emotions {
+			intensities[i] = append(intensities[i], e.Intensity)
+		}
+	}
+
+	wow := WeekOverWeek{
+		Journals:    periodDelta(float64(len(scores[0])), float64(len(scores[1]))),
+		EmotionLogs: periodDelta(float64(emotionLogs[0]), float64(emotionLogs[1])),
+	}
+
+	if current, previous := averageOf(scores[0]), averageOf(scores[1]); current != nil && previous != nil {
+		delta := periodDelta(*current, *previous)
+		wow.AverageMood = &delta
+	}
+	if current, previous := averageOf(intensities[0]), averageOf(intensities[1]); current != nil && previous != nil {
+		delta := periodDelta(*current, *previous)
+		wow.AverageIntensity = &delta
+	}
+
+	return wow
+}
+
+func periodDelta(current, previous float64) PeriodDelta {
+	d := PeriodDelta{
+		Current:  round2(current),
+		Previous: round2(previous),
+		Delta:    round2(current - previous),
+	}
+	if previous != 0 {
+		percent := round2((current - previous) / previous * 100)
+		d.Percent = &percent
+	}
+	return d
+}
+
+func moodStats(scores []int) MoodStats {
+	stats := MoodStats{Count: len(scores)}
+	if len(scores) == 0 {
+		return stats
+	}
+
+	minScore, maxScore := scores[0], scores[0]
+	sum := 0.0
+	for _, s := range scores {
+		minScore = min(minScore, s)
+		maxScore = max(maxScore, s)
+		sum += float64(s)
+	}
+	mean := sum / float64(len(scores))
+
+	squares := 0.0
+	for _, s := range scores {
+		squares += (float64(s) - mean) * (float64(s) - mean)
+	}
+
+	average := round2(mean)
+	variance := round2(squares / float64(len(scores)))
+	stats.Average = &average
+	stats.Min = &minScore
+	stats.Max = &maxScore
+	stats.Variance = &variance
+
+	return stats
+}
+
+func averageOf(values []int) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	average := round2(float64(sum) / float64(len(values)))
+	return &average
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,253 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Nudge kinds and actions
+const (
+	NudgeKindMoodDecline = "mood_decline"
+
+	NudgeActionOpenSelfCare       = "open_self_care"
+	NudgeActionBookTherapySession = "book_therapy_session"
+)
+
+// NudgeCooldown is the minimum time between two nudges of the same kind for a user.
+const NudgeCooldown = 72 * time.Hour
+
+// nudgeCopy holds the text of each nudge kind; text is rendered on read so it
+// follows the locale of the request rather than the one active when it was created.
+var nudgeCopy = map[string]struct {
+	title, message, titleVi, messageVi string
+}{
+	NudgeKindMoodDecline: {
+		title:     "Checking in on you",
+		message:   "Things seem heavier than usual lately. Taking a few minutes for yourself might help.",
+		titleVi:   "Bạn dạo này thế nào?",
+		messageVi: "Dạo gần đây mọi thứ có vẻ nặng nề hơn thường lệ. Dành vài phút cho bản thân có thể giúp bạn thấy nhẹ nhõm hơn.",
+	},
+}
+
+// Nudge is an in-app message raised by the server for a user.
+type Nudge struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Kind      string          `json:"kind"`
+	Title     string          `json:"title"`
+	Message   string          `json:"message"`
+	Action    string          `json:"action,omitempty"`
+	Details   json.RawMessage `json:"details"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ApplyLocale fills in the title and message for the nudge kind in the given locale.
+func (n *Nudge) ApplyLocale(locale string) {
+	text, ok := nudgeCopy[n.Kind]
+	if !ok {
+		return
+	}
+
+	n.Title, n.Message = text.title, text.message
+	if locale == "vi" {
+		n.Title, n.Message = text.titleVi, text.messageVi
+	}
+}
+
+type NudgeModel struct {
+	DB *sql.DB
+}
+
+// Insert records a new nudge
+func (m NudgeModel) Insert(nudge *Nudge) (*Nudge, error) {
+	query := `
+		INSERT INTO nudges (user_id, kind, action, details)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if nudge.Details == nil {
+		nudge.Details = json.RawMessage(`{}`)
+	}
+
+	err := m.DB.QueryRowContext(ctx, query,
+		nudge.UserID,
+		nudge.Kind,
+		nudge.Action,
+		[]byte(nudge.Details),
+	).Scan(
+		&nudge.ID,
+		&nudge.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return nudge, nil
+}
+
+// InsertUnlessSince records the nudge unless the user already got one of the same kind
+// since the given time, and reports whether it was recorded. The check and the insert
+// run under a per-user advisory lock so concurrent analyses raise a single nudge.
+func (m NudgeModel) InsertUnlessSince(nudge *Nudge, since time.Time) (*Nudge, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if nudge.Details == nil {
+		nudge.Details = json.RawMessage(`{}`)
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text || ':' || $2))`, nudge.UserID, nudge.Kind)
+	if err != nil {
+		return nil, false, err
+	}
+
+	query := `
+		INSERT INTO nudges (user_id, kind, action, details)
+		SELECT $1, $2, NULLIF($3, ''), $4
+		WHERE NOT EXISTS (
+			SELECT 1 FROM nudges
+			WHERE user_id = $1 AND kind = $2 AND created_at >= $5
+		)
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		nudge.UserID,
+		nudge.Kind,
+		nudge.Action,
+		[]byte(nudge.Details),
+		since,
+	).Scan(
+		&nudge.ID,
+		&nudge.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return nudge, true, nil
+}
+
+// ExistsSince reports whether the user received a nudge of the given kind since the given time
+func (m NudgeModel) ExistsSince(userID uuid.UUID, kind string, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM nudges
+			WHERE user_id = $1 AND kind = $2 AND created_at >= $3
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := m.DB.QueryRowContext(ctx, query, userID, kind, since).Scan(&exists)
+
+	return exists, err
+}
+
+// GetAllByUser retrieves the user's nudges, newest first
+func (m NudgeModel) GetAllByUser(userID uuid.UUID, unreadOnly bool) ([]*Nudge, error) {
+	query := `
+		SELECT id, user_id, kind, COALESCE(action, ''), details, read_at, created_at
+		FROM nudges
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT 50
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nudges := []*Nudge{}
+	for rows.Next() {
+		var n Nudge
+		var details []byte
+		err = rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Kind,
+			&n.Action,
+			&details,
+			&n.ReadAt,
+			&n.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		n.Details = json.RawMessage(details)
+		nudges = append(nudges, &n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return nudges, nil
+}
+
+// MarkRead marks a nudge as read; reading it again keeps the first read time
+func (m NudgeModel) MarkRead(id, userID uuid.UUID) (*Nudge, error) {
+	query := `
+		UPDATE nudges
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+		RETURNING id, user_id, kind, COALESCE(action, ''), details, read_at, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var n Nudge
+	var details []byte
+	err := m.DB.QueryRowContext(ctx, query, id, userID).Scan(
+		&n.ID,
+		&n.UserID,
+		&n.Kind,
+		&n.Action,
+		&details,
+		&n.ReadAt,
+		&n.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	n.Details = json.RawMessage(details)
+
+	return &n, nil
+}
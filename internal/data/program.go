@@ -0,0 +1,448 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Program item types
+const (
+	ProgramItemExercise = "exercise"
+	ProgramItemTemplate = "template"
+	ProgramItemLearn    = "learn"
+)
+
+// Program is a guided sequence of days. DurationDays is the highest day number;
+// days without an entry are rest days.
+type Program struct {
+	ID            uuid.UUID     `json:"id"`
+	Title         string        `json:"title"`
+	TitleVi       *string       `json:"-"`
+	Description   string        `json:"description"`
+	DescriptionVi *string       `json:"-"`
+	DurationDays  int           `json:"duration_days"`
+	IsActive      bool          `json:"is_active"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Days          []*ProgramDay `json:"days,omitempty"`
+	// Enrollment is the requesting user's enrollment, when listed for a user.
+	Enrollment *ProgramEnrollment `json:"enrollment,omitempty"`
+}
+
+type ProgramDay struct {
+	DayNumber     int            `json:"day_number"`
+	Week          int            `json:"week"`
+	Title         string         `json:"title"`
+	TitleVi       *string        `json:"-"`
+	Description   string         `json:"description"`
+	DescriptionVi *string        `json:"-"`
+	Items         []*ProgramItem `json:"items"`
+}
+
+// ProgramItem is one exercise, journal template or learn collection of a program day.
+type ProgramItem struct {
+	ID       uuid.UUID `json:"id"`
+	Position int       `json:"position"`
+	ItemType string    `json:"item_type"`
+	RefID    uuid.UUID `json:"ref_id"`
+	Title    string    `json:"title"`
+	TitleVi  *string   `json:"-"`
+}
+
+// ProgramEnrollment is a user's enrollment; StartDate (YYYY-MM-DD) is day 1 in the user's timezone.
+type ProgramEnrollment struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	ProgramID     uuid.UUID  `json:"program_id"`
+	StartDate     string     `json:"start_date"`
+	EnrolledAt    time.Time  `json:"enrolled_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CompletedDays []int      `json:"completed_days"`
+}
+
+// ProgramToday is the user's current step in a program.
+type ProgramToday struct {
+	Program      *Program           `json:"program"`
+	Enrollment   *ProgramEnrollment `json:"enrollment"`
+	Day          *ProgramDay        `json:"day"`
+	DayCompleted bool               `json:"day_completed"`
+	UnlockedDays int                `json:"unlocked_days"`
+	// NextUnlockAt is the local midnight at which the next day unlocks; nil once every day is unlocked.
+	NextUnlockAt *time.Time `json:"next_unlock_at"`
+	Completed    bool       `json:"completed"`
+}
+
+// ApplyLocale swaps in the Vietnamese titles and descriptions of the program, its
+// days and items when available.
+func (p *Program) ApplyLocale(locale string) {
+	if locale != "vi" {
+		return
+	}
+	p.Title, p.Description = localized(p.Title, p.TitleVi, p.Description, p.DescriptionVi)
+	for _, d := range p.Days {
+		d.Title, d.Description = localized(d.Title, d.TitleVi, d.Description, d.DescriptionVi)
+		for _, item := range d.Items {
+			if item.TitleVi != nil && *item.TitleVi != "" {
+				item.Title = *item.TitleVi
+			}
+		}
+	}
+}
+
+func localized(title string, titleVi *string, description string, descriptionVi *string) (string, string) {
+	if titleVi != nil && *titleVi != "" {
+		title = *titleVi
+	}
+	if descriptionVi != nil && *descriptionVi != "" {
+		description = *descriptionVi
+	}
+	return title, description
+}
+
+// UnlockedDays returns how many days of a program lasting totalDays are unlocked
+// on now's date in loc: day 1 on the start date, one more each following day.
+func (e *ProgramEnrollment) UnlockedDays(totalDays int, now time.Time, loc *time.Location) int {
+	start, err := time.Parse(SleepDateLayout, e.StartDate)
+	if err != nil {
+		return 0
+	}
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	return max(0, min(dayCount(start, today)+1, totalDays))
+}
+
+// Today works out the user's current step: the first unlocked day not yet completed,
+// or the latest unlocked day when the user is caught up.
+func (p *Program) Today(e *ProgramEnrollment, now time.Time, loc *time.Location) *ProgramToday {
+	today := &ProgramToday{
+		Program:      p,
+		Enrollment:   e,
+		UnlockedDays: e.UnlockedDays(p.DurationDays, now, loc),
+		Completed:    e.CompletedAt != nil,
+	}
+
+	completed := make(map[int]bool, len(e.CompletedDays))
+	for _, day := range e.CompletedDays {
+		completed[day] = true
+	}
+
+	for _, d := range p.Days {
+		if d.DayNumber > today.UnlockedDays {
+			break
+		}
+		today.Day = d
+		today.DayCompleted = completed[d.DayNumber]
+		if !today.DayCompleted {
+			break
+		}
+	}
+
+	if today.UnlockedDays < p.DurationDays {
+		local := now.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+		today.NextUnlockAt = &next
+	}
+
+	return today
+}
+
+// Day returns the program day with the given number, or nil for a rest or unknown day.
+func (p *Program) Day(dayNumber int) *ProgramDay {
+	for _, d := range p.Days {
+		if d.DayNumber == dayNumber {
+			return d
+		}
+	}
+	return nil
+}
+
+type ProgramModel struct {
+	DB *sql.DB
+}
+
+const programColumns = `p.id, p.title, p.title_vi, COALESCE(p.description, ''), p.description_vi,
+	COALESCE((SELECT MAX(d.day_number) FROM program_days d WHERE d.program_id = p.id), 0),
+	p.is_active, p.created_at, p.updated_at`
+
+func scanProgram(row interface{ Scan(...any) error }) (*Program, error) {
+	var p Program
+	err := row.Scan(
+		&p.ID,
+		&p.Title,
+		&p.TitleVi,
+		&p.Description,
+		&p.DescriptionVi,
+		&p.DurationDays,
+		&p.IsActive,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetAll returns the active programs, or only those the user is enrolled in, with
+// the user's enrollment attached.
+func (m ProgramModel) GetAll(userID uuid.UUID, enrolledOnly bool) ([]*Program, error) {
+	query := `
+		SELECT ` + programColumns + `
+		FROM programs p
+		WHERE (p.is_active AND NOT $2)
+		   OR EXISTS (SELECT 1 FROM program_enrollments e WHERE e.program_id = p.id AND e.user_id = $1)
+		ORDER BY p.created_at, p.title`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, enrolledOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	programs := []*Program{}
+	for rows.Next() {
+		p, err := scanProgram(rows)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range programs {
+		p.Enrollment, err = m.GetEnrollment(userID, p.ID)
+		if err != nil && !errors.Is(err, ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return programs, nil
+}
+
+// Get returns a program with its days and their items in order.
+func (m ProgramModel) Get(id uuid.UUID) (*Program, error) {
+	query := `SELECT ` + programColumns + ` FROM programs p WHERE p.id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := scanProgram(m.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	days := `
+		SELECT d.day_number, COALESCE(d.title, ''), d.title_vi, COALESCE(d.description, ''), d.description_vi,
+		       i.id, i.position,
+		       CASE WHEN i.exercise_id IS NOT NULL THEN 'exercise'
+		            WHEN t.type = 'learn' THEN 'learn'
+		            ELSE 'template' END,
+		       COALESCE(i.exercise_id, i.template_id),
+		       COALESCE(e.title, t.title),
+		       COALESCE(e.title_vi, t.title_vi)
+		FROM program_days d
+		LEFT JOIN program_day_items i ON i.program_id = d.program_id AND i.day_number = d.day_number
+		LEFT JOIN exercises e ON e.exercise_id = i.exercise_id
+		LEFT JOIN journal_templates t ON t.id = i.template_id
+		WHERE d.program_id = $1
+		ORDER BY d.day_number, i.position, i.id`
+
+	rows, err := m.DB.QueryContext(ctx, days, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Days = []*ProgramDay{}
+	var day *ProgramDay
+	for rows.Next() {
+		var d ProgramDay
+		var itemID, refID uuid.NullUUID
+		var position sql.NullInt64
+		var itemType, title sql.NullString
+		var titleVi *string
+
+		err = rows.Scan(
+			&d.DayNumber,
+			&d.Title,
+			&d.TitleVi,
+			&d.Description,
+			&d.DescriptionVi,
+			&itemID,
+			&position,
+			&itemType,
+			&refID,
+			&title,
+			&titleVi,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if day == nil || day.DayNumber != d.DayNumber {
+			d.Week = (d.DayNumber-1)/7 + 1
+			d.Items = []*ProgramItem{}
+			day = &d
+			p.Days = append(p.Days, day)
+		}
+
+		if itemID.Valid {
+			day.Items = append(day.Items, &ProgramItem{
+				ID:       itemID.UUID,
+				Position: int(position.Int64),
+				ItemType: itemType.String,
+				RefID:    refID.UUID,
+				Title:    title.String,
+				TitleVi:  titleVi,
+			})
+		}
+	}
+
+	return p, rows.Err()
+}
+
+// Enroll enrolls the user in the program starting on startDate (YYYY-MM-DD) and
+// reports whether a new enrollment was created; an existing one is returned as is.
+func (m ProgramModel) Enroll(userID, programID uuid.UUID, startDate string) (*ProgramEnrollment, bool, error) {
+	query := `
+		INSERT INTO program_enrollments (user_id, program_id, start_date)
+		VALUES ($1, $2, $3::date)
+		ON CONFLICT (user_id, program_id) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, programID, startDate)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	enrollment, err := m.GetEnrollment(userID, programID)
+	return enrollment, rowsAffected == 1, err
+}
+
+// GetEnrollment returns the user's enrollment in the program with its completed days.
+func (m ProgramModel) GetEnrollment(userID, programID uuid.UUID) (*ProgramEnrollment, error) {
+	query := `
+		SELECT e.id, e.user_id, e.program_id, e.start_date, e.enrolled_at, e.completed_at,
+		       COALESCE(ARRAY(SELECT p.day_number FROM program_day_progress p
+		                      WHERE p.enrollment_id = e.id ORDER BY p.day_number), '{}')
+		FROM program_enrollments e
+		WHERE e.user_id = $1 AND e.program_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var e ProgramEnrollment
+	var startDate time.Time
+	var completedDays []int64
+
+	err := m.DB.QueryRowContext(ctx, query, userID, programID).Scan(
+		&e.ID,
+		&e.UserID,
+		&e.ProgramID,
+		&startDate,
+		&e.EnrolledAt,
+		&e.CompletedAt,
+		pq.Array(&completedDays),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	e.StartDate = startDate.Format(SleepDateLayout)
+	e.CompletedDays = make([]int, len(completedDays))
+	for i, day := range completedDays {
+		e.CompletedDays[i] = int(day)
+	}
+
+	return &e, nil
+}
+
+// Unenroll removes the user's enrollment and its progress.
+func (m ProgramModel) Unenroll(userID, programID uuid.UUID) error {
+	query := `DELETE FROM program_enrollments WHERE user_id = $1 AND program_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, programID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// CompleteDay records the day as completed and marks the enrollment completed once
+// every program day is. It reports whether this completed the whole program.
+func (m ProgramModel) CompleteDay(enrollmentID uuid.UUID, dayNumber int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO program_day_progress (enrollment_id, day_number)
+		VALUES ($1, $2)
+		ON CONFLICT (enrollment_id, day_number) DO NOTHING`, enrollmentID, dayNumber)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE program_enrollments e
+		SET completed_at = CURRENT_TIMESTAMP
+		WHERE e.id = $1 AND e.completed_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM program_days d
+			WHERE d.program_id = e.program_id
+			  AND NOT EXISTS (
+				SELECT 1 FROM program_day_progress p
+				WHERE p.enrollment_id = e.id AND p.day_number = d.day_number
+			  )
+		  )`, enrollmentID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, tx.Commit()
+}
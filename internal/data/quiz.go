@@ -0,0 +1,218 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SlideTypeQuiz = "quiz"
+	// QuizPassPercent is the minimum percentage of correct answers needed to pass a quiz.
+	QuizPassPercent = 70.0
+)
+
+// quizSecretFields are removed from quiz slides before templates leave the server.
+var quizSecretFields = []string{"correct_option_ids", "explanation"}
+
+// HasQuiz reports whether the slide group contains at least one quiz slide.
+func (g SlideGroup) HasQuiz() bool {
+	return slices.ContainsFunc(g.Slides, func(s SlideData) bool { return s.Type == SlideTypeQuiz })
+}
+
+// QuizQuestionResult is the outcome of a single quiz slide.
+type QuizQuestionResult struct {
+	SlideID           string   `json:"slide_id"`
+	Correct           bool     `json:"correct"`
+	SelectedOptionIDs []string `json:"selected_option_ids"`
+	CorrectOptionIDs  []string `json:"correct_option_ids"`
+	Explanation       string   `json:"explanation,omitempty"`
+}
+
+// QuizResult is the scored outcome of a quiz submission for a slide group.
+type QuizResult struct {
+	Score     int                  `json:"score"`
+	Total     int                  `json:"total"`
+	Percent   float64              `json:"percent"`
+	Passed    bool                 `json:"passed"`
+	Questions []QuizQuestionResult `json:"questions"`
+}
+
+// ScoreQuiz scores the answers (quiz slide id → selected option ids) against every
+// quiz slide in the group. A question is correct only when the selected options
+// match the correct options exactly; unanswered questions count as wrong.
+func ScoreQuiz(group SlideGroup, answers map[string][]string) QuizResult {
+	result := QuizResult{Questions: []QuizQuestionResult{}}
+
+	for _, slide := range group.Slides {
+		if slide.Type != SlideTypeQuiz {
+			continue
+		}
+
+		selected := answers[slide.ID]
+		if selected == nil {
+			selected = []string{}
+		}
+
+		question := QuizQuestionResult{
+			SlideID:           slide.ID,
+			Correct:           sameOptionSet(selected, slide.CorrectOptionIDs),
+			SelectedOptionIDs: selected,
+			CorrectOptionIDs:  slide.CorrectOptionIDs,
+			Explanation:       slide.Explanation,
+		}
+
+		result.Total++
+		if question.Correct {
+			result.Score++
+		}
+		result.Questions = append(result.Questions, question)
+	}
+
+	if result.Total > 0 {
+		percent := float64(result.Score) / float64(result.Total) * 100
+		result.Percent = math.Round(percent*10) / 10
+		result.Passed = result.Percent >= QuizPassPercent
+	}
+
+	return result
+}
+
+func sameOptionSet(selected, correct []string) bool {
+	if len(correct) == 0 {
+		return false
+	}
+
+	a := slices.Clone(selected)
+	b := slices.Clone(correct)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// StripQuizAnswers removes correct answers and explanations from quiz slides in
+// both the default and the Vietnamese slide groups.
+func (t *JournalTemplate) StripQuizAnswers() error {
+	stripped, err := stripQuizAnswers(t.SlideGroups)
+	if err != nil {
+		return err
+	}
+	t.SlideGroups = stripped
+
+	stripped, err = stripQuizAnswers(t.SlideGroupsVi)
+	if err != nil {
+		return err
+	}
+	t.SlideGroupsVi = stripped
+
+	return nil
+}
+
+// stripQuizAnswers works on generic JSON so that slide fields unknown to
+// SlideData survive the round trip.
+func stripQuizAnswers(raw json.RawMessage) (json.RawMessage, error) {
+	if !bytes.Contains(raw, []byte(`"`+SlideTypeQuiz+`"`)) {
+		return raw, nil
+	}
+
+	var groups []map[string]any
+	err := json.Unmarshal(raw, &groups)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		slides, _ := group["slides"].([]any)
+		for _, s := range slides {
+			slide, ok := s.(map[string]any)
+			if !ok || slide["type"] != SlideTypeQuiz {
+				continue
+			}
+			for _, field := range quizSecretFields {
+				delete(slide, field)
+			}
+		}
+	}
+
+	return json.Marshal(groups)
+}
+
+// QuizAttempt is a scored quiz submission for one slide group.
+type QuizAttempt struct {
+	ID           uuid.UUID           `json:"id"`
+	UserID       uuid.UUID           `json:"user_id"`
+	CollectionID uuid.UUID           `json:"collection_id"`
+	SlideGroupID string              `json:"slide_group_id"`
+	Answers      map[string][]string `json:"answers"`
+	Score        int                 `json:"score"`
+	Total        int                 `json:"total"`
+	Percent      float64             `json:"percent"`
+	Passed       bool                `json:"passed"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+type QuizAttemptModel struct {
+	DB *sql.DB
+}
+
+// Insert records a scored quiz attempt
+func (m QuizAttemptModel) Insert(attempt *QuizAttempt) (*QuizAttempt, error) {
+	query := `
+		INSERT INTO quiz_attempts (user_id, collection_id, slide_group_id, answers, score, total, percent, passed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	answersJSON, err := json.Marshal(attempt.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.DB.QueryRowContext(ctx, query,
+		attempt.UserID,
+		attempt.CollectionID,
+		attempt.SlideGroupID,
+		answersJSON,
+		attempt.Score,
+		attempt.Total,
+		attempt.Percent,
+		attempt.Passed,
+	).Scan(
+		&attempt.ID,
+		&attempt.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attempt, nil
+}
+
+// HasPassed reports whether the user has at least one passing attempt for the slide group
+func (m QuizAttemptModel) HasPassed(userID, collectionID uuid.UUID, slideGroupID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM quiz_attempts
+			WHERE user_id = $1 AND collection_id = $2 AND slide_group_id = $3 AND passed = true
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var passed bool
+	err := m.DB.QueryRowContext(ctx, query, userID, collectionID, slideGroupID).Scan(&passed)
+
+	return passed, err
+}
@@ -0,0 +1,239 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func quizSlide(id string, correct ...string) SlideData {
+	return SlideData{
+		ID:               id,
+		Type:             SlideTypeQuiz,
+		Question:         "Question " + id,
+		Options:          []QuizOption{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}},
+		CorrectOptionIDs: correct,
+		Explanation:      "Because " + id,
+	}
+}
+
+func TestScoreQuiz(t *testing.T) {
+	group := SlideGroup{
+		ID: "g1",
+		Slides: []SlideData{
+			{ID: "intro", Type: "doc", Content: "Read me"},
+			quizSlide("single", "a"),
+			quizSlide("multi", "b", "c"),
+			quizSlide("last", "d"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		answers     map[string][]string
+		wantScore   int
+		wantPercent float64
+		wantPassed  bool
+		wantCorrect []bool
+	}{
+		{
+			name:        "all correct",
+			answers:     map[string][]string{"single": {"a"}, "multi": {"b", "c"}, "last": {"d"}},
+			wantScore:   3,
+			wantPercent: 100,
+			wantPassed:  true,
+			wantCorrect: []bool{true, true, true},
+		},
+		{
+			name:        "multi-choice in any order with repeats",
+			answers:     map[string][]string{"single": {"a"}, "multi": {"c", "b", "c"}, "last": {"d"}},
+			wantScore:   3,
+			wantPercent: 100,
+			wantPassed:  true,
+			wantCorrect: []bool{true, true, true},
+		},
+		{
+			name:        "multi-choice missing an option",
+			answers:     map[string][]string{"single": {"a"}, "multi": {"b"}, "last": {"d"}},
+			wantScore:   2,
+			wantPercent: 66.7,
+			wantCorrect: []bool{true, false, true},
+		},
+		{
+			name:        "extra selected option",
+			answers:     map[string][]string{"single": {"a", "b"}, "multi": {"b", "c", "d"}, "last": {"d"}},
+			wantScore:   1,
+			wantPercent: 33.3,
+			wantCorrect: []bool{false, false, true},
+		},
+		{
+			name:        "wrong single choice",
+			answers:     map[string][]string{"single": {"b"}, "multi": {"b", "c"}, "last": {"d"}},
+			wantScore:   2,
+			wantPercent: 66.7,
+			wantCorrect: []bool{false, true, true},
+		},
+		{
+			name:        "missing answers count as wrong",
+			answers:     map[string][]string{"single": {"a"}},
+			wantScore:   1,
+			wantPercent: 33.3,
+			wantCorrect: []bool{true, false, false},
+		},
+		{
+			name:        "answers to unknown and non-quiz slides are ignored",
+			answers:     map[string][]string{"single": {"a"}, "multi": {"b", "c"}, "last": {"d"}, "intro": {"a"}, "other": {"a"}},
+			wantScore:   3,
+			wantPercent: 100,
+			wantPassed:  true,
+			wantCorrect: []bool{true, true, true},
+		},
+		{
+			name:        "no answers",
+			answers:     nil,
+			wantCorrect: []bool{false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ScoreQuiz(group, tt.answers)
+			if result.Total != 3 {
+				t.Fatalf("got total %d, want 3", result.Total)
+			}
+			if result.Score != tt.wantScore || result.Percent != tt.wantPercent || result.Passed != tt.wantPassed {
+				t.Errorf("got score %d (%v%%, passed %v), want %d (%v%%, passed %v)",
+					result.Score, result.Percent, result.Passed, tt.wantScore, tt.wantPercent, tt.wantPassed)
+			}
+
+			var correct []bool
+			for _, q := range result.Questions {
+				correct = append(correct, q.Correct)
+				if q.SelectedOptionIDs == nil {
+					t.Errorf("question %q: selected options are nil", q.SlideID)
+				}
+			}
+			if !slices.Equal(correct, tt.wantCorrect) {
+				t.Errorf("got correct %v, want %v", correct, tt.wantCorrect)
+			}
+		})
+	}
+}
+
+func TestScoreQuizPassThreshold(t *testing.T) {
+	tests := []struct {
+		right, total int
+		wantPassed   bool
+	}{
+		{7, 10, true},
+		{6, 10, false},
+		{2, 3, false},
+		{3, 4, true},
+		{1, 1, true},
+		{0, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d of %d", tt.right, tt.total), func(t *testing.T) {
+			group := SlideGroup{}
+			answers := map[string][]string{}
+			for i := 0; i < tt.total; i++ {
+				id := fmt.Sprintf("q%d", i)
+				group.Slides = append(group.Slides, quizSlide(id, "a"))
+				if i < tt.right {
+					answers[id] = []string{"a"}
+				} else {
+					answers[id] = []string{"b"}
+				}
+			}
+
+			result := ScoreQuiz(group, answers)
+			if result.Passed != tt.wantPassed {
+				t.Errorf("got passed %v at %v%%, want %v (threshold %v%%)", result.Passed, result.Percent, tt.wantPassed, QuizPassPercent)
+			}
+		})
+	}
+}
+
+func TestScoreQuizEdgeCases(t *testing.T) {
+	result := ScoreQuiz(SlideGroup{Slides: []SlideData{{ID: "intro", Type: "doc"}}}, map[string][]string{"intro": {"a"}})
+	if result.Total != 0 || result.Passed || len(result.Questions) != 0 {
+		t.Errorf("group without quiz slides: got %+v, want nothing scored and not passed", result)
+	}
+
+	// A quiz slide without correct options can never be answered correctly
+	result = ScoreQuiz(SlideGroup{Slides: []SlideData{quizSlide("broken")}}, map[string][]string{"broken": {}})
+	if result.Score != 0 || result.Passed {
+		t.Errorf("quiz slide without correct options: got %+v, want it scored wrong", result)
+	}
+}
+
+func TestStripQuizAnswers(t *testing.T) {
+	raw := json.RawMessage(`[
+		{"id": "g1", "title": "Basics", "custom_group_field": 1, "slides": [
+			{"id": "intro", "type": "doc", "content": "Read me", "explanation": "not a quiz, kept"},
+			{"id": "q1", "type": "quiz", "question": "Pick one", "options": [{"id": "a", "text": "A"}],
+			 "correct_option_ids": ["a"], "explanation": "Because", "custom_slide_field": "kept"}
+		]},
+		{"id": "g2", "slides": [
+			{"id": "q2", "type": "quiz", "correct_option_ids": ["b", "c"]}
+		]},
+		{"id": "g3"}
+	]`)
+
+	template := &JournalTemplate{SlideGroups: raw, SlideGroupsVi: raw}
+	err := template.StripQuizAnswers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, stripped := range map[string]json.RawMessage{"slide_groups": template.SlideGroups, "slide_groups_vi": template.SlideGroupsVi} {
+		t.Run(name, func(t *testing.T) {
+			if strings.Contains(string(stripped), "correct_option_ids") || strings.Contains(string(stripped), "Because") {
+				t.Errorf("answers left in %s", stripped)
+			}
+
+			var groups []map[string]any
+			if err := json.Unmarshal(stripped, &groups); err != nil {
+				t.Fatal(err)
+			}
+			if len(groups) != 3 || groups[0]["custom_group_field"] != 1.0 {
+				t.Errorf("group fields not kept: %s", stripped)
+			}
+
+			slides := groups[0]["slides"].([]any)
+			intro := slides[0].(map[string]any)
+			quiz := slides[1].(map[string]any)
+			if intro["explanation"] != "not a quiz, kept" {
+				t.Errorf("non-quiz slide changed: %v", intro)
+			}
+			if quiz["question"] != "Pick one" || quiz["custom_slide_field"] != "kept" || quiz["options"] == nil {
+				t.Errorf("quiz slide lost public fields: %v", quiz)
+			}
+		})
+	}
+}
+
+func TestStripQuizAnswersWithoutQuiz(t *testing.T) {
+	raw := json.RawMessage(`[{"id": "g1", "slides": [{"id": "intro", "type": "doc"}]}]`)
+
+	stripped, err := stripQuizAnswers(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stripped) != string(raw) {
+		t.Errorf("got %s, want the input unchanged", stripped)
+	}
+
+	stripped, err = stripQuizAnswers(nil)
+	if err != nil || stripped != nil {
+		t.Errorf("got %s, %v for no slide groups, want nil, nil", stripped, err)
+	}
+
+	_, err = stripQuizAnswers(json.RawMessage(`{"type": "quiz"`))
+	if err == nil {
+		t.Error("invalid JSON was accepted")
+	}
+}
@@ -0,0 +1,434 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
+)
+
+// MaxRoutineSteps caps the number of exercises in a routine.
+const MaxRoutineSteps = 20
+
+// Routine is a user's named, ordered list of exercises.
+type Routine struct {
+	ID          uuid.UUID   `json:"id"`
+	UserID      uuid.UUID   `json:"user_id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	ExerciseIDs []uuid.UUID `json:"-"`
+	Exercises   []*Exercise `json:"exercises"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// RoutineSession plays a routine step by step. Position is the zero-based index of
+// the current step; StepStartedAt times it.
+type RoutineSession struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	RoutineID     uuid.UUID  `json:"routine_id"`
+	Status        string     `json:"status"`
+	Position      int        `json:"position"`
+	TotalSteps    int        `json:"total_steps"`
+	StepStartedAt time.Time  `json:"step_started_at"`
+	StartedAt     time.Time  `json:"started_at"`
+	EndedAt       *time.Time `json:"ended_at"`
+}
+
+func ValidateRoutine(v *validator.Validator, r *Routine) {
+	v.Check(r.Title != "", "title", "must be provided")
+	v.Check(len(r.Title) <= 100, "title", "must not be more than 100 bytes long")
+	v.Check(len(r.ExerciseIDs) >= 1, "exercise_ids", "must contain at least one exercise")
+	v.Check(len(r.ExerciseIDs) <= MaxRoutineSteps, "exercise_ids", "must not contain more than 20 exercises")
+	for _, id := range r.ExerciseIDs {
+		v.Check(id != uuid.Nil, "exercise_ids", "must not contain empty ids")
+	}
+}
+
+type RoutineModel struct {
+	DB *sql.DB
+}
+
+const routineColumns = `id, user_id, title, COALESCE(description, ''), created_at, updated_at`
+
+func scanRoutine(row interface{ Scan(...any) error }) (*Routine, error) {
+	var r Routine
+	err := row.Scan(
+		&r.ID,
+		&r.UserID,
+		&r.Title,
+		&r.Description,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (m RoutineModel) Insert(r *Routine) (*Routine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO routines (user_id, title, description)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING ` + routineColumns
+
+	created, err := scanRoutine(tx.QueryRowContext(ctx, query, r.UserID, r.Title, r.Description))
+	if err != nil {
+		return nil, err
+	}
+
+	err = replaceRoutineExercises(ctx, tx, created.ID, r.ExerciseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Get(created.ID, created.UserID)
+}
+
+// Get returns the user's routine with its exercises in order.
+func (m RoutineModel) Get(id, userID uuid.UUID) (*Routine, error) {
+	query := `SELECT ` + routineColumns + ` FROM routines WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	r, err := scanRoutine(m.DB.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	r.Exercises, err = m.exercises(ctx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetAllByUser returns the user's routines with their exercises, newest first
+func (m RoutineModel) GetAllByUser(userID uuid.UUID) ([]*Routine, error) {
+	query := `SELECT ` + routineColumns + ` FROM routines WHERE user_id = $1 ORDER BY created_at DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routines := []*Routine{}
+	for rows.Next() {
+		r, err := scanRoutine(rows)
+		if err != nil {
+			return nil, err
+		}
+		routines = append(routines, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, r := range routines {
+		r.Exercises, err = m.exercises(ctx, r.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return routines, nil
+}
+
+// Update saves the title and description, and replaces the exercises when
+// r.ExerciseIDs is not nil.
+func (m RoutineModel) Update(r *Routine) (*Routine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE routines
+		SET title = $1, description = NULLIF($2, '')
+		WHERE id = $3 AND user_id = $4`
+
+	result, err := tx.ExecContext(ctx, query, r.Title, r.Description, r.ID, r.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	if r.ExerciseIDs != nil {
+		err = replaceRoutineExercises(ctx, tx, r.ID, r.ExerciseIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Get(r.ID, r.UserID)
+}
+
+func (m RoutineModel) Delete(id, userID uuid.UUID) error {
+	query := `DELETE FROM routines WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m RoutineModel) exercises(ctx context.Context, routineID uuid.UUID) ([]*Exercise, error) {
+	query := `
+		SELECT ` + exerciseColumns + `
+		FROM exercises
+		JOIN (
+			SELECT position, exercise_id AS step_exercise_id
+			FROM routine_exercises
+			WHERE routine_id = $1
+		) s ON s.step_exercise_id = exercises.exercise_id
+		ORDER BY s.position`
+
+	rows, err := m.DB.QueryContext(ctx, query, routineID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []*Exercise{}
+	for rows.Next() {
+		exercise, err := scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, exercise)
+	}
+
+	return exercises, rows.Err()
+}
+
+func replaceRoutineExercises(ctx context.Context, tx *sql.Tx, routineID uuid.UUID, exerciseIDs []uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM routine_exercises WHERE routine_id = $1`, routineID)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO routine_exercises (routine_id, position, exercise_id)
+		VALUES ($1, $2, $3)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for position, exerciseID := range exerciseIDs {
+		_, err = stmt.ExecContext(ctx, routineID, position, exerciseID)
+		if err != nil {
+			if strings.Contains(err.Error(), "fk_routine_exercises_exercise") {
+				return ErrUnknownExercise
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+const routineSessionColumns = `id, user_id, routine_id, status, position,
+	(SELECT COUNT(*) FROM routine_exercises re WHERE re.routine_id = routine_sessions.routine_id),
+	step_started_at, started_at, ended_at`
+
+func scanRoutineSession(row interface{ Scan(...any) error }) (*RoutineSession, error) {
+	var s RoutineSession
+	err := row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.RoutineID,
+		&s.Status,
+		&s.Position,
+		&s.TotalSteps,
+		&s.StepStartedAt,
+		&s.StartedAt,
+		&s.EndedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// StartSession starts playing the user's routine from its first step.
+func (m RoutineModel) StartSession(routineID, userID uuid.UUID) (*RoutineSession, error) {
+	query := `
+		INSERT INTO routine_sessions (user_id, routine_id, step_started_at, started_at)
+		SELECT $2, r.id, $3, $3 FROM routines r WHERE r.id = $1 AND r.user_id = $2
+		RETURNING ` + routineSessionColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	s, err := scanRoutineSession(m.DB.QueryRowContext(ctx, query, routineID, userID, time.Now().UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return s, err
+}
+
+func (m RoutineModel) GetSession(id, userID uuid.UUID) (*RoutineSession, error) {
+	query := `SELECT ` + routineSessionColumns + ` FROM routine_sessions WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	s, err := scanRoutineSession(m.DB.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return s, err
+}
+
+// AdvanceSession finishes the current step and moves to the next one. Unless the
+// step is skipped, its exercise is logged to user_completed_exercises with the time
+// spent on it. Finishing the last step completes the session. It reports whether an
+// exercise was logged.
+func (m RoutineModel) AdvanceSession(id, userID uuid.UUID, skip bool) (*RoutineSession, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + routineSessionColumns + ` FROM routine_sessions WHERE id = $1 AND user_id = $2 FOR UPDATE`
+
+	s, err := scanRoutineSession(tx.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, ErrRecordNotFound
+		}
+		return nil, false, err
+	}
+	if s.Status != ExerciseSessionActive {
+		return nil, false, fmt.Errorf("%w: cannot advance a routine session that is %s", ErrInvalidSessionTransition, s.Status)
+	}
+
+	now := time.Now().UTC()
+	logged := false
+
+	if !skip {
+		// The routine may have been edited mid-session; a step that no longer exists is not logged
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO user_completed_exercises (user_id, exercise_id, duration_seconds, routine_session_id, completed_at)
+			SELECT $1, re.exercise_id, $2, $3, $4
+			FROM routine_exercises re
+			WHERE re.routine_id = $5
+			ORDER BY re.position
+			OFFSET $6 LIMIT 1`,
+			userID, max(0, int(now.Sub(s.StepStartedAt).Seconds())), s.ID, now, s.RoutineID, s.Position)
+		if err != nil {
+			return nil, false, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, false, err
+		}
+		logged = rowsAffected == 1
+	}
+
+	s.Position++
+	s.StepStartedAt = now
+	if s.Position >= s.TotalSteps {
+		s.Status = ExerciseSessionCompleted
+		s.EndedAt = &now
+	}
+
+	update := `
+		UPDATE routine_sessions
+		SET position = $1, step_started_at = $2, status = $3, ended_at = $4
+		WHERE id = $5`
+
+	_, err = tx.ExecContext(ctx, update, s.Position, s.StepStartedAt, s.Status, s.EndedAt, s.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return s, logged, tx.Commit()
+}
+
+// AbandonSession stops an active routine session.
+func (m RoutineModel) AbandonSession(id, userID uuid.UUID) (*RoutineSession, error) {
+	query := `
+		UPDATE routine_sessions
+		SET status = 'abandoned', ended_at = $3
+		WHERE id = $1 AND user_id = $2 AND status = 'active'
+		RETURNING ` + routineSessionColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	s, err := scanRoutineSession(m.DB.QueryRowContext(ctx, query, id, userID, time.Now().UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		existing, getErr := m.GetSession(id, userID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return nil, fmt.Errorf("%w: cannot abandon a routine session that is %s", ErrInvalidSessionTransition, existing.Status)
+	}
+	return s, err
+}
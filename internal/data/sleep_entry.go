@@ -0,0 +1,357 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
+)
+
+var (
+	ErrDuplicateSleepEntry = errors.New("duplicate sleep entry")
+)
+
+// SleepDateLayout is the format of SleepEntry.SleepDate.
+const SleepDateLayout = "2006-01-02"
+
+// sleepDateCutoffHour: bedtimes before this local hour belong to the previous night.
+const sleepDateCutoffHour = 12
+
+// SleepEntry is one night of the sleep diary.
+type SleepEntry struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// SleepDate is the night of the entry (YYYY-MM-DD): the evening the user went to bed.
+	SleepDate                  string       `json:"sleep_date"`
+	Bedtime                    time.Time    `json:"bedtime"`
+	SleepOnsetLatencyMinutes   int          `json:"sleep_onset_latency_minutes"`
+	WakeCount                  int          `json:"wake_count"`
+	WakeAfterSleepOnsetMinutes int          `json:"wake_after_sleep_onset_minutes"`
+	FinalWakeAt                time.Time    `json:"final_wake_at"`
+	RiseAt                     time.Time    `json:"rise_at"`
+	Quality                    *int         `json:"quality,omitempty"`
+	Notes                      string       `json:"notes"`
+	Metrics                    SleepMetrics `json:"metrics"`
+	CreatedAt                  time.Time    `json:"created_at"`
+	UpdatedAt                  time.Time    `json:"updated_at"`
+}
+
+// SleepMetrics are the CBT-I measures derived from a diary entry.
+type SleepMetrics struct {
+	// TimeInBedMinutes runs from bedtime to rise time.
+	TimeInBedMinutes int `json:"time_in_bed_minutes"`
+	// TotalSleepMinutes is bedtime to final wake minus sleep onset latency and time awake.
+	TotalSleepMinutes int `json:"total_sleep_minutes"`
+	// SleepEfficiency is total sleep time as a percentage of time in bed.
+	SleepEfficiency float64 `json:"sleep_efficiency"`
+}
+
+// ComputeMetrics fills in the entry's derived metrics.
+func (e *SleepEntry) ComputeMetrics() {
+	timeInBed := int(e.RiseAt.Sub(e.Bedtime).Minutes())
+	asleepWindow := int(e.FinalWakeAt.Sub(e.Bedtime).Minutes())
+	totalSleep := max(asleepWindow-e.SleepOnsetLatencyMinutes-e.WakeAfterSleepOnsetMinutes, 0)
+
+	e.Metrics = SleepMetrics{
+		TimeInBedMinutes:  timeInBed,
+		TotalSleepMinutes: totalSleep,
+	}
+	if timeInBed > 0 {
+		e.Metrics.SleepEfficiency = math.Round(float64(totalSleep)/float64(timeInBed)*1000) / 10
+	}
+}
+
+// DefaultSleepDate returns the night a bedtime belongs to in the given location:
+// going to bed at 23:30 or 01:30 both count as the night of the earlier date.
+func DefaultSleepDate(bedtime time.Time, loc *time.Location) string {
+	local := bedtime.In(loc)
+	if local.Hour() < sleepDateCutoffHour {
+		local = local.AddDate(0, 0, -1)
+	}
+	return local.Format(SleepDateLayout)
+}
+
+func ValidateSleepEntry(v *validator.Validator, e *SleepEntry) {
+	_, err := time.Parse(SleepDateLayout, e.SleepDate)
+	v.Check(err == nil, "sleep_date", "must be a date in YYYY-MM-DD format")
+
+	v.Check(!e.Bedtime.IsZero(), "bedtime", "must be provided")
+	v.Check(!e.FinalWakeAt.IsZero(), "final_wake_at", "must be provided")
+	v.Check(!e.RiseAt.IsZero(), "rise_at", "must be provided")
+
+	if !e.Bedtime.IsZero() && !e.FinalWakeAt.IsZero() && !e.RiseAt.IsZero() {
+		v.Check(e.FinalWakeAt.After(e.Bedtime), "final_wake_at", "must be after bedtime")
+		v.Check(!e.RiseAt.Before(e.FinalWakeAt), "rise_at", "must not be before final_wake_at")
+		v.Check(e.RiseAt.Sub(e.Bedtime) <= 24*time.Hour, "rise_at", "must be within 24 hours of bedtime")
+
+		asleepWindow := int(e.FinalWakeAt.Sub(e.Bedtime).Minutes())
+		v.Check(e.SleepOnsetLatencyMinutes+e.WakeAfterSleepOnsetMinutes <= asleepWindow,
+			"wake_after_sleep_onset_minutes", "sleep onset latency and time awake must fit between bedtime and final wake")
+	}
+
+	v.Check(e.SleepOnsetLatencyMinutes >= 0 && e.SleepOnsetLatencyMinutes <= 720, "sleep_onset_latency_minutes", "must be between 0 and 720")
+	v.Check(e.WakeAfterSleepOnsetMinutes >= 0, "wake_after_sleep_onset_minutes", "must not be negative")
+	v.Check(e.WakeCount >= 0 && e.WakeCount <= 50, "wake_count", "must be between 0 and 50")
+
+	if e.Quality != nil {
+		v.Check(*e.Quality >= 1 && *e.Quality <= 5, "quality", "must be between 1 and 5")
+	}
+
+	v.Check(len(e.Notes) <= 2000, "notes", "must not be more than 2000 characters")
+}
+
+type SleepEntryModel struct {
+	DB *sql.DB
+}
+
+const sleepEntryColumns = `id, user_id, sleep_date::text, bedtime, sleep_onset_latency_minutes, wake_count,
+		       wake_after_sleep_onset_minutes, final_wake_at, rise_at, quality, COALESCE(notes, ''),
+		       created_at, updated_at`
+
+func scanSleepEntry(row interface{ Scan(...any) error }, extra ...any) (*SleepEntry, error) {
+	var e SleepEntry
+	dest := append(extra,
+		&e.ID,
+		&e.UserID,
+		&e.SleepDate,
+		&e.Bedtime,
+		&e.SleepOnsetLatencyMinutes,
+		&e.WakeCount,
+		&e.WakeAfterSleepOnsetMinutes,
+		&e.FinalWakeAt,
+		&e.RiseAt,
+		&e.Quality,
+		&e.Notes,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	e.ComputeMetrics()
+	return &e, nil
+}
+
+// Insert creates a sleep entry; each night can only be recorded once.
+// Times are stored in UTC since the columns are TIMESTAMP without time zone.
+func (m SleepEntryModel) Insert(entry *SleepEntry) (*SleepEntry, error) {
+	query := `
+		INSERT INTO sleep_entries (user_id, sleep_date, bedtime, sleep_onset_latency_minutes, wake_count,
+		                           wake_after_sleep_onset_minutes, final_wake_at, rise_at, quality, notes)
+		VALUES ($1, $2::date, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
+		RETURNING ` + sleepEntryColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	created, err := scanSleepEntry(m.DB.QueryRowContext(ctx, query,
+		entry.UserID,
+		entry.SleepDate,
+		entry.Bedtime.UTC(),
+		entry.SleepOnsetLatencyMinutes,
+		entry.WakeCount,
+		entry.WakeAfterSleepOnsetMinutes,
+		entry.FinalWakeAt.UTC(),
+		entry.RiseAt.UTC(),
+		entry.Quality,
+		entry.Notes,
+	))
+
+	if err != nil {
+		if strings.Contains(err.Error(), "uq_sleep_entries_user_date") {
+			return nil, ErrDuplicateSleepEntry
+		}
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Get retrieves a single sleep entry by ID and user
+func (m SleepEntryModel) Get(id, userID uuid.UUID) (*SleepEntry, error) {
+	query := `
+		SELECT ` + sleepEntryColumns + `
+		FROM sleep_entries
+		WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	entry, err := scanSleepEntry(m.DB.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetList returns a page of the user's sleep entries, filtered by sleep_date range
+func (m SleepEntryModel) GetList(userID uuid.UUID, filter *QueryFilter) ([]*SleepEntry, Metadata, error) {
+	var queryBuilder strings.Builder
+	var args []interface{}
+	paramIndex := 1
+
+	queryBuilder.WriteString(`
+		SELECT COUNT(*) OVER(), ` + sleepEntryColumns + `
+		FROM sleep_entries
+		WHERE user_id = $1
+	`)
+	args = append(args, userID)
+	paramIndex++
+
+	if filter.HasTimeRange() {
+		timeSQL, timeArgs, nextIdx := filter.TimeRangeConditionSQL(paramIndex)
+		if timeSQL != "" {
+			queryBuilder.WriteString(" AND ")
+			queryBuilder.WriteString(timeSQL)
+			args = append(args, timeArgs...)
+			paramIndex = nextIdx
+		}
+	}
+
+	if filter.SortClause() != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s", filter.SortClause()))
+	} else {
+		queryBuilder.WriteString(" ORDER BY sleep_date DESC")
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1))
+	args = append(args, filter.Limit(), filter.Offset())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []*SleepEntry{}
+
+	for rows.Next() {
+		entry, err := scanSleepEntry(rows, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return entries, filter.CalculateMetadata(totalRecords), nil
+}
+
+// GetSince returns the user's sleep entries for nights on or after the given date, oldest first
+func (m SleepEntryModel) GetSince(userID uuid.UUID, since time.Time) ([]*SleepEntry, error) {
+	query := `
+		SELECT ` + sleepEntryColumns + `
+		FROM sleep_entries
+		WHERE user_id = $1 AND sleep_date >= $2::date
+		ORDER BY sleep_date ASC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, since.Format(SleepDateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*SleepEntry{}
+	for rows.Next() {
+		entry, err := scanSleepEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Update replaces the diary fields of a sleep entry
+func (m SleepEntryModel) Update(entry *SleepEntry) (*SleepEntry, error) {
+	query := `
+		UPDATE sleep_entries
+		SET sleep_date = $1::date, bedtime = $2, sleep_onset_latency_minutes = $3, wake_count = $4,
+		    wake_after_sleep_onset_minutes = $5, final_wake_at = $6, rise_at = $7, quality = $8,
+		    notes = NULLIF($9, '')
+		WHERE id = $10 AND user_id = $11
+		RETURNING ` + sleepEntryColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	updated, err := scanSleepEntry(m.DB.QueryRowContext(ctx, query,
+		entry.SleepDate,
+		entry.Bedtime.UTC(),
+		entry.SleepOnsetLatencyMinutes,
+		entry.WakeCount,
+		entry.WakeAfterSleepOnsetMinutes,
+		entry.FinalWakeAt.UTC(),
+		entry.RiseAt.UTC(),
+		entry.Quality,
+		entry.Notes,
+		entry.ID,
+		entry.UserID,
+	))
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		case strings.Contains(err.Error(), "uq_sleep_entries_user_date"):
+			return nil, ErrDuplicateSleepEntry
+		default:
+			return nil, err
+		}
+	}
+
+	return updated, nil
+}
+
+// Delete removes a sleep entry
+func (m SleepEntryModel) Delete(id, userID uuid.UUID) error {
+	query := `
+		DELETE FROM sleep_entries
+		WHERE id = $1 AND user_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
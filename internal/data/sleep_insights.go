@@ -0,0 +1,227 @@
+package data
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// EfficientSleepThreshold is the CBT-I sleep efficiency (%) considered good sleep.
+	EfficientSleepThreshold = 85.0
+	// MinCorrelationPairs is the fewest paired observations a correlation is reported for.
+	MinCorrelationPairs = 5
+)
+
+// SleepAverages are averages over a set of diary nights; nil when there are none.
+type SleepAverages struct {
+	Nights                     int      `json:"nights"`
+	TimeInBedMinutes           *float64 `json:"time_in_bed_minutes"`
+	TotalSleepMinutes          *float64 `json:"total_sleep_minutes"`
+	SleepEfficiency            *float64 `json:"sleep_efficiency"`
+	SleepOnsetLatencyMinutes   *float64 `json:"sleep_onset_latency_minutes"`
+	WakeAfterSleepOnsetMinutes *float64 `json:"wake_after_sleep_onset_minutes"`
+	Quality                    *float64 `json:"quality"`
+}
+
+// SleepWeek holds the averages of one ISO week of nights.
+type SleepWeek struct {
+	WeekStart string `json:"week_start"`
+	SleepAverages
+}
+
+// SleepMoodCorrelation relates each night to the average mood_score of the following day.
+type SleepMoodCorrelation struct {
+	Pairs int `json:"pairs"`
+	// Pearson correlations; nil when there are fewer than MinCorrelationPairs pairs or no variance.
+	SleepEfficiency   *float64 `json:"sleep_efficiency"`
+	TotalSleepMinutes *float64 `json:"total_sleep_minutes"`
+	Quality           *float64 `json:"quality"`
+	// Average next-day mood after nights at or above EfficientSleepThreshold and after the rest.
+	MoodAfterEfficientNights *float64 `json:"mood_after_efficient_nights"`
+	MoodAfterOtherNights     *float64 `json:"mood_after_other_nights"`
+}
+
+// SleepInsights is the response of the sleep analytics endpoint.
+type SleepInsights struct {
+	Range       string               `json:"range"`
+	Timezone    string               `json:"timezone"`
+	StartDate   string               `json:"start_date"`
+	Summary     SleepAverages        `json:"summary"`
+	Weeks       []SleepWeek          `json:"weeks"`
+	NextDayMood SleepMoodCorrelation `json:"next_day_mood"`
+}
+
+// BuildSleepInsights summarizes diary entries (oldest first) and correlates them with
+// the mood scores of the following day in the user's timezone.
+func BuildSleepInsights(entries []*SleepEntry, points []MoodPoint, opts MoodInsightsOptions) SleepInsights {
+	start := RangeStart(opts)
+	startDate := start.Format(SleepDateLayout)
+
+	insights := SleepInsights{
+		Range:     opts.Range,
+		Timezone:  opts.Location.String(),
+		StartDate: startDate,
+		Weeks:     []SleepWeek{},
+	}
+
+	var inRange []*SleepEntry
+	for _, e := range entries {
+		if e.SleepDate >= startDate {
+			inRange = append(inRange, e)
+		}
+	}
+
+	insights.Summary = sleepAverages(inRange)
+
+	var week []*SleepEntry
+	weekStart := ""
+	for _, e := range inRange {
+		night, err := time.ParseInLocation(SleepDateLayout, e.SleepDate, opts.Location)
+		if err != nil {
+			continue
+		}
+		ws := bucketStart(night, BucketWeek).Format(SleepDateLayout)
+		if ws != weekStart && len(week) > 0 {
+			insights.Weeks = append(insights.Weeks, SleepWeek{WeekStart: weekStart, SleepAverages: sleepAverages(week)})
+			week = nil
+		}
+		weekStart = ws
+		week = append(week, e)
+	}
+	if len(week) > 0 {
+		insights.Weeks = append(insights.Weeks, SleepWeek{WeekStart: weekStart, SleepAverages: sleepAverages(week)})
+	}
+
+	insights.NextDayMood = sleepMoodCorrelation(inRange, DailyMoodAverages(points, opts.Location))
+
+	return insights
+}
+
+// DailyMoodAverages averages mood scores per local calendar day (YYYY-MM-DD).
+func DailyMoodAverages(points []MoodPoint, loc *time.Location) map[string]float64 {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for _, p := range points {
+		day := p.At.In(loc).Format(SleepDateLayout)
+		sums[day] += float64(p.Score)
+		counts[day]++
+	}
+
+	averages := make(map[string]float64, len(sums))
+	for day, sum := range sums {
+		averages[day] = sum / float64(counts[day])
+	}
+	return averages
+}
+
+func sleepMoodCorrelation(entries []*SleepEntry, dailyMood map[string]float64) SleepMoodCorrelation {
+	var efficiency, totalSleep, mood []float64
+	var quality, qualityMood []float64
+	var efficientMood, otherMood []float64
+
+	for _, e := range entries {
+		night, err := time.Parse(SleepDateLayout, e.SleepDate)
+		if err != nil {
+			continue
+		}
+		nextDayMood, ok := dailyMood[night.AddDate(0, 0, 1).Format(SleepDateLayout)]
+		if !ok {
+			continue
+		}
+
+		efficiency = append(efficiency, e.Metrics.SleepEfficiency)
+		totalSleep = append(totalSleep, float64(e.Metrics.TotalSleepMinutes))
+		mood = append(mood, nextDayMood)
+		if e.Quality != nil {
+			quality = append(quality, float64(*e.Quality))
+			qualityMood = append(qualityMood, nextDayMood)
+		}
+
+		if e.Metrics.SleepEfficiency >= EfficientSleepThreshold {
+			efficientMood = append(efficientMood, nextDayMood)
+		} else {
+			otherMood = append(otherMood, nextDayMood)
+		}
+	}
+
+	return SleepMoodCorrelation{
+		Pairs:                    len(mood),
+		SleepEfficiency:          PearsonCorrelation(efficiency, mood),
+		TotalSleepMinutes:        PearsonCorrelation(totalSleep, mood),
+		Quality:                  PearsonCorrelation(quality, qualityMood),
+		MoodAfterEfficientNights: meanOf(efficientMood),
+		MoodAfterOtherNights:     meanOf(otherMood),
+	}
+}
+
+func sleepAverages(entries []*SleepEntry) SleepAverages {
+	averages := SleepAverages{Nights: len(entries)}
+	if len(entries) == 0 {
+		return averages
+	}
+
+	var timeInBed, totalSleep, efficiency, latency, awake, quality []float64
+	for _, e := range entries {
+		timeInBed = append(timeInBed, float64(e.Metrics.TimeInBedMinutes))
+		totalSleep = append(totalSleep, float64(e.Metrics.TotalSleepMinutes))
+		efficiency = append(efficiency, e.Metrics.SleepEfficiency)
+		latency = append(latency, float64(e.SleepOnsetLatencyMinutes))
+		awake = append(awake, float64(e.WakeAfterSleepOnsetMinutes))
+		if e.Quality != nil {
+			quality = append(quality, float64(*e.Quality))
+		}
+	}
+
+	averages.TimeInBedMinutes = meanOf(timeInBed)
+	averages.TotalSleepMinutes = meanOf(totalSleep)
+	averages.SleepEfficiency = meanOf(efficiency)
+	averages.SleepOnsetLatencyMinutes = meanOf(latency)
+	averages.WakeAfterSleepOnsetMinutes = meanOf(awake)
+	averages.Quality = meanOf(quality)
+
+	return averages
+}
+
+// PearsonCorrelation returns the correlation coefficient of two equally long series,
+// or nil when there are fewer than MinCorrelationPairs pairs or either series is constant.
+func PearsonCorrelation(xs, ys []float64) *float64 {
+	n := len(xs)
+	if n != len(ys) || n < MinCorrelationPairs {
+		return nil
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var covariance, varianceX, varianceY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+
+	if varianceX == 0 || varianceY == 0 {
+		return nil
+	}
+
+	r := round2(covariance / math.Sqrt(varianceX*varianceY))
+	return &r
+}
+
+func meanOf(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := round2(sum / float64(len(values)))
+	return &mean
+}
@@ -0,0 +1,265 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Streak event types
+const (
+	StreakEventStarted      = "started"
+	StreakEventExtended     = "extended"
+	StreakEventFreezeUsed   = "freeze_used"
+	StreakEventFreezeEarned = "freeze_earned"
+	StreakEventReset        = "reset"
+)
+
+const (
+	// StreakGracePeriod is how long after local midnight activity still counts for the previous day.
+	StreakGracePeriod = 3 * time.Hour
+	// DefaultStreakFreezes is the number of freezes a new streak is allotted.
+	DefaultStreakFreezes = 1
+	// MaxStreakFreezes caps how many freezes can be held at once.
+	MaxStreakFreezes = 2
+	// FreezeEarnInterval is the streak length (in days) at every multiple of which a freeze is earned.
+	FreezeEarnInterval = 7
+)
+
+// StreakEvent is one change to a user's streak. EventDate is the streak day
+// (YYYY-MM-DD) it applies to; for freeze_used it is the missed day.
+type StreakEvent struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	EventType   string    `json:"event_type"`
+	EventDate   string    `json:"event_date"`
+	StreakValue int       `json:"streak_value"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type StreakEventModel struct {
+	DB *sql.DB
+}
+
+// GetList returns a page of the user's streak events, optionally of one type
+func (m StreakEventModel) GetList(userID uuid.UUID, eventType string, filter *QueryFilter) ([]*StreakEvent, Metadata, error) {
+	var queryBuilder strings.Builder
+	var args []interface{}
+	paramIndex := 1
+
+	queryBuilder.WriteString(`
+		SELECT COUNT(*) OVER(), id, user_id, event_type, event_date, streak_value, created_at
+		FROM streak_events
+		WHERE user_id = $1
+	`)
+	args = append(args, userID)
+	paramIndex++
+
+	if eventType != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND event_type = $%d", paramIndex))
+		args = append(args, eventType)
+		paramIndex++
+	}
+
+	if filter.HasTimeRange() {
+		timeSQL, timeArgs, nextIdx := filter.TimeRangeConditionSQL(paramIndex)
+		if timeSQL != "" {
+			queryBuilder.WriteString(" AND ")
+			queryBuilder.WriteString(timeSQL)
+			args = append(args, timeArgs...)
+			paramIndex = nextIdx
+		}
+	}
+
+	if filter.SortClause() != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s, created_at DESC", filter.SortClause()))
+	} else {
+		queryBuilder.WriteString(" ORDER BY event_date DESC, created_at DESC")
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1))
+	args = append(args, filter.Limit(), filter.Offset())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	events := []*StreakEvent{}
+
+	for rows.Next() {
+		var event StreakEvent
+		var eventDate time.Time
+		err = rows.Scan(
+			&totalRecords,
+			&event.ID,
+			&event.UserID,
+			&event.EventType,
+			&eventDate,
+			&event.StreakValue,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		event.EventDate = eventDate.Format(SleepDateLayout)
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return events, filter.CalculateMetadata(totalRecords), nil
+}
+
+func insertStreakEvents(ctx context.Context, tx *sql.Tx, userID uuid.UUID, events []StreakEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO streak_events (user_id, event_type, event_date, streak_value, created_at)
+		VALUES ($1, $2, $3::date, $4, clock_timestamp() AT TIME ZONE 'UTC')`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		_, err = stmt.ExecContext(ctx, userID, e.EventType, e.EventDate, e.StreakValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storedStreakEvents returns the user's streak events in the order they were recorded.
+func storedStreakEvents(ctx context.Context, tx *sql.Tx, userID uuid.UUID) ([]StreakEvent, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, user_id, event_type, event_date, streak_value, created_at
+		FROM streak_events
+		WHERE user_id = $1
+		ORDER BY event_date, created_at, id
+		FOR UPDATE`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []StreakEvent
+	for rows.Next() {
+		var event StreakEvent
+		var eventDate time.Time
+		err = rows.Scan(&event.ID, &event.UserID, &event.EventType, &eventDate, &event.StreakValue, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		event.EventDate = eventDate.Format(SleepDateLayout)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// firstChangedEvent returns the index of the first replayed event that does not
+// match the stored history; everything before it can be kept as is.
+func firstChangedEvent(stored, replayed []StreakEvent) int {
+	i := 0
+	for i < len(stored) && i < len(replayed) {
+		s, r := stored[i], replayed[i]
+		if s.EventType != r.EventType || s.EventDate != r.EventDate || s.StreakValue != r.StreakValue {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+// streakState is the part of a streak that advances day by day.
+type streakState struct {
+	current          int
+	longest          int
+	freezesAvailable int
+	freezesUsed      int
+	// lastDay is the last streak day with activity; zero before the first one.
+	lastDay time.Time
+}
+
+// advance records activity on day and returns the resulting events. Missed days
+// are covered by freezes when enough are left; otherwise the streak restarts.
+func (s *streakState) advance(day time.Time) []StreakEvent {
+	var events []StreakEvent
+	event := func(eventType string, on time.Time, value int) {
+		events = append(events, StreakEvent{EventType: eventType, EventDate: on.Format(SleepDateLayout), StreakValue: value})
+	}
+
+	gap := dayCount(s.lastDay, day)
+	switch {
+	case s.lastDay.IsZero():
+		s.current = 1
+		event(StreakEventStarted, day, s.current)
+	case gap <= 0:
+		// Same day, or a late-arriving entry for an earlier day
+		return nil
+	case gap == 1:
+		s.current++
+		event(StreakEventExtended, day, s.current)
+	case gap-1 <= s.freezesAvailable:
+		for missed := 1; missed < gap; missed++ {
+			s.freezesAvailable--
+			s.freezesUsed++
+			event(StreakEventFreezeUsed, s.lastDay.AddDate(0, 0, missed), s.current)
+		}
+		s.current++
+		event(StreakEventExtended, day, s.current)
+	default:
+		event(StreakEventReset, day, s.current)
+		s.current = 1
+		event(StreakEventStarted, day, s.current)
+	}
+
+	if s.current%FreezeEarnInterval == 0 && s.freezesAvailable < MaxStreakFreezes {
+		s.freezesAvailable++
+		event(StreakEventFreezeEarned, day, s.current)
+	}
+
+	s.longest = max(s.longest, s.current)
+	s.lastDay = day
+
+	return events
+}
+
+// replayStreak rebuilds a streak from activity timestamps (oldest first) as if
+// each had been recorded as it happened.
+func replayStreak(activity []time.Time, loc *time.Location) (streakState, []StreakEvent) {
+	state := streakState{freezesAvailable: DefaultStreakFreezes}
+	var events []StreakEvent
+	for _, at := range activity {
+		events = append(events, state.advance(streakDay(at, loc))...)
+	}
+	return state, events
+}
+
+// streakDay returns the day t counts for in loc as a UTC midnight, so days can be
+// compared exactly. Activity within StreakGracePeriod after midnight counts for the day before.
+func streakDay(t time.Time, loc *time.Location) time.Time {
+	t = t.Add(-StreakGracePeriod).In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayCount counts the days from a to b, both as returned by streakDay.
+func dayCount(a, b time.Time) int {
+	return int(b.Sub(a).Hours()) / 24
+}
@@ -0,0 +1,241 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestFirstChangedEvent(t *testing.T) {
+	started := StreakEvent{EventType: StreakEventStarted, EventDate: "2026-01-01", StreakValue: 1}
+	extended := StreakEvent{EventType: StreakEventExtended, EventDate: "2026-01-02", StreakValue: 2}
+	freeze := StreakEvent{EventType: StreakEventFreezeUsed, EventDate: "2026-01-02", StreakValue: 1}
+	late := StreakEvent{EventType: StreakEventExtended, EventDate: "2026-01-03", StreakValue: 2}
+
+	tests := []struct {
+		name     string
+		stored   []StreakEvent
+		replayed []StreakEvent
+		want     int
+	}{
+		{"no history", nil, []StreakEvent{started}, 0},
+		{"unchanged", []StreakEvent{started, extended}, []StreakEvent{started, extended}, 2},
+		{"appended", []StreakEvent{started}, []StreakEvent{started, extended}, 1},
+		{"changed in the middle", []StreakEvent{started, freeze, late}, []StreakEvent{started, extended, late}, 1},
+		{"history shrank", []StreakEvent{started, extended}, []StreakEvent{started}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstChangedEvent(tt.stored, tt.replayed); got != tt.want {
+				t.Errorf("firstChangedEvent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// eventStrings renders events as "type date value" for compact comparisons.
+func eventStrings(events []StreakEvent) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		out = append(out, fmt.Sprintf("%s %s %d", e.EventType, e.EventDate, e.StreakValue))
+	}
+	return out
+}
+
+// activityOn returns noon UTC of each given day of March 2026.
+func activityOn(days ...int) []time.Time {
+	activity := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		activity = append(activity, time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC))
+	}
+	return activity
+}
+
+func daysThrough(from, to int) []int {
+	var days []int
+	for d := from; d <= to; d++ {
+		days = append(days, d)
+	}
+	return days
+}
+
+func TestReplayStreak(t *testing.T) {
+	tests := []struct {
+		name          string
+		activity      []time.Time
+		wantEvents    []string
+		wantCurrent   int
+		wantLongest   int
+		wantAvailable int
+		wantUsed      int
+	}{
+		{
+			name:          "no activity",
+			wantAvailable: DefaultStreakFreezes,
+		},
+		{
+			name:          "consecutive days",
+			activity:      activityOn(1, 2, 3),
+			wantEvents:    []string{"started 2026-03-01 1", "extended 2026-03-02 2", "extended 2026-03-03 3"},
+			wantCurrent:   3,
+			wantLongest:   3,
+			wantAvailable: 1,
+		},
+		{
+			name:          "several entries on one day",
+			activity:      activityOn(1, 1, 2),
+			wantEvents:    []string{"started 2026-03-01 1", "extended 2026-03-02 2"},
+			wantCurrent:   2,
+			wantLongest:   2,
+			wantAvailable: 1,
+		},
+		{
+			name:          "gap covered by one freeze",
+			activity:      activityOn(1, 2, 4),
+			wantEvents:    []string{"started 2026-03-01 1", "extended 2026-03-02 2", "freeze_used 2026-03-03 2", "extended 2026-03-04 3"},
+			wantCurrent:   3,
+			wantLongest:   3,
+			wantAvailable: 0,
+			wantUsed:      1,
+		},
+		{
+			name:          "gap larger than the available freezes",
+			activity:      activityOn(1, 2, 5),
+			wantEvents:    []string{"started 2026-03-01 1", "extended 2026-03-02 2", "reset 2026-03-05 2", "started 2026-03-05 1"},
+			wantCurrent:   1,
+			wantLongest:   2,
+			wantAvailable: 1,
+		},
+		{
+			name:     "freeze earned at day 7",
+			activity: activityOn(daysThrough(1, 7)...),
+			wantEvents: []string{
+				"started 2026-03-01 1", "extended 2026-03-02 2", "extended 2026-03-03 3", "extended 2026-03-04 4",
+				"extended 2026-03-05 5", "extended 2026-03-06 6", "extended 2026-03-07 7", "freeze_earned 2026-03-07 7",
+			},
+			wantCurrent:   7,
+			wantLongest:   7,
+			wantAvailable: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, events := replayStreak(tt.activity, time.UTC)
+			if got := eventStrings(events); !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("got events %v, want %v", got, tt.wantEvents)
+			}
+			if state.current != tt.wantCurrent || state.longest != tt.wantLongest {
+				t.Errorf("got current %d, longest %d, want %d, %d", state.current, state.longest, tt.wantCurrent, tt.wantLongest)
+			}
+			if state.freezesAvailable != tt.wantAvailable || state.freezesUsed != tt.wantUsed {
+				t.Errorf("got %d freezes available, %d used, want %d, %d", state.freezesAvailable, state.freezesUsed, tt.wantAvailable, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestReplayStreakFreezeCap(t *testing.T) {
+	tests := []struct {
+		name          string
+		days          []int
+		wantEarned    []string
+		wantAvailable int
+	}{
+		{
+			name:          "no freeze earned beyond the cap",
+			days:          daysThrough(1, 14),
+			wantEarned:    []string{"freeze_earned 2026-03-07 7"},
+			wantAvailable: MaxStreakFreezes,
+		},
+		{
+			name:          "a used freeze is earned back",
+			days:          append(daysThrough(1, 7), daysThrough(9, 22)...), // the 8th is frozen
+			wantEarned:    []string{"freeze_earned 2026-03-07 7", "freeze_earned 2026-03-15 14"},
+			wantAvailable: MaxStreakFreezes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, events := replayStreak(activityOn(tt.days...), time.UTC)
+
+			var earned []string
+			for _, e := range eventStrings(events) {
+				if strings.HasPrefix(e, StreakEventFreezeEarned) {
+					earned = append(earned, e)
+				}
+			}
+			if !slices.Equal(earned, tt.wantEarned) {
+				t.Errorf("got %v, want %v", earned, tt.wantEarned)
+			}
+			if state.freezesAvailable != tt.wantAvailable {
+				t.Errorf("got %d freezes available, want %d", state.freezesAvailable, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestStreakLateEntry(t *testing.T) {
+	_, events := replayStreak(activityOn(1, 2, 3), time.UTC)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	state := streakState{current: 3, longest: 3, freezesAvailable: 1, lastDay: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)}
+	before := state
+
+	if events := state.advance(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)); events != nil {
+		t.Errorf("late entry for an earlier day raised %v", eventStrings(events))
+	}
+	if state != before {
+		t.Errorf("late entry changed the streak: %+v, want %+v", state, before)
+	}
+}
+
+func TestStreakGracePeriod(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dayTests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 2, 2, 59, 0, 0, loc), "2026-03-01"},
+		{time.Date(2026, 3, 2, 3, 0, 0, 0, loc), "2026-03-02"},
+		{time.Date(2026, 3, 2, 3, 1, 0, 0, loc), "2026-03-02"},
+		{time.Date(2026, 3, 2, 23, 59, 0, 0, loc), "2026-03-02"},
+		// 02:59 in Ho Chi Minh City is still the evening before in UTC
+		{time.Date(2026, 3, 1, 19, 59, 0, 0, time.UTC), "2026-03-01"},
+	}
+
+	for _, tt := range dayTests {
+		t.Run(tt.at.In(loc).Format("2006-01-02 15:04"), func(t *testing.T) {
+			if got := streakDay(tt.at, loc).Format(SleepDateLayout); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, loc)
+
+	// Activity at 02:59 on the 3rd still counts for the 2nd and extends the streak
+	_, events := replayStreak([]time.Time{first, time.Date(2026, 3, 3, 2, 59, 0, 0, loc)}, loc)
+	want := []string{"started 2026-03-01 1", "extended 2026-03-02 2"}
+	if got := eventStrings(events); !slices.Equal(got, want) {
+		t.Errorf("at 02:59: got %v, want %v", got, want)
+	}
+
+	// At 03:01 it counts for the 3rd, so the 2nd was missed and a freeze is used
+	_, events = replayStreak([]time.Time{first, time.Date(2026, 3, 3, 3, 1, 0, 0, loc)}, loc)
+	want = []string{"started 2026-03-01 1", "freeze_used 2026-03-02 1", "extended 2026-03-03 2"}
+	if got := eventStrings(events); !slices.Equal(got, want) {
+		t.Errorf("at 03:01: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Activities that can count towards a streak
+const (
+	StreakActivityJournal    = "journal"
+	StreakActivityEmotionLog = "emotion_log"
+	StreakActivityExercise   = "exercise"
+	StreakActivityLearn      = "learn"
+)
+
+// streakActivitySources selects the timestamps of each activity for a user ($1),
+// used to rebuild streaks from history.
+var streakActivitySources = map[string]string{
+	StreakActivityJournal:    `SELECT created_at AS at FROM user_journals WHERE user_id = $1`,
+	StreakActivityEmotionLog: `SELECT created_at AS at FROM emotion_logs WHERE user_id = $1`,
+	StreakActivityExercise:   `SELECT completed_at AS at FROM user_completed_exercises WHERE user_id = $1`,
+	StreakActivityLearn:      `SELECT completed_at AS at FROM user_learned_slide_groups WHERE user_id = $1`,
+}
+
+// DefaultStreakActivities is the comma-separated list of activities counted when none is configured.
+const DefaultStreakActivities = "journal,emotion_log,exercise,learn"
+
+// StreakPolicy is the set of activities that advance a streak.
+type StreakPolicy map[string]bool
+
+// ParseStreakPolicy parses a comma-separated list of activities, e.g. "journal,exercise".
+func ParseStreakPolicy(list string) (StreakPolicy, error) {
+	policy := StreakPolicy{}
+	for _, activity := range strings.Split(list, ",") {
+		activity = strings.TrimSpace(activity)
+		if activity == "" {
+			continue
+		}
+		if _, ok := streakActivitySources[activity]; !ok {
+			return nil, fmt.Errorf("unknown streak activity %q", activity)
+		}
+		policy[activity] = true
+	}
+
+	if len(policy) == 0 {
+		return nil, fmt.Errorf("at least one streak activity is required")
+	}
+
+	return policy, nil
+}
+
+// Counts reports whether the activity advances a streak. A nil policy counts everything.
+func (p StreakPolicy) Counts(activity string) bool {
+	if p == nil {
+		_, ok := streakActivitySources[activity]
+		return ok
+	}
+	return p[activity]
+}
+
+// historyQuery unions the timestamps of every counted activity, oldest first.
+func (p StreakPolicy) historyQuery() string {
+	activities := make([]string, 0, len(streakActivitySources))
+	for activity := range streakActivitySources {
+		if p.Counts(activity) {
+			activities = append(activities, activity)
+		}
+	}
+	sort.Strings(activities)
+
+	selects := make([]string, len(activities))
+	for i, activity := range activities {
+		selects[i] = streakActivitySources[activity]
+	}
+
+	return strings.Join(selects, "\nUNION ALL\n") + "\nORDER BY at"
+}
@@ -0,0 +1,167 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
+)
+
+// TemplateAudience restricts who sees a template. Every non-empty rule must match;
+// an empty audience matches everyone.
+type TemplateAudience struct {
+	// AgeRanges lists accepted user_informations.age_range values.
+	AgeRanges []string `json:"age_ranges,omitempty"`
+	// Locales lists accepted request locales ("en", "vi").
+	Locales []string `json:"locales,omitempty"`
+	// KYC maps onboarding question keys to accepted answers. A user matches a
+	// question when any of their answers to it is accepted.
+	KYC map[string][]string `json:"kyc,omitempty"`
+}
+
+// IsEmpty reports whether the audience has no rules.
+func (a TemplateAudience) IsEmpty() bool {
+	return len(a.AgeRanges) == 0 && len(a.Locales) == 0 && len(a.KYC) == 0
+}
+
+// Matches reports whether a user with the given information, browsing in the
+// given locale, is part of the audience. info may be nil for users who have not
+// finished onboarding; they only match rules that do not need it.
+func (a TemplateAudience) Matches(info *UserInformation, locale string) bool {
+	if len(a.Locales) > 0 && !containsFold(a.Locales, locale) {
+		return false
+	}
+
+	if len(a.AgeRanges) > 0 && (info == nil || !containsFold(a.AgeRanges, info.AgeRange)) {
+		return false
+	}
+
+	for question, accepted := range a.KYC {
+		if len(accepted) == 0 {
+			continue
+		}
+		if info == nil || !kycAnswerAccepted(info.KYCAnswers[question], accepted) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// kycAnswerAccepted handles single answers as well as multi-select answers stored as arrays.
+func kycAnswerAccepted(answer any, accepted []string) bool {
+	switch v := answer.(type) {
+	case nil:
+		return false
+	case []any:
+		for _, item := range v {
+			if kycAnswerAccepted(item, accepted) {
+				return true
+			}
+		}
+		return false
+	case string:
+		return containsFold(accepted, v)
+	default:
+		return containsFold(accepted, fmt.Sprint(v))
+	}
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplatePublishing is the editable visibility of a template.
+type TemplatePublishing struct {
+	IsActive    bool             `json:"is_active"`
+	PublishAt   *time.Time       `json:"publish_at"`
+	UnpublishAt *time.Time       `json:"unpublish_at"`
+	Audience    TemplateAudience `json:"audience"`
+}
+
+func ValidateTemplatePublishing(v *validator.Validator, p *TemplatePublishing) {
+	if p.PublishAt != nil && p.UnpublishAt != nil {
+		v.Check(p.UnpublishAt.After(*p.PublishAt), "unpublish_at", "must be after publish_at")
+	}
+
+	for _, locale := range p.Audience.Locales {
+		v.Check(validator.In(locale, "en", "vi"), "audience.locales", "must only contain en or vi")
+	}
+
+	for question := range p.Audience.KYC {
+		v.Check(strings.TrimSpace(question) != "", "audience.kyc", "question keys must not be empty")
+	}
+}
+
+// Publishing returns the template's visibility settings, including the audience
+// rules that are left out of its JSON.
+func (t *JournalTemplate) Publishing() TemplatePublishing {
+	return TemplatePublishing{
+		IsActive:    t.IsActive,
+		PublishAt:   t.PublishAt,
+		UnpublishAt: t.UnpublishAt,
+		Audience:    t.Audience,
+	}
+}
+
+// IsPublished reports whether the template is active and inside its publish window at now.
+func (t *JournalTemplate) IsPublished(now time.Time) bool {
+	if !t.IsActive {
+		return false
+	}
+	if t.PublishAt != nil && now.Before(*t.PublishAt) {
+		return false
+	}
+	return t.UnpublishAt == nil || now.Before(*t.UnpublishAt)
+}
+
+// UpdatePublishing replaces the visibility settings of a template.
+func (journal UserJournalModel) UpdatePublishing(id uuid.UUID, p *TemplatePublishing) (*JournalTemplate, error) {
+	query := `
+		UPDATE journal_templates
+		SET is_active = $1, publish_at = $2, unpublish_at = $3, audience = $4
+		WHERE id = $5
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	audienceJSON, err := json.Marshal(p.Audience)
+	if err != nil {
+		return nil, err
+	}
+
+	// The columns are TIMESTAMP, so offsets must be resolved before binding
+	result, err := journal.DB.ExecContext(ctx, query, p.IsActive, utcTime(p.PublishAt), utcTime(p.UnpublishAt), audienceJSON, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return journal.GetTemplate(id)
+}
+
+// utcTime converts an optional time to UTC for TIMESTAMP columns.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
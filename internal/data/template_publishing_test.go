@@ -0,0 +1,101 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplateAudienceMatches(t *testing.T) {
+	onboarded := &UserInformation{
+		AgeRange: "18-24",
+		KYCAnswers: map[string]any{
+			"main_goal":  "Reduce stress",
+			"struggles":  []any{"sleep", "anxiety"},
+			"sessions":   float64(3),
+			"unanswered": nil,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		audience TemplateAudience
+		info     *UserInformation
+		locale   string
+		want     bool
+	}{
+		{"empty audience, no info", TemplateAudience{}, nil, "en", true},
+		{"empty audience", TemplateAudience{}, onboarded, "vi", true},
+		{"locale rule matches without info", TemplateAudience{Locales: []string{"vi"}}, nil, "vi", true},
+		{"locale rule is case-insensitive", TemplateAudience{Locales: []string{"VI"}}, onboarded, "vi", true},
+		{"locale rule rejects other locales", TemplateAudience{Locales: []string{"vi"}}, onboarded, "en", false},
+		{"age range matches", TemplateAudience{AgeRanges: []string{"18-24", "25-34"}}, onboarded, "en", true},
+		{"age range rejects other ranges", TemplateAudience{AgeRanges: []string{"25-34"}}, onboarded, "en", false},
+		{"age range needs info", TemplateAudience{AgeRanges: []string{"18-24"}}, nil, "en", false},
+		{"single KYC answer", TemplateAudience{KYC: map[string][]string{"main_goal": {"reduce stress"}}}, onboarded, "en", true},
+		{"single KYC answer not accepted", TemplateAudience{KYC: map[string][]string{"main_goal": {"Sleep better"}}}, onboarded, "en", false},
+		{"numeric KYC answer", TemplateAudience{KYC: map[string][]string{"sessions": {"3"}}}, onboarded, "en", true},
+		{"multi-select KYC answer", TemplateAudience{KYC: map[string][]string{"struggles": {"anxiety", "grief"}}}, onboarded, "en", true},
+		{"multi-select KYC answer not accepted", TemplateAudience{KYC: map[string][]string{"struggles": {"grief"}}}, onboarded, "en", false},
+		{"KYC question not answered", TemplateAudience{KYC: map[string][]string{"unanswered": {"yes"}}}, onboarded, "en", false},
+		{"KYC question missing", TemplateAudience{KYC: map[string][]string{"other": {"yes"}}}, onboarded, "en", false},
+		{"KYC rule needs info", TemplateAudience{KYC: map[string][]string{"main_goal": {"Reduce stress"}}}, nil, "en", false},
+		{"empty accepted list is ignored", TemplateAudience{KYC: map[string][]string{"main_goal": {}}}, nil, "en", true},
+		{
+			name:     "every rule must match",
+			audience: TemplateAudience{Locales: []string{"en"}, AgeRanges: []string{"18-24"}, KYC: map[string][]string{"struggles": {"sleep"}}},
+			info:     onboarded,
+			locale:   "vi",
+			want:     false,
+		},
+		{
+			name:     "all rules match",
+			audience: TemplateAudience{Locales: []string{"en"}, AgeRanges: []string{"18-24"}, KYC: map[string][]string{"struggles": {"sleep"}}},
+			info:     onboarded,
+			locale:   "en",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.audience.Matches(tt.info, tt.locale); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateAudienceIsEmpty(t *testing.T) {
+	if !(TemplateAudience{}).IsEmpty() {
+		t.Error("zero audience is not empty")
+	}
+	if (TemplateAudience{KYC: map[string][]string{"main_goal": {"x"}}}).IsEmpty() {
+		t.Error("audience with a KYC rule is empty")
+	}
+}
+
+func TestJournalTemplateIsPublished(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		template JournalTemplate
+		want     bool
+	}{
+		{"active without a window", JournalTemplate{IsActive: true}, true},
+		{"inactive", JournalTemplate{IsActive: false}, false},
+		{"scheduled", JournalTemplate{IsActive: true, PublishAt: &after}, false},
+		{"published", JournalTemplate{IsActive: true, PublishAt: &before, UnpublishAt: &after}, true},
+		{"unpublished", JournalTemplate{IsActive: true, UnpublishAt: &before}, false},
+		{"unpublished at now", JournalTemplate{IsActive: true, UnpublishAt: &now}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.template.IsPublished(now); got != tt.want {
+				t.Errorf("IsPublished() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
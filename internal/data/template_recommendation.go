@@ -0,0 +1,222 @@
+package data
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecommendationSignals is everything the ranking knows about a user.
+type RecommendationSignals struct {
+	// RecentMoodAverage is the average mood_score of the last 7 days, nil when unknown.
+	RecentMoodAverage *float64
+	// MoodTrend is the last 7 days' average minus the 7 days before, nil when unknown.
+	MoodTrend *float64
+	// EmotionCounts maps emotion codes (or lower-cased legacy labels) logged recently to their frequency.
+	EmotionCounts map[string]int
+	// CollectionUsage maps collection IDs to the number of journals written with them.
+	CollectionUsage map[uuid.UUID]int
+	// LearnProgress maps learn collection IDs to the user's progress in them.
+	LearnProgress map[uuid.UUID]CollectionProgress
+}
+
+// TemplateRecommendation is a ranked template with the reason it was picked.
+type TemplateRecommendation struct {
+	Template *JournalTemplate `json:"template"`
+	Score    float64          `json:"score"`
+	Reason   string           `json:"reason"`
+}
+
+// emotionCategories maps branches of the emotion taxonomy to the template categories
+// that help with them. A branch covers its own code and every code below it.
+var emotionCategories = []struct {
+	branches   []string
+	categories []string
+}{
+	{[]string{"fear"}, []string{"anxiety", "mindfulness"}},
+	{[]string{"sadness"}, []string{"self_care", "emotions"}},
+	{[]string{"anger"}, []string{"emotions", "mindfulness"}},
+	{[]string{"tired"}, []string{"sleep", "self_care"}},
+	{[]string{"joy"}, []string{"gratitude", "relationships"}},
+}
+
+const (
+	lowMoodThreshold     = 4.0
+	highMoodThreshold    = 7.0
+	decliningMoodTrend   = -1.0
+	frequentUseThreshold = 3
+)
+
+// SummarizeMoodTrend computes the 7-day average mood and its change against the
+// previous 7 days from points observed up to now.
+func SummarizeMoodTrend(points []MoodPoint, now time.Time) (average *float64, trend *float64) {
+	recentStart := now.AddDate(0, 0, -7)
+	previousStart := now.AddDate(0, 0, -14)
+
+	var recentSum, previousSum, recentN, previousN float64
+	for _, p := range points {
+		switch {
+		case !p.At.Before(recentStart) && !p.At.After(now):
+			recentSum += float64(p.Score)
+			recentN++
+		case !p.At.Before(previousStart) && p.At.Before(recentStart):
+			previousSum += float64(p.Score)
+			previousN++
+		}
+	}
+
+	if recentN == 0 {
+		return nil, nil
+	}
+
+	avg := recentSum / recentN
+	average = &avg
+
+	if previousN > 0 {
+		delta := avg - previousSum/previousN
+		trend = &delta
+	}
+
+	return average, trend
+}
+
+// RankTemplates scores every template against the user's signals and returns them
+// best first. Ties keep the gallery order (category, title) so results are stable.
+func RankTemplates(templates []*JournalTemplate, signals RecommendationSignals) []TemplateRecommendation {
+	helpfulCategories, emotionHint := categoriesForEmotions(signals.EmotionCounts)
+
+	recommendations := make([]TemplateRecommendation, 0, len(templates))
+	for _, t := range templates {
+		score, reason := scoreTemplate(t, signals, helpfulCategories, emotionHint)
+		recommendations = append(recommendations, TemplateRecommendation{
+			Template: t,
+			Score:    math.Round(score*100) / 100,
+			Reason:   reason,
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Template.Category != b.Template.Category {
+			return a.Template.Category < b.Template.Category
+		}
+		return a.Template.Title < b.Template.Title
+	})
+
+	return recommendations
+}
+
+// scoreTemplate adds up weighted contributions; the reason shown to the user is the
+// one belonging to the largest positive contribution.
+func scoreTemplate(t *JournalTemplate, signals RecommendationSignals, helpfulCategories map[string]float64, emotionHint string) (float64, string) {
+	score := 1.0
+	bestContribution := 0.0
+	reason := "A good place to start"
+
+	add := func(points float64, why string) {
+		score += points
+		if points > bestContribution {
+			bestContribution = points
+			reason = why
+		}
+	}
+
+	if t.Type == "learn" {
+		if progress, ok := signals.LearnProgress[t.ID]; ok {
+			switch {
+			case progress.IsCompleted:
+				score -= 3
+			case progress.CompletedSlideGroups > 0:
+				add(2.5, "Continue where you left off")
+			}
+		}
+	}
+
+	if weight, ok := helpfulCategories[t.Category]; ok {
+		add(1.5*weight, "Matches how you've been feeling lately ("+emotionHint+")")
+	}
+
+	if signals.RecentMoodAverage != nil {
+		declining := signals.MoodTrend != nil && *signals.MoodTrend <= decliningMoodTrend
+		switch {
+		case *signals.RecentMoodAverage <= lowMoodThreshold || declining:
+			if t.Category == "self_care" || t.Category == "mental_health" || t.Category == "emotions" {
+				add(1.5, "Gentle support while your mood has been lower")
+			}
+		case *signals.RecentMoodAverage >= highMoodThreshold:
+			if t.Category == "gratitude" || t.Category == "relationships" {
+				add(1.0, "Builds on your positive mood")
+			}
+		}
+	}
+
+	if t.Type != "learn" {
+		uses := signals.CollectionUsage[t.ID]
+		switch {
+		case uses >= frequentUseThreshold:
+			add(0.15*math.Min(float64(uses), 10), "You often come back to this one")
+		case uses == 0:
+			add(0.5, "Something new to try")
+		}
+	} else if _, started := signals.LearnProgress[t.ID]; !started {
+		add(0.5, "Something new to learn")
+	}
+
+	return score, reason
+}
+
+// categoriesForEmotions returns category weights (share of recent emotions that point
+// to each category) and the most frequent matching emotion for the reason text.
+func categoriesForEmotions(counts map[string]int) (map[string]float64, string) {
+	weights := make(map[string]float64)
+
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	if total == 0 {
+		return weights, ""
+	}
+
+	topEmotion := ""
+	topCount := 0
+	for emotion, count := range counts {
+		codes := EmotionCodesIn(emotion)
+		matched := false
+		for _, group := range emotionCategories {
+			if !slices.ContainsFunc(codes, func(code string) bool { return InEmotionBranch(code, group.branches) }) {
+				continue
+			}
+			matched = true
+			for _, category := range group.categories {
+				weights[category] += float64(count) / float64(total)
+			}
+		}
+
+		if matched && (count > topCount || (count == topCount && emotion < topEmotion)) {
+			topEmotion = emotion
+			topCount = count
+		}
+	}
+
+	for category, weight := range weights {
+		weights[category] = math.Min(weight, 1)
+	}
+
+	return weights, emotionDisplayName(topEmotion)
+}
+
+// emotionDisplayName turns "fear.anxious" or "anxious" into "anxious".
+func emotionDisplayName(emotion string) string {
+	if i := strings.LastIndex(emotion, "."); i >= 0 {
+		return emotion[i+1:]
+	}
+	return emotion
+}
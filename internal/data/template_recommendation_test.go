@@ -0,0 +1,231 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCategoriesForEmotions(t *testing.T) {
+	tests := []struct {
+		name     string
+		counts   map[string]int
+		want     map[string]float64
+		wantHint string
+	}{
+		{
+			name:     "taxonomy code",
+			counts:   map[string]int{"fear.anxious.worried": 2},
+			want:     map[string]float64{"anxiety": 1, "mindfulness": 1},
+			wantHint: "worried",
+		},
+		{
+			name:     "legacy label",
+			counts:   map[string]int{"lonely": 1},
+			want:     map[string]float64{"self_care": 1, "emotions": 1},
+			wantHint: "lonely",
+		},
+		{
+			name:     "word of a legacy label",
+			counts:   map[string]int{"feeling grateful": 1},
+			want:     map[string]float64{"gratitude": 1, "relationships": 1},
+			wantHint: "feeling grateful",
+		},
+		{
+			name:   "substring of a label does not match",
+			counts: map[string]int{"discontent": 3, "downtown": 1, "lovely weather": 1},
+			want:   map[string]float64{},
+		},
+		{
+			name:   "unmapped legacy labels",
+			counts: map[string]int{"storm": 2, "partly cloudy": 1},
+			want:   map[string]float64{},
+		},
+		{
+			name:     "weights are shares of all emotions",
+			counts:   map[string]int{"anger.frustrated": 3, "storm": 1},
+			want:     map[string]float64{"emotions": 0.75, "mindfulness": 0.75},
+			wantHint: "frustrated",
+		},
+		{
+			name:     "shared categories add up and are capped",
+			counts:   map[string]int{"fear": 1, "anger": 1},
+			want:     map[string]float64{"anxiety": 0.5, "mindfulness": 1, "emotions": 0.5},
+			wantHint: "anger",
+		},
+		{
+			name:   "no emotions",
+			counts: nil,
+			want:   map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hint := categoriesForEmotions(tt.counts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got categories %v, want %v", got, tt.want)
+			}
+			for category, weight := range tt.want {
+				if got[category] != weight {
+					t.Errorf("category %q: got weight %v, want %v", category, got[category], weight)
+				}
+			}
+			if hint != tt.wantHint {
+				t.Errorf("got hint %q, want %q", hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestScoreTemplate(t *testing.T) {
+	low, high, declining := 3.0, 8.0, -2.0
+	learnID := uuid.New()
+
+	tests := []struct {
+		name       string
+		template   *JournalTemplate
+		signals    RecommendationSignals
+		helpful    map[string]float64
+		wantScore  float64
+		wantReason string
+	}{
+		{
+			name:       "unused journal template",
+			template:   &JournalTemplate{Type: "journal", Category: "daily"},
+			wantScore:  1.5,
+			wantReason: "Something new to try",
+		},
+		{
+			name:       "matching emotion category",
+			template:   &JournalTemplate{ID: uuid.New(), Type: "journal", Category: "anxiety"},
+			helpful:    map[string]float64{"anxiety": 1},
+			wantScore:  3,
+			wantReason: "Matches how you've been feeling lately (anxious)",
+		},
+		{
+			name:       "low mood favours support",
+			template:   &JournalTemplate{Type: "journal", Category: "self_care"},
+			signals:    RecommendationSignals{RecentMoodAverage: &high, MoodTrend: &declining},
+			wantScore:  3,
+			wantReason: "Gentle support while your mood has been lower",
+		},
+		{
+			name:       "high mood favours gratitude",
+			template:   &JournalTemplate{Type: "journal", Category: "gratitude"},
+			signals:    RecommendationSignals{RecentMoodAverage: &high},
+			wantScore:  2.5,
+			wantReason: "Builds on your positive mood",
+		},
+		{
+			name:       "low mood ignores other categories",
+			template:   &JournalTemplate{Type: "journal", Category: "gratitude"},
+			signals:    RecommendationSignals{RecentMoodAverage: &low},
+			wantScore:  1.5,
+			wantReason: "Something new to try",
+		},
+		{
+			name:     "frequently used",
+			template: &JournalTemplate{ID: learnID, Type: "journal", Category: "daily"},
+			signals: RecommendationSignals{
+				CollectionUsage: map[uuid.UUID]int{learnID: 4},
+			},
+			wantScore:  1.6,
+			wantReason: "You often come back to this one",
+		},
+		{
+			name:     "learn collection in progress",
+			template: &JournalTemplate{ID: learnID, Type: "learn", Category: "mindfulness"},
+			signals: RecommendationSignals{
+				LearnProgress: map[uuid.UUID]CollectionProgress{learnID: {CompletedSlideGroups: 1}},
+			},
+			wantScore:  3.5,
+			wantReason: "Continue where you left off",
+		},
+		{
+			name:     "completed learn collection sinks",
+			template: &JournalTemplate{ID: learnID, Type: "learn", Category: "mindfulness"},
+			signals: RecommendationSignals{
+				LearnProgress: map[uuid.UUID]CollectionProgress{learnID: {IsCompleted: true}},
+			},
+			wantScore:  -2,
+			wantReason: "A good place to start",
+		},
+		{
+			name:       "new learn collection",
+			template:   &JournalTemplate{ID: learnID, Type: "learn", Category: "mindfulness"},
+			wantScore:  1.5,
+			wantReason: "Something new to learn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reason := scoreTemplate(tt.template, tt.signals, tt.helpful, "anxious")
+			if score != tt.wantScore {
+				t.Errorf("got score %v, want %v", score, tt.wantScore)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("got reason %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRankTemplates(t *testing.T) {
+	inProgress := &JournalTemplate{ID: uuid.New(), Title: "Breathing basics", Type: "learn", Category: "mindfulness"}
+	anxiety := &JournalTemplate{ID: uuid.New(), Title: "Worry time", Type: "journal", Category: "anxiety"}
+	dailyB := &JournalTemplate{ID: uuid.New(), Title: "B daily", Type: "journal", Category: "daily"}
+	dailyA := &JournalTemplate{ID: uuid.New(), Title: "A daily", Type: "journal", Category: "daily"}
+	completed := &JournalTemplate{ID: uuid.New(), Title: "Done", Type: "learn", Category: "mindfulness"}
+
+	signals := RecommendationSignals{
+		EmotionCounts: map[string]int{"fear.anxious": 1, "storm": 1},
+		LearnProgress: map[uuid.UUID]CollectionProgress{
+			inProgress.ID: {CompletedSlideGroups: 2},
+			completed.ID:  {IsCompleted: true},
+		},
+	}
+
+	got := RankTemplates([]*JournalTemplate{completed, dailyB, dailyA, anxiety, inProgress}, signals)
+
+	want := []*JournalTemplate{inProgress, anxiety, dailyA, dailyB, completed}
+	if len(got) != len(want) {
+		t.Fatalf("got %d recommendations, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Template != want[i] {
+			t.Errorf("position %d: got %q (score %v), want %q", i, got[i].Template.Title, got[i].Score, want[i].Title)
+		}
+	}
+
+	if got[1].Reason != "Matches how you've been feeling lately (anxious)" {
+		t.Errorf("got reason %q for the anxiety template", got[1].Reason)
+	}
+}
+
+func TestSummarizeMoodTrend(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	average, trend := SummarizeMoodTrend(nil, now)
+	if average != nil || trend != nil {
+		t.Fatalf("got %v, %v without points, want nil, nil", average, trend)
+	}
+
+	points := []MoodPoint{
+		{Score: 8, At: now.AddDate(0, 0, -10)},
+		{Score: 6, At: now.AddDate(0, 0, -9)},
+		{Score: 4, At: now.AddDate(0, 0, -2)},
+		{Score: 2, At: now.AddDate(0, 0, -1)},
+		{Score: 10, At: now.AddDate(0, 0, -20)}, // outside both weeks
+	}
+
+	average, trend = SummarizeMoodTrend(points, now)
+	if average == nil || *average != 3 {
+		t.Errorf("got average %v, want 3", average)
+	}
+	if trend == nil || *trend != -4 {
+		t.Errorf("got trend %v, want -4", trend)
+	}
+}
@@ -161,6 +161,24 @@ func (m TherapySessionModel) GetAllByUser(userID string) ([]*TherapySession, err
 	return sessions, nil
 }
 
+// HasUpcoming reports whether the user has a scheduled session at or after the given time
+func (m TherapySessionModel) HasUpcoming(userID string, after time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM therapy_sessions
+			WHERE user_id = $1 AND status = 'scheduled' AND session_date >= $2
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := m.DB.QueryRowContext(ctx, query, userID, after).Scan(&exists)
+
+	return exists, err
+}
+
 // Update modifies an existing therapy session (only non-nil fields)
 func (m TherapySessionModel) Update(session *TherapySession) error {
 	query := `
@@ -11,11 +11,27 @@ import (
 )
 
 type UserCompletedExercise struct {
-	Id          int64     `json:"id"`
-	UserId      uuid.UUID `json:"user_id"`
-	ExerciseId  int       `json:"exercise_id"`
-	Duration    int8      `json:"duration"`
-	CompletedAt time.Time `json:"completed_at"`
+	Id         uuid.UUID `json:"id"`
+	UserId     uuid.UUID `json:"user_id"`
+	ExerciseId uuid.UUID `json:"exercise_id"`
+	Duration   int       `json:"duration"`
+	// DurationSeconds is the measured active time when the completion came from a session.
+	DurationSeconds *int       `json:"duration_seconds"`
+	SessionId       *uuid.UUID `json:"session_id"`
+	// RoutineSessionId is set when the exercise was completed as a step of a routine.
+	RoutineSessionId *uuid.UUID `json:"routine_session_id"`
+	CompletedAt      time.Time  `json:"completed_at"`
+}
+
+// CompletedExerciseAggregates summarises the completions in a time range.
+type CompletedExerciseAggregates struct {
+	TotalCompletions  int `json:"total_completions"`
+	DistinctExercises int `json:"distinct_exercises"`
+	// TotalSeconds and AverageSeconds only cover completions recorded through sessions.
+	TotalSeconds   int     `json:"total_seconds"`
+	AverageSeconds float64 `json:"average_seconds"`
+	// AverageMoodChange is the mean of mood_after - mood_before over sessions rating both.
+	AverageMoodChange *float64 `json:"average_mood_change"`
 }
 
 type UserCompletedExerciseModel struct {
@@ -23,13 +39,14 @@ type UserCompletedExerciseModel struct {
 }
 
 func UserCompleteExercise(v *validator.Validator, uce *UserCompletedExercise) {
-	v.Check(uce.Duration > 0, "week_number", "week_number must be between 1 and 7")
+	v.Check(uce.ExerciseId != uuid.Nil, "exercise_id", "must be provided")
+	v.Check(uce.Duration > 0, "duration", "must be greater than zero")
 }
 
 func (uce *UserCompletedExerciseModel) Insert(completeExercise *UserCompletedExercise) error {
 	query := `INSERT INTO user_completed_exercises (user_id, duration, exercise_id)
 			 VALUES ($1, $2, $3)
-			 RETURNING *`
+			 RETURNING id, user_id, duration, exercise_id, duration_seconds, session_id, routine_session_id, completed_at`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 
@@ -38,6 +55,8 @@ func (uce *UserCompletedExerciseModel) Insert(completeExercise *UserCompletedExe
 	args := []any{completeExercise.UserId, completeExercise.Duration, completeExercise.ExerciseId}
 	argsResponse := []any{&completeExercise.Id, &completeExercise.UserId,
 		&completeExercise.Duration, &completeExercise.ExerciseId,
+		&completeExercise.DurationSeconds, &completeExercise.SessionId,
+		&completeExercise.RoutineSessionId,
 		&completeExercise.CompletedAt}
 
 	return uce.DB.QueryRowContext(ctx, query, args...).Scan(argsResponse...)
@@ -45,7 +64,7 @@ func (uce *UserCompletedExerciseModel) Insert(completeExercise *UserCompletedExe
 
 func (e UserCompletedExerciseModel) GetList(fromTime, toTime time.Time, userID uuid.UUID, filter *QueryFilter) ([]*UserCompletedExercise, Metadata, error) {
 	query := fmt.Sprintf(`
-					SELECT COUNT(*) OVER(), user_id, duration , exercise_id , completed_at FROM user_completed_exercises 
+					SELECT COUNT(*) OVER(), id, user_id, COALESCE(duration, 0), exercise_id, duration_seconds, session_id, routine_session_id, completed_at FROM user_completed_exercises 
 					WHERE completed_at BETWEEN $1 AND $2
 					AND user_id = $3
 					ORDER BY %s %s, id DESC
@@ -68,8 +87,11 @@ func (e UserCompletedExerciseModel) GetList(fromTime, toTime time.Time, userID u
 		var completedExercise UserCompletedExercise
 		err = rows.Scan(
 			&totalRecords,
+			&completedExercise.Id,
 			&completedExercise.UserId,
 			&completedExercise.Duration, &completedExercise.ExerciseId,
+			&completedExercise.DurationSeconds, &completedExercise.SessionId,
+			&completedExercise.RoutineSessionId,
 			&completedExercise.CompletedAt,
 		)
 
@@ -88,3 +110,61 @@ func (e UserCompletedExerciseModel) GetList(fromTime, toTime time.Time, userID u
 
 	return completedExercises, metadata, nil
 }
+
+// Aggregates summarises the user's completions between fromTime and toTime.
+func (e UserCompletedExerciseModel) Aggregates(fromTime, toTime time.Time, userID uuid.UUID) (CompletedExerciseAggregates, error) {
+	query := `
+		SELECT COUNT(*), COUNT(DISTINCT c.exercise_id),
+		       COALESCE(SUM(c.duration_seconds), 0), COALESCE(AVG(c.duration_seconds), 0),
+		       AVG(s.mood_after - s.mood_before)
+		FROM user_completed_exercises c
+		LEFT JOIN exercise_sessions s ON s.id = c.session_id
+		WHERE c.completed_at BETWEEN $1 AND $2
+		AND c.user_id = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var aggregates CompletedExerciseAggregates
+	err := e.DB.QueryRowContext(ctx, query, fromTime, toTime, userID).Scan(
+		&aggregates.TotalCompletions,
+		&aggregates.DistinctExercises,
+		&aggregates.TotalSeconds,
+		&aggregates.AverageSeconds,
+		&aggregates.AverageMoodChange,
+	)
+
+	return aggregates, err
+}
+
+// HistoryByExercise returns the user's completion count and average session mood
+// change for every exercise they completed.
+func (e UserCompletedExerciseModel) HistoryByExercise(userID uuid.UUID) (map[uuid.UUID]ExerciseHistory, error) {
+	query := `
+		SELECT c.exercise_id, COUNT(*), AVG(s.mood_after - s.mood_before)
+		FROM user_completed_exercises c
+		LEFT JOIN exercise_sessions s ON s.id = c.session_id
+		WHERE c.user_id = $1 AND c.exercise_id IS NOT NULL
+		GROUP BY c.exercise_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := e.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := map[uuid.UUID]ExerciseHistory{}
+	for rows.Next() {
+		var exerciseID uuid.UUID
+		var h ExerciseHistory
+		if err := rows.Scan(&exerciseID, &h.Completions, &h.AverageMoodChange); err != nil {
+			return nil, err
+		}
+		history[exerciseID] = h
+	}
+
+	return history, rows.Err()
+}
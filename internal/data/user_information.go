@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"tranquara.net/internal/validator"
 )
 
 type UserInformation struct {
@@ -198,3 +199,47 @@ func (m UserInformationModel) Update(info *UserInformation) error {
 
 	return nil
 }
+
+// TimezoneSettingKey is the settings key holding the user's IANA timezone, e.g. "Asia/Ho_Chi_Minh".
+const TimezoneSettingKey = "timezone"
+
+// Location returns the user's timezone from settings, or UTC when it is missing or invalid.
+func (info *UserInformation) Location() *time.Location {
+	name := info.Timezone()
+	if name == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Timezone returns the raw timezone setting, or "" when none is set.
+func (info *UserInformation) Timezone() string {
+	if info == nil {
+		return ""
+	}
+	name, _ := info.Settings[TimezoneSettingKey].(string)
+	return name
+}
+
+// ValidateSettings checks the known settings keys.
+func ValidateSettings(v *validator.Validator, settings map[string]any) {
+	raw, ok := settings[TimezoneSettingKey]
+	if !ok || raw == nil {
+		return
+	}
+
+	name, isString := raw.(string)
+	if !isString {
+		v.AddError("settings.timezone", "must be a string")
+		return
+	}
+
+	_, err := time.LoadLocation(name)
+	v.Check(name != "" && err == nil, "settings.timezone", "must be a valid IANA timezone, e.g. Asia/Ho_Chi_Minh")
+}
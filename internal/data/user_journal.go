@@ -37,26 +37,41 @@ type SlideGroup struct {
 // SlideData represents individual slide configuration
 type SlideData struct {
 	ID       string                 `json:"id"`
-	Type     string                 `json:"type"` // emotion_log, sleep_check, journal_prompt, doc
+	Type     string                 `json:"type"` // emotion_log, sleep_check, journal_prompt, doc, quiz
 	Question string                 `json:"question,omitempty"`
 	Title    string                 `json:"title,omitempty"`
 	Content  string                 `json:"content,omitempty"`
 	Config   map[string]interface{} `json:"config,omitempty"`
+
+	// Quiz slides only. CorrectOptionIDs and Explanation stay server-side and are
+	// stripped before templates are sent to clients.
+	Options          []QuizOption `json:"options,omitempty"`
+	CorrectOptionIDs []string     `json:"correct_option_ids,omitempty"`
+	Explanation      string       `json:"explanation,omitempty"`
+}
+
+// QuizOption is one selectable answer of a quiz slide
+type QuizOption struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
 }
 
 type JournalTemplate struct {
-	ID            uuid.UUID       `json:"id"`
-	Title         string          `json:"title"`
-	TitleVi       *string         `json:"title_vi,omitempty"`
-	Description   *string         `json:"description,omitempty"`
-	DescriptionVi *string         `json:"description_vi,omitempty"`
-	Category      string          `json:"category"`
-	Type          string          `json:"type"` // "journal" or "learn"
-	SlideGroups   json.RawMessage `json:"slide_groups"`
-	SlideGroupsVi json.RawMessage `json:"slide_groups_vi,omitempty"`
-	IsActive      bool            `json:"is_active"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
+	ID            uuid.UUID        `json:"id"`
+	Title         string           `json:"title"`
+	TitleVi       *string          `json:"title_vi,omitempty"`
+	Description   *string          `json:"description,omitempty"`
+	DescriptionVi *string          `json:"description_vi,omitempty"`
+	Category      string           `json:"category"`
+	Type          string           `json:"type"` // "journal" or "learn"
+	SlideGroups   json.RawMessage  `json:"slide_groups"`
+	SlideGroupsVi json.RawMessage  `json:"slide_groups_vi,omitempty"`
+	IsActive      bool             `json:"is_active"`
+	PublishAt     *time.Time       `json:"publish_at,omitempty"`
+	UnpublishAt   *time.Time       `json:"unpublish_at,omitempty"`
+	Audience      TemplateAudience `json:"-"` // admin only, see Publishing
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
 }
 
 type UserJournalModel struct {
@@ -104,9 +119,12 @@ func (journal UserJournalModel) Get(id uuid.UUID, userID uuid.UUID) (*UserJourna
 func (journal UserJournalModel) GetAllTemplates() ([]*JournalTemplate, error) {
 	query := `
 		SELECT id, title, title_vi, description, description_vi, category, type, 
-		       slide_groups, slide_groups_vi, is_active, created_at, updated_at 
+		       slide_groups, slide_groups_vi, is_active, publish_at, unpublish_at, audience,
+		       created_at, updated_at
 		FROM journal_templates
 		WHERE is_active = true
+		  AND (publish_at IS NULL OR publish_at <= CURRENT_TIMESTAMP)
+		  AND (unpublish_at IS NULL OR unpublish_at > CURRENT_TIMESTAMP)
 		ORDER BY category, title
 	`
 
@@ -125,6 +143,7 @@ func (journal UserJournalModel) GetAllTemplates() ([]*JournalTemplate, error) {
 		var journalTemplate JournalTemplate
 		var slideGroupsRaw []byte
 		var slideGroupsViRaw []byte
+		var audienceRaw []byte
 
 		err = rows.Scan(
 			&journalTemplate.ID,
@@ -137,6 +156,9 @@ func (journal UserJournalModel) GetAllTemplates() ([]*JournalTemplate, error) {
 			&slideGroupsRaw,
 			&slideGroupsViRaw,
 			&journalTemplate.IsActive,
+			&journalTemplate.PublishAt,
+			&journalTemplate.UnpublishAt,
+			&audienceRaw,
 			&journalTemplate.CreatedAt,
 			&journalTemplate.UpdatedAt,
 		)
@@ -152,6 +174,11 @@ func (journal UserJournalModel) GetAllTemplates() ([]*JournalTemplate, error) {
 			journalTemplate.SlideGroupsVi = json.RawMessage(slideGroupsViRaw)
 		}
 
+		err = json.Unmarshal(audienceRaw, &journalTemplate.Audience)
+		if err != nil {
+			return nil, err
+		}
+
 		journalTemplates = append(journalTemplates, &journalTemplate)
 	}
 
@@ -167,6 +194,99 @@ func (journal UserJournalModel) GetAllTemplates() ([]*JournalTemplate, error) {
 	return journalTemplates, nil
 }
 
+// GetTemplate retrieves a single collection by ID, including inactive ones.
+func (journal UserJournalModel) GetTemplate(id uuid.UUID) (*JournalTemplate, error) {
+	query := `
+		SELECT id, title, title_vi, description, description_vi, category, type,
+		       slide_groups, slide_groups_vi, is_active, publish_at, unpublish_at, audience,
+		       created_at, updated_at
+		FROM journal_templates
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var journalTemplate JournalTemplate
+	var slideGroupsRaw []byte
+	var slideGroupsViRaw []byte
+	var audienceRaw []byte
+
+	err := journal.DB.QueryRowContext(ctx, query, id).Scan(
+		&journalTemplate.ID,
+		&journalTemplate.Title,
+		&journalTemplate.TitleVi,
+		&journalTemplate.Description,
+		&journalTemplate.DescriptionVi,
+		&journalTemplate.Category,
+		&journalTemplate.Type,
+		&slideGroupsRaw,
+		&slideGroupsViRaw,
+		&journalTemplate.IsActive,
+		&journalTemplate.PublishAt,
+		&journalTemplate.UnpublishAt,
+		&audienceRaw,
+		&journalTemplate.CreatedAt,
+		&journalTemplate.UpdatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if slideGroupsRaw != nil {
+		journalTemplate.SlideGroups = json.RawMessage(slideGroupsRaw)
+	}
+	if slideGroupsViRaw != nil {
+		journalTemplate.SlideGroupsVi = json.RawMessage(slideGroupsViRaw)
+	}
+
+	err = json.Unmarshal(audienceRaw, &journalTemplate.Audience)
+	if err != nil {
+		return nil, err
+	}
+
+	return &journalTemplate, nil
+}
+
+// ParseSlideGroups decodes the template's slide_groups JSONB into typed slide groups.
+func (t *JournalTemplate) ParseSlideGroups() ([]SlideGroup, error) {
+	var groups []SlideGroup
+	if len(t.SlideGroups) == 0 {
+		return groups, nil
+	}
+
+	err := json.Unmarshal(t.SlideGroups, &groups)
+	if err != nil {
+		return nil, fmt.Errorf("parse slide groups of template %s: %w", t.ID, err)
+	}
+
+	return groups, nil
+}
+
+// ApplyLocale swaps in the translated title, description and slide groups when
+// the requested locale is Vietnamese and a translation is available.
+func (t *JournalTemplate) ApplyLocale(locale string) {
+	if locale != "vi" {
+		return
+	}
+
+	if t.TitleVi != nil && *t.TitleVi != "" {
+		t.Title = *t.TitleVi
+	}
+	if t.DescriptionVi != nil && *t.DescriptionVi != "" {
+		t.Description = t.DescriptionVi
+	}
+	if t.SlideGroupsVi != nil && len(t.SlideGroupsVi) > 2 { // not empty "[]"
+		t.SlideGroups = t.SlideGroupsVi
+	}
+}
+
 func (journal UserJournalModel) GetList(userId uuid.UUID) ([]*UserJournal, error) {
 	query := `
 		SELECT COUNT(*) OVER(), id, user_id, collection_id, title, content, content_html,
@@ -488,3 +608,73 @@ func (journal UserJournalModel) GetAllSince(userID uuid.UUID, since time.Time) (
 
 	return journals, rows.Err()
 }
+
+// MoodPoint is a single mood_score observation taken from a journal.
+type MoodPoint struct {
+	Score int       `json:"score"`
+	Label string    `json:"label,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// GetMoodPoints returns the journals with a mood score created since the given time, oldest first.
+func (journal UserJournalModel) GetMoodPoints(userID uuid.UUID, since time.Time) ([]MoodPoint, error) {
+	query := `
+		SELECT mood_score, COALESCE(mood_label, ''), created_at
+		FROM user_journals
+		WHERE user_id = $1 AND mood_score IS NOT NULL AND created_at >= $2
+		ORDER BY created_at ASC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := journal.DB.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []MoodPoint{}
+	for rows.Next() {
+		var p MoodPoint
+		err = rows.Scan(&p.Score, &p.Label, &p.At)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// CountByCollection returns how many journals the user has written per collection.
+func (journal UserJournalModel) CountByCollection(userID uuid.UUID) (map[uuid.UUID]int, error) {
+	query := `
+		SELECT collection_id, COUNT(*)
+		FROM user_journals
+		WHERE user_id = $1 AND collection_id IS NOT NULL
+		GROUP BY collection_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := journal.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var collectionID uuid.UUID
+		var count int
+		err = rows.Scan(&collectionID, &count)
+		if err != nil {
+			return nil, err
+		}
+		counts[collectionID] = count
+	}
+
+	return counts, rows.Err()
+}
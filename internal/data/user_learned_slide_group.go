@@ -4,31 +4,35 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type UserLearnedSlideGroup struct {
-	ID           uuid.UUID `json:"id"`
-	UserID       uuid.UUID `json:"user_id"`
-	CollectionID uuid.UUID `json:"collection_id"`
-	SlideGroupID string    `json:"slide_group_id"`
-	CompletedAt  time.Time `json:"completed_at"`
+	ID               uuid.UUID `json:"id"`
+	UserID           uuid.UUID `json:"user_id"`
+	CollectionID     uuid.UUID `json:"collection_id"`
+	SlideGroupID     string    `json:"slide_group_id"`
+	TimeSpentSeconds int       `json:"time_spent_seconds"`
+	CompletedAt      time.Time `json:"completed_at"`
 }
 
 type UserLearnedSlideGroupModel struct {
 	DB *sql.DB
 }
 
-// Insert marks a slide group as completed for a user
-// Uses ON CONFLICT to handle duplicate completions gracefully
-func (m UserLearnedSlideGroupModel) Insert(learned *UserLearnedSlideGroup) (*UserLearnedSlideGroup, error) {
+// Insert marks a slide group as completed for a user and reports whether this call
+// recorded the completion. Uses ON CONFLICT to handle duplicate completions gracefully:
+// a repeated or concurrent completion returns the existing record and false.
+func (m UserLearnedSlideGroupModel) Insert(learned *UserLearnedSlideGroup) (*UserLearnedSlideGroup, bool, error) {
 	query := `
-		INSERT INTO user_learned_slide_groups (user_id, collection_id, slide_group_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO user_learned_slide_groups (user_id, collection_id, slide_group_id, time_spent_seconds)
+		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (user_id, collection_id, slide_group_id) DO NOTHING
-		RETURNING id, user_id, collection_id, slide_group_id, completed_at
+		RETURNING id, user_id, collection_id, slide_group_id, time_spent_seconds, completed_at
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -38,11 +42,13 @@ func (m UserLearnedSlideGroupModel) Insert(learned *UserLearnedSlideGroup) (*Use
 		learned.UserID,
 		learned.CollectionID,
 		learned.SlideGroupID,
+		learned.TimeSpentSeconds,
 	).Scan(
 		&learned.ID,
 		&learned.UserID,
 		&learned.CollectionID,
 		&learned.SlideGroupID,
+		&learned.TimeSpentSeconds,
 		&learned.CompletedAt,
 	)
 
@@ -50,18 +56,19 @@ func (m UserLearnedSlideGroupModel) Insert(learned *UserLearnedSlideGroup) (*Use
 		// ON CONFLICT DO NOTHING returns no rows — treat as already exists
 		if errors.Is(err, sql.ErrNoRows) {
 			// Fetch the existing record
-			return m.GetOne(learned.UserID, learned.CollectionID, learned.SlideGroupID)
+			existing, err := m.GetOne(learned.UserID, learned.CollectionID, learned.SlideGroupID)
+			return existing, false, err
 		}
-		return nil, err
+		return nil, false, err
 	}
 
-	return learned, nil
+	return learned, true, nil
 }
 
 // GetOne retrieves a specific completion record
 func (m UserLearnedSlideGroupModel) GetOne(userID, collectionID uuid.UUID, slideGroupID string) (*UserLearnedSlideGroup, error) {
 	query := `
-		SELECT id, user_id, collection_id, slide_group_id, completed_at
+		SELECT id, user_id, collection_id, slide_group_id, time_spent_seconds, completed_at
 		FROM user_learned_slide_groups
 		WHERE user_id = $1 AND collection_id = $2 AND slide_group_id = $3
 	`
@@ -75,6 +82,7 @@ func (m UserLearnedSlideGroupModel) GetOne(userID, collectionID uuid.UUID, slide
 		&learned.UserID,
 		&learned.CollectionID,
 		&learned.SlideGroupID,
+		&learned.TimeSpentSeconds,
 		&learned.CompletedAt,
 	)
 
@@ -91,7 +99,7 @@ func (m UserLearnedSlideGroupModel) GetOne(userID, collectionID uuid.UUID, slide
 // GetByCollection retrieves all completed slide groups for a user in a specific collection
 func (m UserLearnedSlideGroupModel) GetByCollection(userID, collectionID uuid.UUID) ([]*UserLearnedSlideGroup, error) {
 	query := `
-		SELECT id, user_id, collection_id, slide_group_id, completed_at
+		SELECT id, user_id, collection_id, slide_group_id, time_spent_seconds, completed_at
 		FROM user_learned_slide_groups
 		WHERE user_id = $1 AND collection_id = $2
 		ORDER BY completed_at ASC
@@ -114,6 +122,7 @@ func (m UserLearnedSlideGroupModel) GetByCollection(userID, collectionID uuid.UU
 			&learned.UserID,
 			&learned.CollectionID,
 			&learned.SlideGroupID,
+			&learned.TimeSpentSeconds,
 			&learned.CompletedAt,
 		)
 		if err != nil {
@@ -132,7 +141,7 @@ func (m UserLearnedSlideGroupModel) GetByCollection(userID, collectionID uuid.UU
 // GetAllByUser retrieves all completed slide groups for a user across all collections
 func (m UserLearnedSlideGroupModel) GetAllByUser(userID uuid.UUID) ([]*UserLearnedSlideGroup, error) {
 	query := `
-		SELECT id, user_id, collection_id, slide_group_id, completed_at
+		SELECT id, user_id, collection_id, slide_group_id, time_spent_seconds, completed_at
 		FROM user_learned_slide_groups
 		WHERE user_id = $1
 		ORDER BY completed_at DESC
@@ -155,6 +164,7 @@ func (m UserLearnedSlideGroupModel) GetAllByUser(userID uuid.UUID) ([]*UserLearn
 			&learned.UserID,
 			&learned.CollectionID,
 			&learned.SlideGroupID,
+			&learned.TimeSpentSeconds,
 			&learned.CompletedAt,
 		)
 		if err != nil {
@@ -196,3 +206,114 @@ func (m UserLearnedSlideGroupModel) Delete(id, userID uuid.UUID) error {
 
 	return nil
 }
+
+// MarkCollectionCompleted records that the user finished the collection and reports
+// whether this is the first time, so callers emit "learn.collection_completed" only once.
+func (m UserLearnedSlideGroupModel) MarkCollectionCompleted(userID, collectionID uuid.UUID, completedAt time.Time) (bool, error) {
+	query := `
+		INSERT INTO learn_collection_completions (user_id, collection_id, completed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, collection_id) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, collectionID, completedAt)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// SlideGroupRef is a lightweight pointer to a slide group inside a collection.
+type SlideGroupRef struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+}
+
+// CollectionProgress summarises how far a user has got through a learn collection.
+type CollectionProgress struct {
+	CollectionID         uuid.UUID      `json:"collection_id"`
+	Title                string         `json:"title"`
+	TotalSlideGroups     int            `json:"total_slide_groups"`
+	CompletedSlideGroups int            `json:"completed_slide_groups"`
+	PercentComplete      float64        `json:"percent_complete"`
+	IsCompleted          bool           `json:"is_completed"`
+	NextSlideGroup       *SlideGroupRef `json:"next_slide_group,omitempty"`
+	TimeSpentSeconds     int            `json:"time_spent_seconds"`
+	StartedAt            *time.Time     `json:"started_at,omitempty"`
+	CompletedAt          *time.Time     `json:"completed_at,omitempty"`
+}
+
+// CalculateCollectionProgress cross-references a collection's slide groups with the
+// user's completion records. Records for slide groups that no longer exist in the
+// collection are ignored so that edited collections never report more than 100%.
+func CalculateCollectionProgress(template *JournalTemplate, learned []*UserLearnedSlideGroup) (CollectionProgress, error) {
+	progress := CollectionProgress{
+		CollectionID: template.ID,
+		Title:        template.Title,
+	}
+
+	groups, err := template.ParseSlideGroups()
+	if err != nil {
+		return progress, err
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Position < groups[j].Position
+	})
+
+	completed := make(map[string]*UserLearnedSlideGroup, len(learned))
+	for _, l := range learned {
+		if l.CollectionID == template.ID {
+			completed[l.SlideGroupID] = l
+		}
+	}
+
+	progress.TotalSlideGroups = len(groups)
+
+	var lastCompletedAt time.Time
+	for _, group := range groups {
+		l, ok := completed[group.ID]
+		if !ok {
+			if progress.NextSlideGroup == nil {
+				progress.NextSlideGroup = &SlideGroupRef{
+					ID:       group.ID,
+					Title:    group.Title,
+					Position: group.Position,
+				}
+			}
+			continue
+		}
+
+		progress.CompletedSlideGroups++
+		progress.TimeSpentSeconds += l.TimeSpentSeconds
+
+		completedAt := l.CompletedAt
+		if progress.StartedAt == nil || completedAt.Before(*progress.StartedAt) {
+			progress.StartedAt = &completedAt
+		}
+		if completedAt.After(lastCompletedAt) {
+			lastCompletedAt = completedAt
+		}
+	}
+
+	if progress.TotalSlideGroups > 0 {
+		percent := float64(progress.CompletedSlideGroups) / float64(progress.TotalSlideGroups) * 100
+		progress.PercentComplete = math.Round(percent*10) / 10
+		progress.IsCompleted = progress.CompletedSlideGroups == progress.TotalSlideGroups
+	}
+
+	if progress.IsCompleted {
+		progress.CompletedAt = &lastCompletedAt
+	}
+
+	return progress, nil
+}
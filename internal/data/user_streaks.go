@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +16,11 @@ type UserStreak struct {
 	LastActive    time.Time `json:"last_active"`
 	TotalEntries  int       `json:"total_entries"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	// FreezesAvailable are consumed automatically, one per missed day, to keep the streak.
+	FreezesAvailable int `json:"freezes_available"`
+	FreezesUsed      int `json:"freezes_used"`
+	// ActiveToday reports whether the user was active on the current day in their timezone.
+	ActiveToday bool `json:"active_today"`
 }
 
 type UserStreakModel struct {
@@ -22,7 +28,8 @@ type UserStreakModel struct {
 }
 
 func (streak UserStreakModel) Get(userUuid uuid.UUID) (UserStreak, error) {
-	query := `SELECT user_id, current_streak, longest_streak, last_active, total_entries, updated_at
+	query := `SELECT user_id, current_streak, longest_streak, last_active, total_entries, updated_at,
+				freezes_available, freezes_used
 			  FROM user_streaks
 			  WHERE user_id = $1`
 
@@ -38,14 +45,29 @@ func (streak UserStreakModel) Get(userUuid uuid.UUID) (UserStreak, error) {
 		&userStreak.LastActive,
 		&userStreak.TotalEntries,
 		&userStreak.UpdatedAt,
+		&userStreak.FreezesAvailable,
+		&userStreak.FreezesUsed,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return userStreak, ErrRecordNotFound
+	}
 
 	return userStreak, err
 }
 
+// ApplyLocation evaluates the streak as of now in the user's timezone: a streak
+// that has missed more days than there are freezes left is reported as broken (0).
+func (s *UserStreak) ApplyLocation(loc *time.Location, now time.Time) {
+	gap := dayCount(streakDay(s.LastActive, loc), streakDay(now, loc))
+	s.ActiveToday = gap == 0 && s.CurrentStreak > 0
+	if gap-1 > s.FreezesAvailable {
+		s.CurrentStreak = 0
+	}
+}
+
 func (streak UserStreakModel) Insert(userUUID uuid.UUID) error {
 	query := `INSERT INTO user_streaks (user_id, last_active)
-			  VALUES ($1, CURRENT_TIMESTAMP)
+			  VALUES ($1, CURRENT_TIMESTAMP AT TIME ZONE 'UTC')
 			  ON CONFLICT (user_id) DO NOTHING`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -56,32 +78,176 @@ func (streak UserStreakModel) Insert(userUUID uuid.UUID) error {
 	return err
 }
 
-func (streak UserStreakModel) UpdateOrReset(userUuid uuid.UUID) error {
-	query := `WITH updated_values AS (
-				SELECT 
-					user_id,
-					CASE 
-						WHEN CURRENT_DATE - last_active::date > 1 THEN 1
-						WHEN CURRENT_DATE - last_active::date = 1 THEN current_streak + 1
-						ELSE current_streak
-					END AS new_current_streak
-				FROM user_streaks
-				WHERE user_id = $1
-			)
-			UPDATE user_streaks u
-			SET 
-				current_streak = uv.new_current_streak,
-				longest_streak = GREATEST(uv.new_current_streak, u.longest_streak),
-				last_active = CURRENT_TIMESTAMP,
-				total_entries = u.total_entries + 1,
-				updated_at = CURRENT_TIMESTAMP
-			FROM updated_values uv
-			WHERE u.user_id = uv.user_id`
-
+// UpdateOrReset records activity now. Days are taken in loc (shifted by
+// StreakGracePeriod), missed days consume freezes, and the resulting streak
+// events are stored.
+func (streak UserStreakModel) UpdateOrReset(userUuid uuid.UUID, loc *time.Location) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := streak.DB.ExecContext(ctx, query, userUuid)
+	tx, err := streak.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	return err
+	query := `SELECT current_streak, longest_streak, freezes_available, freezes_used, last_active
+			  FROM user_streaks
+			  WHERE user_id = $1
+			  FOR UPDATE`
+
+	state := streakState{freezesAvailable: DefaultStreakFreezes}
+	var lastActive time.Time
+
+	err = tx.QueryRowContext(ctx, query, userUuid).Scan(
+		&state.current,
+		&state.longest,
+		&state.freezesAvailable,
+		&state.freezesUsed,
+		&lastActive,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if state.current > 0 {
+		state.lastDay = streakDay(lastActive, loc)
+	}
+
+	now := time.Now().UTC()
+	events := state.advance(streakDay(now, loc))
+
+	update := `
+		INSERT INTO user_streaks (user_id, current_streak, longest_streak, freezes_available, freezes_used, last_active, total_entries)
+		VALUES ($1, $2, $3, $4, $5, $6, 1)
+		ON CONFLICT (user_id) DO UPDATE
+		SET current_streak = EXCLUDED.current_streak,
+			longest_streak = EXCLUDED.longest_streak,
+			freezes_available = EXCLUDED.freezes_available,
+			freezes_used = EXCLUDED.freezes_used,
+			last_active = EXCLUDED.last_active,
+			total_entries = user_streaks.total_entries + 1,
+			updated_at = CURRENT_TIMESTAMP`
+
+	_, err = tx.ExecContext(ctx, update, userUuid, state.current, state.longest, state.freezesAvailable, state.freezesUsed, now)
+	if err != nil {
+		return err
+	}
+
+	err = insertStreakEvents(ctx, tx, userUuid, events)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Recompute rebuilds the streak and its event history from the activities counted
+// by policy, with days in loc. It runs when the user's timezone changes, after an
+// offline sync and from the rebuild-streaks command.
+func (streak UserStreakModel) Recompute(userUuid uuid.UUID, loc *time.Location, policy StreakPolicy) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := streak.DB.QueryContext(ctx, policy.historyQuery(), userUuid)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var activity []time.Time
+	for rows.Next() {
+		var at time.Time
+		if err := rows.Scan(&at); err != nil {
+			return err
+		}
+		activity = append(activity, at)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	state, events := replayStreak(activity, loc)
+
+	var lastActive *time.Time
+	if len(activity) > 0 {
+		lastActive = &activity[len(activity)-1]
+	}
+
+	tx, err := streak.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	update := `
+		INSERT INTO user_streaks (user_id, current_streak, longest_streak, freezes_available, freezes_used, total_entries, last_active)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, CURRENT_TIMESTAMP AT TIME ZONE 'UTC'))
+		ON CONFLICT (user_id) DO UPDATE
+		SET current_streak = EXCLUDED.current_streak,
+			longest_streak = EXCLUDED.longest_streak,
+			freezes_available = EXCLUDED.freezes_available,
+			freezes_used = EXCLUDED.freezes_used,
+			total_entries = EXCLUDED.total_entries,
+			last_active = EXCLUDED.last_active,
+			updated_at = CURRENT_TIMESTAMP`
+
+	_, err = tx.ExecContext(ctx, update, userUuid, state.current, state.longest, state.freezesAvailable, state.freezesUsed, len(activity), lastActive)
+	if err != nil {
+		return err
+	}
+
+	// Only events from the first one that differs are rewritten, so unchanged
+	// history keeps its created_at
+	stored, err := storedStreakEvents(ctx, tx, userUuid)
+	if err != nil {
+		return err
+	}
+
+	from := firstChangedEvent(stored, events)
+	for _, e := range stored[from:] {
+		_, err = tx.ExecContext(ctx, `DELETE FROM streak_events WHERE id = $1`, e.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = insertStreakEvents(ctx, tx, userUuid, events[from:])
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAllTimezones returns every user with a streak or profile and their timezone
+// setting ("" when unset).
+func (streak UserStreakModel) GetAllTimezones() (map[uuid.UUID]string, error) {
+	query := `
+		SELECT i.user_id, COALESCE(i.settings->>'timezone', '')
+		FROM user_informations i
+		UNION
+		SELECT s.user_id, ''
+		FROM user_streaks s
+		WHERE NOT EXISTS (SELECT 1 FROM user_informations i WHERE i.user_id = s.user_id)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rows, err := streak.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	timezones := map[uuid.UUID]string{}
+	for rows.Next() {
+		var userID uuid.UUID
+		var timezone string
+		if err := rows.Scan(&userID, &timezone); err != nil {
+			return nil, err
+		}
+		timezones[userID] = timezone
+	}
+
+	return timezones, rows.Err()
 }
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Decline detection tuning
+const (
+	// DeclineLookback is how much history the detector needs: two 7-day windows.
+	DeclineLookback = 14 * 24 * time.Hour
+	// MoodDeclineThreshold is the drop of the 7-day mood average that counts as a decline.
+	MoodDeclineThreshold = 2.0
+	// MinDeclineSamples is the minimum number of mood scores needed in each window.
+	MinDeclineSamples = 3
+	// StormLabel is the mood_label of the lowest weather mood.
+	StormLabel = "Storm"
+	// ConsecutiveStormThreshold is how many latest labelled journals in a row must be storms.
+	ConsecutiveStormThreshold = 3
+	// NegativeEmotionShareThreshold is the share of negative emotions in the last 7 days that counts as a decline.
+	NegativeEmotionShareThreshold = 0.7
+	// MinNegativeEmotionSamples is the minimum number of emotions logged in the last 7 days for that rule.
+	MinNegativeEmotionSamples = 4
+	// LowMoodAverage is the 7-day average at or below which booking a session is suggested.
+	LowMoodAverage = 3.0
+)
+
+// Decline rules
+const (
+	DeclineRuleMoodAverageDrop   = "mood_average_drop"
+	DeclineRuleConsecutiveStorms = "consecutive_storms"
+	DeclineRuleNegativeEmotions  = "negative_emotions"
+)
+
+// negativeCoreEmotions are the taxonomy cores counted by the negative emotions rule.
+var negativeCoreEmotions = []string{"sadness", "fear", "anger", "disgust"}
+
+// DeclineAssessment is the outcome of analysing a user's recent mood and emotions.
+type DeclineAssessment struct {
+	Declined             bool     `json:"declined"`
+	Rules                []string `json:"rules"`
+	RecentAverage        *float64 `json:"recent_average,omitempty"`
+	PreviousAverage      *float64 `json:"previous_average,omitempty"`
+	AverageDrop          *float64 `json:"average_drop,omitempty"`
+	ConsecutiveStorms    int      `json:"consecutive_storms"`
+	NegativeEmotionShare *float64 `json:"negative_emotion_share,omitempty"`
+	// SuggestSession is set when the decline is severe enough to suggest booking a therapy session.
+	SuggestSession bool `json:"suggest_session"`
+}
+
+// WellbeingDeclinePayload is the payload of the "wellbeing.decline_detected" event.
+type WellbeingDeclinePayload struct {
+	UserID     uuid.UUID         `json:"user_id"`
+	NudgeID    uuid.UUID         `json:"nudge_id"`
+	Assessment DeclineAssessment `json:"assessment"`
+}
+
+// AnalyseWellbeing checks the user's last two weeks for a sustained decline and, on a
+// decline, records a nudge at most once per NudgeCooldown. It returns the payload of
+// the "wellbeing.decline_detected" event to publish, or nil when no nudge was raised.
+// Both the API and the sync consumer run it after mood and emotion writes.
+func (m Models) AnalyseWellbeing(userID uuid.UUID, now time.Time) (*WellbeingDeclinePayload, error) {
+	since := now.Add(-DeclineLookback)
+
+	points, err := m.UserJournal.GetMoodPoints(userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := m.EmotionLog.GetSince(userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	assessment := DetectDecline(points, logs, now)
+	if !assessment.Declined {
+		return nil, nil
+	}
+
+	// Cheap early exit; InsertUnlessSince below makes the final, race-free decision
+	cooldownStart := now.Add(-NudgeCooldown)
+	recentlyNudged, err := m.Nudge.ExistsSince(userID, NudgeKindMoodDecline, cooldownStart)
+	if err != nil || recentlyNudged {
+		return nil, err
+	}
+
+	// Don't suggest booking when a session is already on the calendar
+	if assessment.SuggestSession {
+		upcoming, err := m.TherapySession.HasUpcoming(userID.String(), now)
+		if err != nil {
+			return nil, err
+		}
+		assessment.SuggestSession = !upcoming
+	}
+
+	action := NudgeActionOpenSelfCare
+	if assessment.SuggestSession {
+		action = NudgeActionBookTherapySession
+	}
+
+	details, err := json.Marshal(assessment)
+	if err != nil {
+		return nil, err
+	}
+
+	nudge, inserted, err := m.Nudge.InsertUnlessSince(&Nudge{
+		UserID:  userID,
+		Kind:    NudgeKindMoodDecline,
+		Action:  action,
+		Details: details,
+	}, cooldownStart)
+	if err != nil || !inserted {
+		return nil, err
+	}
+
+	return &WellbeingDeclinePayload{
+		UserID:     userID,
+		NudgeID:    nudge.ID,
+		Assessment: assessment,
+	}, nil
+}
+
+// DetectDecline flags a sustained decline when any of these hold:
+//   - the 7-day mood average dropped by MoodDeclineThreshold or more against the 7 days before
+//   - the latest ConsecutiveStormThreshold labelled journals are all "Storm"
+//   - at least NegativeEmotionShareThreshold of the emotions logged in the last 7 days are negative
+//
+// points must be ordered oldest first, as GetMoodPoints returns them.
+func DetectDecline(points []MoodPoint, logs []*EmotionLog, now time.Time) DeclineAssessment {
+	assessment := DeclineAssessment{Rules: []string{}}
+
+	currentStart := now.AddDate(0, 0, -7)
+	previousStart := now.AddDate(0, 0, -14)
+
+	var recent, previous []int
+	for _, p := range points {
+		switch {
+		case !p.At.Before(currentStart) && !p.At.After(now):
+			recent = append(recent, p.Score)
+		case !p.At.Before(previousStart) && p.At.Before(currentStart):
+			previous = append(previous, p.Score)
+		}
+	}
+
+	assessment.RecentAverage = averageOf(recent)
+	assessment.PreviousAverage = averageOf(previous)
+	if len(recent) >= MinDeclineSamples && len(previous) >= MinDeclineSamples {
+		drop := round2(*assessment.PreviousAverage - *assessment.RecentAverage)
+		assessment.AverageDrop = &drop
+		if drop >= MoodDeclineThreshold {
+			assessment.Rules = append(assessment.Rules, DeclineRuleMoodAverageDrop)
+		}
+	}
+
+	for i := len(points) - 1; i >= 0; i-- {
+		if points[i].Label == "" {
+			continue
+		}
+		if !strings.EqualFold(points[i].Label, StormLabel) {
+			break
+		}
+		assessment.ConsecutiveStorms++
+	}
+	if assessment.ConsecutiveStorms >= ConsecutiveStormThreshold {
+		assessment.Rules = append(assessment.Rules, DeclineRuleConsecutiveStorms)
+	}
+
+	total, negative := 0, 0
+	for _, l := range logs {
+		if l.CreatedAt.Before(currentStart) || l.CreatedAt.After(now) {
+			continue
+		}
+		for _, e := range emotionEntries(l) {
+			total++
+			if isNegativeEmotion(e.Code) {
+				negative++
+			}
+		}
+	}
+	if total >= MinNegativeEmotionSamples {
+		share := round2(float64(negative) / float64(total))
+		assessment.NegativeEmotionShare = &share
+		if share >= NegativeEmotionShareThreshold {
+			assessment.Rules = append(assessment.Rules, DeclineRuleNegativeEmotions)
+		}
+	}
+
+	assessment.Declined = len(assessment.Rules) > 0
+	assessment.SuggestSession = assessment.Declined &&
+		(len(assessment.Rules) > 1 || (assessment.RecentAverage != nil && *assessment.RecentAverage <= LowMoodAverage))
+
+	return assessment
+}
+
+func isNegativeEmotion(code string) bool {
+	core := CoreEmotion(code)
+	for _, negative := range negativeCoreEmotions {
+		if core == negative {
+			return true
+		}
+	}
+	return false
+}
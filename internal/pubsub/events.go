@@ -0,0 +1,24 @@
+package pubsub
+
+import (
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// EventMessage is the envelope for domain events published to the app_events queue.
+// Other services (notifications, analytics) subscribe to this queue.
+type EventMessage struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	Payload   any       `json:"payload"`
+}
+
+// PublishEvent publishes a domain event to the app_events queue.
+func PublishEvent(ch *amqp.Channel, event string, payload any) error {
+	return PublishJson(ch, "", "app_events", EventMessage{
+		Event:     event,
+		Timestamp: time.Now(),
+		Payload:   payload,
+	})
+}
@@ -44,13 +44,13 @@ func Consumer(ch *amqp.Channel, queue_name string, models *data.Models, callback
 	return err
 }
 
-func setupUnits(amqpChannel *amqp.Channel, models *data.Models) error {
+func setupUnits(amqpChannel *amqp.Channel, models *data.Models, streakPolicy data.StreakPolicy) error {
 	err := defineQueues(amqpChannel)
 
 	if err != nil {
 		return err
 	}
-	err = defineConsumers(amqpChannel, models)
+	err = defineConsumers(amqpChannel, models, streakPolicy)
 
 	return err
 }
@@ -62,11 +62,18 @@ func defineQueues(amqpChannel *amqp.Channel) error {
 	}
 
 	_, err = amqpChannel.QueueDeclare("sync_data", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = amqpChannel.QueueDeclare("app_events", false, false, false, false, nil)
 	return err
 }
 
-func defineConsumers(amqpChannel *amqp.Channel, models *data.Models) error {
-	err := Consumer(amqpChannel, "sync_data", models, syncDataMessageCallback)
+func defineConsumers(amqpChannel *amqp.Channel, models *data.Models, streakPolicy data.StreakPolicy) error {
+	err := Consumer(amqpChannel, "sync_data", models, func(message amqp.Delivery, models *data.Models) {
+		syncDataMessageCallback(message, amqpChannel, models, streakPolicy)
+	})
 
 	if err != nil {
 		return err
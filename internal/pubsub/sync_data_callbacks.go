@@ -2,12 +2,15 @@ package pubsub
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"time"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"tranquara.net/internal/data"
 	"tranquara.net/internal/jsonlog"
+	"tranquara.net/internal/validator"
 )
 
 type CustomTime struct {
@@ -27,7 +30,7 @@ func (ct *CustomTime) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-func syncDataMessageCallback(message amqp.Delivery, models *data.Models) {
+func syncDataMessageCallback(message amqp.Delivery, ch *amqp.Channel, models *data.Models, streakPolicy data.StreakPolicy) {
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
 	var input struct {
 		Event     string     `json:"event"`
@@ -65,6 +68,13 @@ func syncDataMessageCallback(message amqp.Delivery, models *data.Models) {
 		_, err = models.UserJournal.Insert(&journal)
 		if err != nil {
 			logger.PrintError(err, nil)
+		} else {
+			if streakPolicy.Counts(data.StreakActivityJournal) {
+				rebuildStreak(models, journal.UserID, streakPolicy, logger)
+			}
+			analyseWellbeing(ch, models, journal.UserID, logger)
+			evaluateAchievements(ch, models, journal.UserID, logger)
+			checkGoals(ch, models, journal.UserID, logger)
 		}
 	}
 
@@ -84,10 +94,27 @@ func syncDataMessageCallback(message amqp.Delivery, models *data.Models) {
 			return
 		}
 
-		// Step 3: Use the journal object
+		// Step 3: Map legacy labels onto the taxonomy; unknown labels stay legacy free text
+		emotionLog.NormalizeEmotions()
+
+		v := validator.New()
+		data.ValidateEmotionLog(v, &emotionLog)
+		if !v.Valid() {
+			logger.PrintError(errors.New("invalid emotion log"), v.Errors)
+			return
+		}
+
+		// Step 4: Use the journal object
 		_, err = models.EmotionLog.Insert(&emotionLog)
 		if err != nil {
 			logger.PrintError(err, nil)
+		} else {
+			if streakPolicy.Counts(data.StreakActivityEmotionLog) {
+				rebuildStreak(models, emotionLog.UserID, streakPolicy, logger)
+			}
+			analyseWellbeing(ch, models, emotionLog.UserID, logger)
+			evaluateAchievements(ch, models, emotionLog.UserID, logger)
+			checkGoals(ch, models, emotionLog.UserID, logger)
 		}
 	}
 
@@ -107,7 +134,36 @@ func syncDataMessageCallback(message amqp.Delivery, models *data.Models) {
 			return
 		}
 
-		// Step 3: Use the journal object
+		// Step 3: File the message under the user's thread, or the journal's thread,
+		// or else a new general thread titled after it
+		if chatLog.ThreadId != nil {
+			_, err = models.ChatThread.Get(*chatLog.ThreadId, chatLog.UserId)
+			if err != nil {
+				logger.PrintError(err, map[string]string{"action": "chatlog_thread", "thread_id": chatLog.ThreadId.String()})
+				chatLog.ThreadId = nil
+			}
+		}
+		if chatLog.ThreadId == nil && chatLog.JournalId != nil {
+			thread, err := models.ChatThread.GetForJournal(chatLog.UserId, *chatLog.JournalId)
+			if err != nil {
+				logger.PrintError(err, map[string]string{"action": "chatlog_thread"})
+			} else {
+				chatLog.ThreadId = &thread.ID
+			}
+		}
+		if chatLog.ThreadId == nil && chatLog.JournalId == nil {
+			thread, err := models.ChatThread.Insert(&data.ChatThread{
+				UserID: chatLog.UserId,
+				Title:  data.ChatThreadTitle(chatLog.Message),
+			})
+			if err != nil {
+				logger.PrintError(err, map[string]string{"action": "chatlog_thread"})
+			} else {
+				chatLog.ThreadId = &thread.ID
+			}
+		}
+
+		// Step 4: Use the journal object
 		_, err = models.GuiderChatlog.Insert(&chatLog)
 		if err != nil {
 			logger.PrintError(err, nil)
@@ -117,3 +173,76 @@ func syncDataMessageCallback(message amqp.Delivery, models *data.Models) {
 	message.Ack(false)
 
 }
+
+// rebuildStreak recomputes the streak from history after an offline sync, since
+// synced entries may belong to earlier days than the one they arrive on.
+func rebuildStreak(models *data.Models, userID uuid.UUID, streakPolicy data.StreakPolicy, logger *jsonlog.Logger) {
+	info, err := models.UserInformation.Get(userID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		logger.PrintError(err, map[string]string{"action": "rebuild_streak_on_sync"})
+		return
+	}
+
+	err = models.UserStreak.Recompute(userID, info.Location(), streakPolicy)
+	if err != nil {
+		logger.PrintError(err, map[string]string{"action": "rebuild_streak_on_sync"})
+	}
+}
+
+// analyseWellbeing re-runs decline detection after a synced mood or emotion write, as
+// the API does after its own writes, and publishes "wellbeing.decline_detected" when
+// a nudge is raised.
+func analyseWellbeing(ch *amqp.Channel, models *data.Models, userID uuid.UUID, logger *jsonlog.Logger) {
+	decline, err := models.AnalyseWellbeing(userID, time.Now())
+	if err != nil {
+		logger.PrintError(err, map[string]string{"action": "analyse_wellbeing_on_sync"})
+		return
+	}
+
+	if decline != nil {
+		publishEvent(ch, "wellbeing.decline_detected", decline, logger)
+	}
+}
+
+// evaluateAchievements awards any achievement a synced journal or emotion log has
+// unlocked and publishes "achievement.awarded" for each, as the API does after its
+// own writes.
+func evaluateAchievements(ch *amqp.Channel, models *data.Models, userID uuid.UUID, logger *jsonlog.Logger) {
+	awarded, err := models.Achievement.Evaluate(userID)
+	if err != nil {
+		logger.PrintError(err, map[string]string{"action": "evaluate_achievements_on_sync"})
+	}
+
+	for _, a := range awarded {
+		publishEvent(ch, "achievement.awarded", a.AwardedPayload(userID), logger)
+	}
+}
+
+// checkGoals re-computes goal progress after a synced journal or emotion log and
+// publishes "goal.met" for goals reached for the first time in their period.
+func checkGoals(ch *amqp.Channel, models *data.Models, userID uuid.UUID, logger *jsonlog.Logger) {
+	info, err := models.UserInformation.Get(userID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		logger.PrintError(err, map[string]string{"action": "check_goals_on_sync"})
+		return
+	}
+
+	_, met, err := models.Goal.CheckProgress(userID, time.Now(), info.Location())
+	if err != nil {
+		logger.PrintError(err, map[string]string{"action": "check_goals_on_sync"})
+		return
+	}
+
+	for _, payload := range met {
+		publishEvent(ch, "goal.met", payload, logger)
+	}
+}
+
+// publishEvent publishes a domain event raised while handling a sync message.
+// Failures are logged only; the synced data is already stored.
+func publishEvent(ch *amqp.Channel, event string, payload any, logger *jsonlog.Logger) {
+	err := PublishEvent(ch, event, payload)
+	if err != nil {
+		logger.PrintError(err, map[string]string{"action": "publish_event", "event": event})
+	}
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage keeps media files by key. Keys are slash-separated relative paths such
+// as "<asset id>/<variant id>".
+type Storage interface {
+	// Put stores everything read from r under key, replacing any existing object,
+	// and returns the number of bytes written. A failed Put leaves nothing behind.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns the object for reading and seeking, so it can serve range requests.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the object; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,3 @@
+-- Rollback migration 000028: Drop time_spent_seconds from user_learned_slide_groups
+
+ALTER TABLE user_learned_slide_groups DROP COLUMN IF EXISTS time_spent_seconds;
@@ -0,0 +1,7 @@
+-- Migration 000028: Track time spent per completed slide group
+-- Lets the server report how long a user has spent on each learn collection
+
+ALTER TABLE user_learned_slide_groups
+    ADD COLUMN IF NOT EXISTS time_spent_seconds INTEGER NOT NULL DEFAULT 0 CHECK (time_spent_seconds >= 0);
+
+COMMENT ON COLUMN user_learned_slide_groups.time_spent_seconds IS 'Seconds the user spent on the slide group before completing it (client reported)';
@@ -0,0 +1,5 @@
+-- Rollback migration 000029: Drop learn_reviews table
+
+DROP TRIGGER IF EXISTS update_learn_reviews_updated_at ON learn_reviews;
+DROP INDEX IF EXISTS idx_learn_reviews_user_due;
+DROP TABLE IF EXISTS learn_reviews;
@@ -0,0 +1,36 @@
+-- Migration 000029: Create learn_reviews table
+-- Spaced-repetition (SM-2) review schedule for completed learn slide groups
+
+CREATE TABLE IF NOT EXISTS learn_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    collection_id UUID NOT NULL REFERENCES journal_templates(id) ON DELETE CASCADE,
+    slide_group_id VARCHAR(100) NOT NULL,
+    easiness_factor REAL NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    review_count INTEGER NOT NULL DEFAULT 0,
+    last_quality SMALLINT CHECK (last_quality BETWEEN 0 AND 5),
+    last_reviewed_at TIMESTAMP,
+    due_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, collection_id, slide_group_id)
+);
+
+CREATE INDEX idx_learn_reviews_user_due ON learn_reviews(user_id, due_at);
+
+CREATE TRIGGER update_learn_reviews_updated_at BEFORE UPDATE
+    ON learn_reviews FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Backfill: schedule a first review one day after every existing learn completion
+INSERT INTO learn_reviews (user_id, collection_id, slide_group_id, due_at)
+SELECT l.user_id, l.collection_id, l.slide_group_id, l.completed_at + INTERVAL '1 day'
+FROM user_learned_slide_groups l
+JOIN journal_templates t ON t.id = l.collection_id
+WHERE t.type = 'learn'
+ON CONFLICT (user_id, collection_id, slide_group_id) DO NOTHING;
+
+COMMENT ON TABLE learn_reviews IS 'SM-2 spaced-repetition schedule per completed learn slide group';
+COMMENT ON COLUMN learn_reviews.last_quality IS 'Recall quality of the last review on the SM-2 0-5 scale';
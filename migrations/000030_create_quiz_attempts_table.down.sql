@@ -0,0 +1,4 @@
+-- Rollback migration 000030: Drop quiz_attempts table
+
+DROP INDEX IF EXISTS idx_quiz_attempts_user_group;
+DROP TABLE IF EXISTS quiz_attempts;
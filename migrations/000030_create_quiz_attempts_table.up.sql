@@ -0,0 +1,20 @@
+-- Migration 000030: Create quiz_attempts table
+-- Stores scored submissions for quiz slides inside learn collections
+
+CREATE TABLE IF NOT EXISTS quiz_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    collection_id UUID NOT NULL REFERENCES journal_templates(id) ON DELETE CASCADE,
+    slide_group_id VARCHAR(100) NOT NULL,
+    answers JSONB NOT NULL DEFAULT '{}',
+    score INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    percent REAL NOT NULL,
+    passed BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_quiz_attempts_user_group ON quiz_attempts(user_id, collection_id, slide_group_id);
+
+COMMENT ON TABLE quiz_attempts IS 'Scored quiz submissions; a passing attempt is required before a slide group with quiz slides can be marked as learned';
+COMMENT ON COLUMN quiz_attempts.answers IS 'Map of quiz slide id to the option ids the user selected';
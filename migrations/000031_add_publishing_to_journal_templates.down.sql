@@ -0,0 +1,10 @@
+-- Rollback migration 000031: Remove scheduled publishing and audience targeting
+
+DROP INDEX IF EXISTS idx_journal_templates_publish_window;
+
+ALTER TABLE journal_templates DROP CONSTRAINT IF EXISTS chk_journal_templates_publish_window;
+
+ALTER TABLE journal_templates
+    DROP COLUMN IF EXISTS audience,
+    DROP COLUMN IF EXISTS unpublish_at,
+    DROP COLUMN IF EXISTS publish_at;
@@ -0,0 +1,18 @@
+-- Migration 000031: Add scheduled publishing and audience targeting to journal_templates
+-- publish_at / unpublish_at bound when a template is visible (e.g. seasonal prompts)
+-- audience restricts visibility by user_informations fields and request locale
+
+ALTER TABLE journal_templates
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS audience JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE journal_templates
+    ADD CONSTRAINT chk_journal_templates_publish_window
+    CHECK (publish_at IS NULL OR unpublish_at IS NULL OR unpublish_at > publish_at);
+
+CREATE INDEX idx_journal_templates_publish_window ON journal_templates(publish_at, unpublish_at);
+
+COMMENT ON COLUMN journal_templates.publish_at IS 'Template is hidden before this time (NULL = no start bound)';
+COMMENT ON COLUMN journal_templates.unpublish_at IS 'Template is hidden from this time on (NULL = no end bound)';
+COMMENT ON COLUMN journal_templates.audience IS 'Audience rules: {"age_ranges": [...], "locales": [...], "kyc": {"<question>": [...accepted answers]}}; empty = everyone';
@@ -0,0 +1,10 @@
+-- Rollback migration 000032: Remove structured emotions from emotion_logs
+
+DROP INDEX IF EXISTS idx_emotion_logs_emotions;
+DROP INDEX IF EXISTS idx_emotion_logs_user_created;
+
+ALTER TABLE emotion_logs DROP CONSTRAINT IF EXISTS chk_emotion_logs_emotions_array;
+
+ALTER TABLE emotion_logs
+    DROP COLUMN IF EXISTS taxonomy_version,
+    DROP COLUMN IF EXISTS emotions;
@@ -0,0 +1,17 @@
+-- Migration 000032: Structured emotions with intensity for emotion_logs
+-- emotions holds [{"code": "<taxonomy code>", "intensity": 1-10}, ...]
+-- emotion keeps the primary (first) code so existing readers keep working
+-- Legacy rows keep emotions = '[]' and taxonomy_version = NULL
+
+ALTER TABLE emotion_logs
+    ADD COLUMN IF NOT EXISTS emotions JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS taxonomy_version INT;
+
+ALTER TABLE emotion_logs
+    ADD CONSTRAINT chk_emotion_logs_emotions_array CHECK (jsonb_typeof(emotions) = 'array');
+
+CREATE INDEX IF NOT EXISTS idx_emotion_logs_user_created ON emotion_logs(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_emotion_logs_emotions ON emotion_logs USING GIN (emotions);
+
+COMMENT ON COLUMN emotion_logs.emotions IS 'Emotions felt: [{"code": "fear.anxious", "intensity": 1-10}]';
+COMMENT ON COLUMN emotion_logs.taxonomy_version IS 'Version of the emotion taxonomy the codes belong to (NULL = legacy free text)';
@@ -0,0 +1,3 @@
+-- Rollback migration 000033: Drop nudges table
+
+DROP TABLE IF EXISTS nudges;
@@ -0,0 +1,20 @@
+-- Migration 000033: In-app nudges raised by the server (e.g. mood decline check-ins)
+-- Title and message are rendered from kind at read time so they follow the request locale
+
+CREATE TABLE IF NOT EXISTS nudges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    action VARCHAR(50),
+    details JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_nudges_user_created ON nudges(user_id, created_at DESC);
+CREATE INDEX idx_nudges_user_kind_created ON nudges(user_id, kind, created_at DESC);
+CREATE INDEX idx_nudges_unread ON nudges(user_id) WHERE read_at IS NULL;
+
+COMMENT ON COLUMN nudges.kind IS 'Nudge kind: mood_decline';
+COMMENT ON COLUMN nudges.action IS 'Suggested action: open_self_care, book_therapy_session';
+COMMENT ON COLUMN nudges.details IS 'Why the nudge was raised, e.g. the decline assessment';
@@ -0,0 +1,4 @@
+-- Rollback migration 000034: Drop sleep_entries table
+
+DROP TRIGGER IF EXISTS update_sleep_entries_updated_at ON sleep_entries;
+DROP TABLE IF EXISTS sleep_entries;
@@ -0,0 +1,35 @@
+-- Migration 000034: Sleep diary entries (CBT-I style)
+-- One entry per night; sleep_date is the date of the evening the night started
+-- Total sleep time and sleep efficiency are computed by the API from these fields
+
+CREATE TABLE IF NOT EXISTS sleep_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    sleep_date DATE NOT NULL,
+    bedtime TIMESTAMP NOT NULL,
+    sleep_onset_latency_minutes INT NOT NULL DEFAULT 0,
+    wake_count INT NOT NULL DEFAULT 0,
+    wake_after_sleep_onset_minutes INT NOT NULL DEFAULT 0,
+    final_wake_at TIMESTAMP NOT NULL,
+    rise_at TIMESTAMP NOT NULL,
+    quality INT CHECK (quality >= 1 AND quality <= 5),
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_sleep_entries_user_date UNIQUE (user_id, sleep_date),
+    CONSTRAINT chk_sleep_entries_order CHECK (bedtime < final_wake_at AND final_wake_at <= rise_at),
+    CONSTRAINT chk_sleep_entries_minutes CHECK (
+        sleep_onset_latency_minutes >= 0 AND wake_count >= 0 AND wake_after_sleep_onset_minutes >= 0
+    )
+);
+
+CREATE INDEX idx_sleep_entries_user_date ON sleep_entries(user_id, sleep_date DESC);
+
+CREATE TRIGGER update_sleep_entries_updated_at BEFORE UPDATE
+    ON sleep_entries FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN sleep_entries.sleep_date IS 'Night of the entry: the date the user went to bed (before noon counts as the previous night)';
+COMMENT ON COLUMN sleep_entries.sleep_onset_latency_minutes IS 'Minutes from bedtime until falling asleep (SOL)';
+COMMENT ON COLUMN sleep_entries.wake_after_sleep_onset_minutes IS 'Total minutes awake during the night (WASO)';
+COMMENT ON COLUMN sleep_entries.quality IS 'Self-rated sleep quality 1-5';
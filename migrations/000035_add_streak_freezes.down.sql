@@ -0,0 +1,7 @@
+-- Rollback migration 000035: Drop streak freezes and streak events
+
+DROP TABLE IF EXISTS streak_events;
+
+ALTER TABLE user_streaks
+  DROP COLUMN IF EXISTS freezes_available,
+  DROP COLUMN IF EXISTS freezes_used;
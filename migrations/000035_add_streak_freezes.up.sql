@@ -0,0 +1,21 @@
+-- Migration 000035: Streak freezes and streak event history
+-- A freeze is consumed automatically for each missed day so the streak survives;
+-- freezes are allotted on creation and earned at streak milestones
+
+ALTER TABLE user_streaks
+  ADD COLUMN IF NOT EXISTS freezes_available INT NOT NULL DEFAULT 1,
+  ADD COLUMN IF NOT EXISTS freezes_used INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS streak_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    event_type TEXT NOT NULL CHECK (event_type IN ('started', 'extended', 'freeze_used', 'freeze_earned', 'reset')),
+    event_date DATE NOT NULL,
+    streak_value INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_streak_events_user_date ON streak_events(user_id, event_date DESC);
+
+COMMENT ON COLUMN streak_events.event_date IS 'Streak day the event applies to, in the user''s timezone (a freeze_used event is dated on the missed day)';
+COMMENT ON COLUMN streak_events.streak_value IS 'current_streak after the event; for reset, the streak that was lost';
@@ -0,0 +1,4 @@
+-- Rollback migration 000036: Drop achievements tables
+
+DROP TABLE IF EXISTS user_achievements;
+DROP TABLE IF EXISTS achievements;
@@ -0,0 +1,40 @@
+-- Migration 000036: Achievements and badges
+-- Badges are defined as data: a badge is awarded once the user's value for its
+-- metric reaches the threshold. New badges only need a row here, as long as they
+-- use a metric the API knows how to compute.
+
+CREATE TABLE IF NOT EXISTS achievements (
+    code VARCHAR(100) PRIMARY KEY,
+    metric VARCHAR(100) NOT NULL,
+    threshold INT NOT NULL CHECK (threshold > 0),
+    title TEXT NOT NULL,
+    title_vi TEXT,
+    description TEXT NOT NULL,
+    description_vi TEXT,
+    icon VARCHAR(100),
+    position INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_achievements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    achievement_code VARCHAR(100) NOT NULL REFERENCES achievements(code) ON DELETE CASCADE,
+    awarded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_user_achievements_user_code UNIQUE (user_id, achievement_code)
+);
+
+CREATE INDEX idx_user_achievements_user ON user_achievements(user_id, awarded_at DESC);
+
+COMMENT ON COLUMN achievements.metric IS 'journal_count, emotion_log_count, longest_streak, learn_collections_completed, homework_completed, prep_pack_count, exercise_count';
+
+INSERT INTO achievements (code, metric, threshold, title, title_vi, description, description_vi, icon, position) VALUES
+    ('first_journal', 'journal_count', 1, 'First words', 'Những dòng đầu tiên', 'Write your first journal entry.', 'Viết bài nhật ký đầu tiên.', 'pen', 10),
+    ('first_emotion_log', 'emotion_log_count', 1, 'Checking in', 'Lắng nghe cảm xúc', 'Log your first emotion.', 'Ghi lại cảm xúc đầu tiên.', 'heart', 20),
+    ('streak_7', 'longest_streak', 7, 'One week strong', 'Một tuần bền bỉ', 'Keep a 7-day streak.', 'Duy trì chuỗi 7 ngày.', 'flame', 30),
+    ('streak_30', 'longest_streak', 30, 'Monthly habit', 'Thói quen hằng tháng', 'Keep a 30-day streak.', 'Duy trì chuỗi 30 ngày.', 'flame', 40),
+    ('streak_100', 'longest_streak', 100, 'Centurion', 'Trăm ngày kiên trì', 'Keep a 100-day streak.', 'Duy trì chuỗi 100 ngày.', 'flame', 50),
+    ('first_learn_collection', 'learn_collections_completed', 1, 'Lifelong learner', 'Không ngừng học hỏi', 'Complete your first learn collection.', 'Hoàn thành bộ bài học đầu tiên.', 'book', 60),
+    ('homework_10', 'homework_completed', 10, 'Doing the work', 'Chăm chỉ thực hành', 'Complete 10 therapy homework items.', 'Hoàn thành 10 bài tập trị liệu.', 'check', 70),
+    ('first_prep_pack', 'prep_pack_count', 1, 'Ready for therapy', 'Sẵn sàng trị liệu', 'Create your first session prep pack.', 'Tạo gói chuẩn bị buổi trị liệu đầu tiên.', 'clipboard', 80);
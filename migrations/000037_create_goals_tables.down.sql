@@ -0,0 +1,5 @@
+-- Rollback migration 000037: Drop goals tables
+
+DROP TABLE IF EXISTS goal_completions;
+DROP TRIGGER IF EXISTS update_goals_updated_at ON goals;
+DROP TABLE IF EXISTS goals;
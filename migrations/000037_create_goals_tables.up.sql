@@ -0,0 +1,33 @@
+-- Migration 000037: Weekly / monthly wellbeing goals
+-- Progress is computed by the API from the activity tables; goal_completions
+-- records each period a goal was met so "goal.met" is emitted once per period
+
+CREATE TABLE IF NOT EXISTS goals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    title TEXT,
+    metric VARCHAR(50) NOT NULL,
+    qualifier VARCHAR(100),
+    target INT NOT NULL CHECK (target > 0),
+    period VARCHAR(10) NOT NULL CHECK (period IN ('week', 'month')),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_goals_user ON goals(user_id, created_at DESC);
+
+CREATE TRIGGER update_goals_updated_at BEFORE UPDATE
+    ON goals FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS goal_completions (
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    met_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (goal_id, period_start)
+);
+
+COMMENT ON COLUMN goals.metric IS 'journals, emotion_logs, exercises, learn_slide_groups, learn_collections, sleep_entries, homework';
+COMMENT ON COLUMN goals.qualifier IS 'Narrows the metric, e.g. the category or exercise_type for exercises ("breathing")';
+COMMENT ON COLUMN goal_completions.period_start IS 'First day of the met period in the user''s timezone';
@@ -0,0 +1,21 @@
+-- Rollback migration 000038: Drop exercise catalog columns
+
+DROP TRIGGER IF EXISTS update_exercises_updated_at ON exercises;
+DROP INDEX IF EXISTS idx_exercises_category;
+DROP INDEX IF EXISTS idx_exercises_tags;
+DROP INDEX IF EXISTS idx_exercises_search;
+
+ALTER TABLE exercises
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS steps_vi,
+    DROP COLUMN IF EXISTS description_vi,
+    DROP COLUMN IF EXISTS title_vi,
+    DROP COLUMN IF EXISTS steps,
+    DROP COLUMN IF EXISTS duration_minutes,
+    DROP COLUMN IF EXISTS difficulty,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS category;
+
+CREATE INDEX IF NOT EXISTS exercise_title_idx ON exercises USING GIN (to_tsvector('simple', title));
@@ -0,0 +1,39 @@
+-- Migration 000038: Rich exercise catalog
+-- Adds categories, tags, difficulty, estimated duration, step-by-step
+-- instructions, Vietnamese translations and a full-text search vector
+
+ALTER TABLE exercises
+    ADD COLUMN category VARCHAR(100),
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN difficulty VARCHAR(20) NOT NULL DEFAULT 'beginner'
+        CHECK (difficulty IN ('beginner', 'intermediate', 'advanced')),
+    ADD COLUMN duration_minutes INT CHECK (duration_minutes > 0),
+    ADD COLUMN steps TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN title_vi VARCHAR(255),
+    ADD COLUMN description_vi TEXT,
+    ADD COLUMN steps_vi TEXT[],
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- 'simple' config: the catalog is bilingual and PostgreSQL has no Vietnamese stemmer.
+-- Tags are matched separately since array_to_string is not immutable.
+ALTER TABLE exercises
+ADD COLUMN search_vector tsvector
+GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(title_vi, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(description_vi, '')), 'B')
+) STORED;
+
+DROP INDEX IF EXISTS exercise_title_idx;
+CREATE INDEX idx_exercises_search ON exercises USING GIN(search_vector);
+CREATE INDEX idx_exercises_tags ON exercises USING GIN(tags);
+CREATE INDEX idx_exercises_category ON exercises(category);
+
+CREATE TRIGGER update_exercises_updated_at BEFORE UPDATE
+    ON exercises FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN exercises.search_vector IS 'Full-text search vector: title/title_vi (weight A) + description/description_vi (weight B)';
+COMMENT ON COLUMN exercises.steps IS 'Ordered step-by-step instructions';
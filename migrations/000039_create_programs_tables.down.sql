@@ -0,0 +1,8 @@
+-- Rollback migration 000039: Drop programs tables
+
+DROP TABLE IF EXISTS program_day_progress;
+DROP TABLE IF EXISTS program_enrollments;
+DROP TABLE IF EXISTS program_day_items;
+DROP TABLE IF EXISTS program_days;
+DROP TRIGGER IF EXISTS update_programs_updated_at ON programs;
+DROP TABLE IF EXISTS programs;
@@ -0,0 +1,64 @@
+-- Migration 000039: Multi-week guided programs
+-- A program is a sequence of numbered days (day 8 is the first day of week 2); each
+-- day lists exercises and journal / learn templates. Day N unlocks N-1 days after
+-- the enrollment's start date in the user's timezone. Programs are authored in SQL.
+
+CREATE TABLE IF NOT EXISTS programs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    title_vi VARCHAR(255),
+    description TEXT,
+    description_vi TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_programs_updated_at BEFORE UPDATE
+    ON programs FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS program_days (
+    program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    day_number INT NOT NULL CHECK (day_number > 0),
+    title VARCHAR(255),
+    title_vi VARCHAR(255),
+    description TEXT,
+    description_vi TEXT,
+    PRIMARY KEY (program_id, day_number)
+);
+
+CREATE TABLE IF NOT EXISTS program_day_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL,
+    day_number INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    exercise_id UUID REFERENCES exercises(exercise_id) ON DELETE CASCADE,
+    template_id UUID REFERENCES journal_templates(id) ON DELETE CASCADE,
+    FOREIGN KEY (program_id, day_number) REFERENCES program_days(program_id, day_number) ON DELETE CASCADE,
+    CONSTRAINT chk_program_day_items_one_ref CHECK ((exercise_id IS NULL) <> (template_id IS NULL))
+);
+
+CREATE INDEX idx_program_day_items_day ON program_day_items(program_id, day_number, position);
+
+CREATE TABLE IF NOT EXISTS program_enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    enrolled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    UNIQUE (user_id, program_id)
+);
+
+CREATE INDEX idx_program_enrollments_user ON program_enrollments(user_id);
+
+CREATE TABLE IF NOT EXISTS program_day_progress (
+    enrollment_id UUID NOT NULL REFERENCES program_enrollments(id) ON DELETE CASCADE,
+    day_number INT NOT NULL,
+    completed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (enrollment_id, day_number)
+);
+
+COMMENT ON COLUMN program_day_items.template_id IS 'A journal template, or a learn collection (journal_templates.type = learn)';
+COMMENT ON COLUMN program_enrollments.start_date IS 'Local date of day 1 in the user''s timezone';
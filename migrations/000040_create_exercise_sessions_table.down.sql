@@ -0,0 +1,11 @@
+-- Rollback migration 000040: Drop exercise sessions
+
+DROP INDEX IF EXISTS idx_user_completed_exercises_user;
+
+ALTER TABLE user_completed_exercises
+    DROP COLUMN IF EXISTS session_id,
+    DROP COLUMN IF EXISTS duration_seconds,
+    ALTER COLUMN duration TYPE SMALLINT;
+
+DROP TRIGGER IF EXISTS update_exercise_sessions_updated_at ON exercise_sessions;
+DROP TABLE IF EXISTS exercise_sessions;
@@ -0,0 +1,39 @@
+-- Migration 000040: Exercise session telemetry
+-- A session is started, paused / resumed and finally completed or abandoned.
+-- active_seconds accumulates time spent active; resumed_at marks the start of the
+-- current active stretch. Completing a session records a user_completed_exercises row.
+
+CREATE TABLE IF NOT EXISTS exercise_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    exercise_id UUID NOT NULL REFERENCES exercises(exercise_id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'completed', 'abandoned')),
+    started_at TIMESTAMP NOT NULL,
+    resumed_at TIMESTAMP,
+    paused_at TIMESTAMP,
+    ended_at TIMESTAMP,
+    active_seconds INT NOT NULL DEFAULT 0 CHECK (active_seconds >= 0),
+    mood_before SMALLINT CHECK (mood_before BETWEEN 1 AND 10),
+    mood_after SMALLINT CHECK (mood_after BETWEEN 1 AND 10),
+    completed_steps INT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_exercise_sessions_user ON exercise_sessions(user_id, started_at DESC);
+
+CREATE TRIGGER update_exercise_sessions_updated_at BEFORE UPDATE
+    ON exercise_sessions FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- duration was a SMALLINT scanned into an int8 (max 127); widen it and record exact seconds
+ALTER TABLE user_completed_exercises
+    ALTER COLUMN duration TYPE INT,
+    ADD COLUMN duration_seconds INT CHECK (duration_seconds >= 0),
+    ADD COLUMN session_id UUID REFERENCES exercise_sessions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_user_completed_exercises_user ON user_completed_exercises(user_id, completed_at DESC);
+
+COMMENT ON COLUMN exercise_sessions.completed_steps IS 'Zero-based indexes of the exercise steps the user completed';
+COMMENT ON COLUMN user_completed_exercises.duration_seconds IS 'Measured active time of the session, NULL for completions logged without a session';
@@ -0,0 +1,10 @@
+-- Rollback migration 000041: Drop favourites and routines
+
+ALTER TABLE user_completed_exercises DROP COLUMN IF EXISTS routine_session_id;
+
+DROP TRIGGER IF EXISTS update_routine_sessions_updated_at ON routine_sessions;
+DROP TABLE IF EXISTS routine_sessions;
+DROP TABLE IF EXISTS routine_exercises;
+DROP TRIGGER IF EXISTS update_routines_updated_at ON routines;
+DROP TABLE IF EXISTS routines;
+DROP TABLE IF EXISTS exercise_favourites;
@@ -0,0 +1,61 @@
+-- Migration 000041: Exercise favourites and custom routines
+-- A routine is a named, ordered list of exercises played as one session; each
+-- step completed in a routine session is logged to user_completed_exercises.
+
+CREATE TABLE IF NOT EXISTS exercise_favourites (
+    user_id UUID NOT NULL,
+    exercise_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, exercise_id),
+    CONSTRAINT fk_exercise_favourites_exercise FOREIGN KEY (exercise_id)
+        REFERENCES exercises(exercise_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS routines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_routines_user ON routines(user_id, created_at DESC);
+
+CREATE TRIGGER update_routines_updated_at BEFORE UPDATE
+    ON routines FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS routine_exercises (
+    routine_id UUID NOT NULL REFERENCES routines(id) ON DELETE CASCADE,
+    position INT NOT NULL CHECK (position >= 0),
+    exercise_id UUID NOT NULL,
+    PRIMARY KEY (routine_id, position),
+    CONSTRAINT fk_routine_exercises_exercise FOREIGN KEY (exercise_id)
+        REFERENCES exercises(exercise_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS routine_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    routine_id UUID NOT NULL REFERENCES routines(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'completed', 'abandoned')),
+    position INT NOT NULL DEFAULT 0,
+    step_started_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_routine_sessions_user ON routine_sessions(user_id, started_at DESC);
+
+CREATE TRIGGER update_routine_sessions_updated_at BEFORE UPDATE
+    ON routine_sessions FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE user_completed_exercises
+    ADD COLUMN routine_session_id UUID REFERENCES routine_sessions(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN routine_sessions.position IS 'Zero-based index of the current step in the routine';
@@ -0,0 +1,9 @@
+-- Rollback migration 000042: Drop the media asset registry
+
+ALTER TABLE exercises
+    DROP CONSTRAINT IF EXISTS fk_exercises_media_asset,
+    DROP COLUMN IF EXISTS media_asset_id;
+
+DROP TABLE IF EXISTS media_asset_variants;
+DROP TRIGGER IF EXISTS update_media_assets_updated_at ON media_assets;
+DROP TABLE IF EXISTS media_assets;
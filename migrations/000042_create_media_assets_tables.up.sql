@@ -0,0 +1,42 @@
+-- Migration 000042: Media asset registry
+-- An asset is a piece of audio or video content; each locale has its own variant
+-- (e.g. a Vietnamese voice-over) stored under storage_key in the media storage.
+-- Exercises link to an asset through media_asset_id; templates reference assets by
+-- id from their slide definitions.
+
+CREATE TABLE IF NOT EXISTS media_assets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('audio', 'video')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_media_assets_kind ON media_assets(kind);
+
+CREATE TRIGGER update_media_assets_updated_at BEFORE UPDATE
+    ON media_assets FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS media_asset_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asset_id UUID NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    checksum CHAR(64) NOT NULL,
+    duration_seconds INT CHECK (duration_seconds > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (asset_id, locale),
+    CONSTRAINT fk_media_asset_variants_asset FOREIGN KEY (asset_id)
+        REFERENCES media_assets(id) ON DELETE CASCADE
+);
+
+ALTER TABLE exercises
+    ADD COLUMN media_asset_id UUID,
+    ADD CONSTRAINT fk_exercises_media_asset FOREIGN KEY (media_asset_id)
+        REFERENCES media_assets(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN media_asset_variants.checksum IS 'Hex-encoded SHA-256 of the stored file';
+COMMENT ON COLUMN media_asset_variants.created_at IS 'Time the current file was uploaded; replaced on re-upload';
@@ -0,0 +1,6 @@
+-- Rollback migration 000043: Drop guider chat threads
+
+ALTER TABLE ai_guider_chatlog DROP COLUMN IF EXISTS thread_id;
+
+DROP TRIGGER IF EXISTS update_chat_threads_updated_at ON chat_threads;
+DROP TABLE IF EXISTS chat_threads;
@@ -0,0 +1,49 @@
+-- Migration 000043: Guider chat threads
+-- A thread is a titled conversation with the guider, optionally about a journal.
+-- Existing chat logs are grouped into one thread per user and journal.
+
+CREATE TABLE IF NOT EXISTS chat_threads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    journal_id UUID REFERENCES user_journals(id) ON DELETE SET NULL,
+    last_message_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_chat_threads_user ON chat_threads(user_id, last_message_at DESC);
+CREATE INDEX idx_chat_threads_journal ON chat_threads(journal_id);
+
+CREATE TRIGGER update_chat_threads_updated_at BEFORE UPDATE
+    ON chat_threads FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE ai_guider_chatlog
+    ADD COLUMN thread_id UUID REFERENCES chat_threads(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_ai_guider_chatlog_thread ON ai_guider_chatlog(thread_id, created_at);
+
+-- Backfill: one thread per user and journal, titled after the journal. Threads of
+-- journals that no longer exist keep their messages but lose the journal link.
+CREATE TEMP TABLE chat_thread_backfill AS
+SELECT gen_random_uuid() AS thread_id, user_id, journal_id,
+       MIN(created_at) AS first_message_at, MAX(created_at) AS last_message_at
+FROM ai_guider_chatlog
+WHERE user_id IS NOT NULL AND journal_id IS NOT NULL
+GROUP BY user_id, journal_id;
+
+INSERT INTO chat_threads (id, user_id, title, journal_id, last_message_at, created_at)
+SELECT b.thread_id, b.user_id, LEFT(COALESCE(NULLIF(j.title, ''), 'Journal chat'), 200),
+       j.id, b.last_message_at, COALESCE(b.first_message_at, CURRENT_TIMESTAMP)
+FROM chat_thread_backfill b
+LEFT JOIN user_journals j ON j.id = b.journal_id;
+
+UPDATE ai_guider_chatlog c
+SET thread_id = b.thread_id
+FROM chat_thread_backfill b
+WHERE b.user_id = c.user_id AND b.journal_id = c.journal_id;
+
+DROP TABLE chat_thread_backfill;
+
+COMMENT ON COLUMN chat_threads.last_message_at IS 'Time of the latest message, used to order the thread list';
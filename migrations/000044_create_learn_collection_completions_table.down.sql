@@ -0,0 +1,3 @@
+-- Rollback migration 000044: Drop learn collection completions
+
+DROP TABLE IF EXISTS learn_collection_completions;
@@ -0,0 +1,10 @@
+-- Migration 000044: Record learn collection completions
+-- One row per user and collection, so "learn.collection_completed" is emitted once
+-- even when the last slide groups are submitted concurrently
+
+CREATE TABLE IF NOT EXISTS learn_collection_completions (
+    user_id UUID NOT NULL,
+    collection_id UUID NOT NULL,
+    completed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, collection_id)
+);
@@ -0,0 +1,11 @@
+-- Rollback migration 000045: Restore the previous exercise foreign keys
+
+ALTER TABLE user_completed_exercises
+    DROP CONSTRAINT IF EXISTS fk_user_completed_exercises_exercise,
+    ADD CONSTRAINT user_completed_exercises_exercise_id_fkey FOREIGN KEY (exercise_id)
+        REFERENCES exercises(exercise_id);
+
+ALTER TABLE exercise_sessions
+    DROP CONSTRAINT IF EXISTS fk_exercise_sessions_exercise,
+    ADD CONSTRAINT exercise_sessions_exercise_id_fkey FOREIGN KEY (exercise_id)
+        REFERENCES exercises(exercise_id) ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+-- Migration 000045: Keep users' exercise history when an exercise is deleted
+-- Sessions and completions now block deleting their exercise (the API answers 409)
+-- instead of sessions cascading away and completions failing the delete.
+
+ALTER TABLE exercise_sessions
+    DROP CONSTRAINT IF EXISTS exercise_sessions_exercise_id_fkey,
+    ADD CONSTRAINT fk_exercise_sessions_exercise FOREIGN KEY (exercise_id)
+        REFERENCES exercises(exercise_id) ON DELETE RESTRICT;
+
+ALTER TABLE user_completed_exercises
+    DROP CONSTRAINT IF EXISTS user_completed_exercises_exercise_id_fkey,
+    ADD CONSTRAINT fk_user_completed_exercises_exercise FOREIGN KEY (exercise_id)
+        REFERENCES exercises(exercise_id) ON DELETE RESTRICT;
@@ -0,0 +1,4 @@
+-- Rollback migration 000046: Allow several threads per journal again
+-- Merged duplicate threads are not restored.
+
+DROP INDEX IF EXISTS idx_chat_threads_user_journal;
@@ -0,0 +1,28 @@
+-- Migration 000046: One chat thread per user and journal
+-- Concurrent first messages about a journal could each create a thread. Duplicates
+-- are merged into the oldest thread before uniqueness is enforced.
+
+CREATE TEMP TABLE chat_thread_duplicates AS
+SELECT id, keep_id
+FROM (
+    SELECT id, FIRST_VALUE(id) OVER (PARTITION BY user_id, journal_id ORDER BY created_at, id) AS keep_id
+    FROM chat_threads
+    WHERE journal_id IS NOT NULL
+) ranked
+WHERE id <> keep_id;
+
+UPDATE ai_guider_chatlog c
+SET thread_id = d.keep_id
+FROM chat_thread_duplicates d
+WHERE c.thread_id = d.id;
+
+UPDATE chat_threads t
+SET last_message_at = (SELECT MAX(c.created_at) FROM ai_guider_chatlog c WHERE c.thread_id = t.id)
+WHERE t.id IN (SELECT keep_id FROM chat_thread_duplicates);
+
+DELETE FROM chat_threads WHERE id IN (SELECT id FROM chat_thread_duplicates);
+
+DROP TABLE chat_thread_duplicates;
+
+CREATE UNIQUE INDEX idx_chat_threads_user_journal ON chat_threads(user_id, journal_id)
+    WHERE journal_id IS NOT NULL;